@@ -7,25 +7,68 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 
 	"Modsec/clientside/auth"
+	"Modsec/clientside/breach"
+	"Modsec/clientside/cache"
+	"Modsec/clientside/client"
+	"Modsec/clientside/config"
+	"Modsec/clientside/generator"
+	"Modsec/clientside/schema"
 	"Modsec/clientside/service"
+	"Modsec/clientside/sshagent"
 
+	"Modsec/clientside/CipherAlgo/keymaster"
+	"Modsec/clientside/idle"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"golang.org/x/crypto/pbkdf2"
 )
 
+// EventVaultLocked is emitted to the frontend when the vault locks itself after inactivity
+const EventVaultLocked = "vault:locked"
+
+// EventVaultOffline is emitted with true when the vault falls back to the local cache and false when back online
+const EventVaultOffline = "vault:offline"
+
+// EventVaultChanged is emitted with a service.ChangeSet whenever a sync changes items or categories
+const EventVaultChanged = "vault:changed"
+
+// EventSyncConflicts is emitted with the number of offline writes waiting for the user to resolve a conflict
+const EventSyncConflicts = "sync:conflicts"
+
+// EventRotationProgress is emitted with a service.RotationProgress while the vault key is rotated
+const EventRotationProgress = "vault:rotation"
+
+// EventSSHConfirm is emitted with an sshagent.ConfirmRequest when ssh wants to use a key from the vault
+const EventSSHConfirm = "ssh:confirm"
+
+// EventSSHAgent is emitted with the sshagent.Status whenever the agent starts or stops
+const EventSSHAgent = "ssh:agent"
+
 // App struct
 type App struct {
-	ctx context.Context
+	ctx      context.Context
+	idle     *idle.Timer
+	sshAgent *sshagent.Server
+
+	statusMu  sync.Mutex // Guards the last status sent to the frontend, bindings run concurrently
+	offline   bool
+	conflicts int
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
-	return &App{}
+	a := &App{}
+	a.idle = idle.NewTimer(0, a.autoLock)
+	a.sshAgent = sshagent.NewServer(a.sshKeys, func(req sshagent.ConfirmRequest) {
+		runtime.EventsEmit(a.ctx, EventSSHConfirm, req)
+	})
+	return a
 }
 
 // startup is called when the app starts. The context is saved
@@ -33,6 +76,55 @@ func NewApp() *App {
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 	log.Println("ModSec application starting...")
+
+	pref, err := config.LoadPreferences()
+	if err != nil {
+		log.Printf("Failed to load preferences, using defaults: %v", err)
+	}
+	a.idle.SetTimeout(pref.AutoLockAfter())
+
+	service.OnVaultChange(func(changes service.ChangeSet) {
+		runtime.EventsEmit(a.ctx, EventVaultChanged, changes)
+	})
+}
+
+// touch records user activity so the vault does not auto-lock
+func (a *App) touch() {
+	a.idle.Touch()
+}
+
+// autoLock wipes the vault keys once the idle timer fires and tells the frontend
+func (a *App) autoLock() {
+	if keymaster.Keys.IsLocked() {
+		return
+	}
+
+	log.Println("Vault locked after inactivity")
+	keymaster.Keys.Lock()
+	service.ClearStore()
+	a.stopSSHAgent()
+	runtime.EventsEmit(a.ctx, EventVaultLocked, "inactivity")
+}
+
+// syncStatus tells the frontend when the service goes on or offline and when sync conflicts appear
+func (a *App) syncStatus() {
+	// Held while emitting so concurrent calls cannot send the events out of order
+	a.statusMu.Lock()
+	defer a.statusMu.Unlock()
+
+	if offline := service.IsOffline(); offline != a.offline {
+		a.offline = offline
+		runtime.EventsEmit(a.ctx, EventVaultOffline, offline)
+	}
+	conflicts, err := service.ConflictCount()
+	if err != nil {
+		log.Printf("ConflictCount error: %v", err)
+		return
+	}
+	if conflicts != a.conflicts {
+		a.conflicts = conflicts
+		runtime.EventsEmit(a.ctx, EventSyncConflicts, conflicts)
+	}
 }
 
 func (a *App) Greet(name string) string {
@@ -153,14 +245,19 @@ func (a *App) DecryptAES256GCM(ciphertext []byte, key []byte, IV []byte) ([]byte
 
 // RegisterUser handles the user registration process
 func (a *App) RegisterUser(email string, password string) (string, error) {
+	a.touch()
+
 	// Validate input
 	if !auth.ValidateEmailFormat(email) {
 		return "", fmt.Errorf("invalid email format")
 	}
 
-	valid, msg := auth.ValidatePasswordStrength(password)
+	valid, msg := auth.ValidatePasswordStrength(password, email)
 	if !valid {
-		return "", fmt.Errorf(msg)
+		return "", errors.New(msg)
+	}
+	if valid, msg := auth.ValidatePasswordNotBreached(a.ctx, password); !valid {
+		return "", errors.New(msg)
 	}
 
 	// Call the modularized registration function that now returns seedPhrase
@@ -169,114 +266,70 @@ func (a *App) RegisterUser(email string, password string) (string, error) {
 
 // LoginUser is a wails-exported function to handle login
 func (a *App) LoginUser(email, password string) map[string]interface{} {
+	a.touch()
+
 	// Add more detailed logging at entry point
 	log.Printf("LoginUser called for email: %s", email)
 
 	// Call the auth.LoginUser function
 	response, err := auth.LoginUser(email, password)
-
-	result := map[string]interface{}{
-		"success": false,
-		"message": "",
-	}
-
-	if err != nil {
-		errMsg := err.Error()
-		log.Printf("LoginUser error: %v", errMsg)
-
-		// Check for "login communication failed" first since it may contain other error messages
-		if strings.Contains(errMsg, "login communication failed") {
-			// Look for "Password not match" within the communication failed message
-			if strings.Contains(errMsg, "Password not match") {
-				result["message"] = "Incorrect password"
-				return result
-			}
-
-			// Check for this specific error message from your backend
-			if strings.Contains(errMsg, "An error occurred during login") {
-				// When this error happens during login attempt with valid email,
-				// it's typically a password issue
-				result["message"] = "Incorrect password"
-				return result
+	if errors.Is(err, client.ErrNetwork) {
+		// Server unreachable, open the cached vault instead
+		if offlineErr := auth.LoginOffline(email, password); offlineErr == nil {
+			log.Println("Login successful (offline)")
+			return map[string]interface{}{
+				"success": true,
+				"code":    client.CodeOffline,
+				"message": client.CodeOffline.Message(),
+				"offline": true,
 			}
-
-			// Extract more specific error message if available
-			parts := strings.Split(errMsg, "login communication failed: ")
-			if len(parts) > 1 && parts[1] != "" {
-				// Look for common error patterns within the extracted message
-				extractedMsg := parts[1]
-				if strings.Contains(extractedMsg, "Password not match") ||
-					strings.Contains(extractedMsg, "invalid password") {
-					result["message"] = "Incorrect password"
-				} else if strings.Contains(extractedMsg, "invalid email") ||
-					strings.Contains(extractedMsg, "email not found") {
-					result["message"] = "Email not found"
-				} else {
-					// Use the extracted message directly if it doesn't match known patterns
-					result["message"] = extractedMsg
-				}
-				return result
-			} else {
-				result["message"] = "Connection error"
-				return result
-			}
-		}
-
-		// Check for other specific error patterns
-		if strings.Contains(errMsg, "Password not match") {
-			result["message"] = "Incorrect password"
-		} else if strings.Contains(errMsg, "failed to decrypt vault key") {
-			result["message"] = "Incorrect password"
-		} else if strings.Contains(errMsg, "invalid password") {
-			result["message"] = "Incorrect password"
-		} else if strings.Contains(errMsg, "An error occurred during login") {
-			// Added this specific check for your error message
-			result["message"] = "Incorrect password"
-		} else if strings.Contains(errMsg, "invalid email") ||
-			strings.Contains(errMsg, "failed to find user") {
-			result["message"] = "Email not found"
-		} else if strings.Contains(errMsg, "login failed with status: 401") {
-			result["message"] = "Invalid email or password"
-		} else if strings.Contains(errMsg, "login failed with status: 500") {
-			// Status 500 often means password errors in your backend
-			result["message"] = "Incorrect password"
-		} else {
-			// Log the actual error for debugging but return a user-friendly message
-			log.Printf("Unrecognized login error: %v", errMsg)
-			result["message"] = "Server error. Please try again later"
+		} else if !errors.Is(offlineErr, cache.ErrNoCache) {
+			log.Printf("Offline login error: %v", offlineErr)
+			return errorResult(offlineErr)
 		}
-
-		return result
+	}
+	if err != nil {
+		log.Printf("LoginUser error: %v", err)
+		return errorResult(err)
 	}
 
 	// If no error but the response indicates failure
 	if response != nil && !response.Success {
-		// Handle backend response failure cases
 		log.Printf("Backend returned success=false with message: %s", response.Message)
-
-		// Check for known error patterns in the response message
-		if strings.Contains(strings.ToLower(response.Message), "password") {
-			result["message"] = "Incorrect password"
-		} else {
-			result["message"] = response.Message
+		return map[string]interface{}{
+			"success": false,
+			"code":    client.CodeUnknown,
+			"message": response.Message,
 		}
-		return result
 	}
 
 	// Success case
 	log.Println("Login successful")
-	result["success"] = true
-	result["message"] = "Login successful"
+	return map[string]interface{}{
+		"success": true,
+		"code":    client.CodeNone,
+		"message": "Login successful",
+	}
+}
 
-	return result
+// errorResult builds the failure map returned to the frontend, with a stable code to switch on
+func errorResult(err error) map[string]interface{} {
+	code := client.CodeOf(err)
+	return map[string]interface{}{
+		"success": false,
+		"code":    code,
+		"message": code.Message(),
+	}
 }
 
 // CheckSession verifies if the user has a valid session
 func (a *App) CheckSession() map[string]interface{} {
+	a.touch()
 	response, err := auth.SessionCheckUser()
 	if err != nil {
 		return map[string]interface{}{
 			"Success": false,
+			"Code":    client.CodeOf(err),
 			"Message": "Session invalid",
 		}
 	}
@@ -290,10 +343,14 @@ func (a *App) CheckSession() map[string]interface{} {
 
 // Add this function to your App struct to expose the LogoutUser functionality
 func (a *App) LogoutUser() map[string]interface{} {
+	a.idle.Stop()
+	a.stopSSHAgent()
+	defer service.ClearStore()
 	response, err := auth.LogoutUser()
 	if err != nil {
 		return map[string]interface{}{
 			"Success": false,
+			"Code":    client.CodeOf(err),
 			"Message": err.Error(),
 		}
 	}
@@ -306,28 +363,96 @@ func (a *App) LogoutUser() map[string]interface{} {
 
 // Add this function to expose RecoveryProcess to the frontend
 func (a *App) RecoveryProcess(email, password, seedPhrase string) (string, error) {
+	a.touch()
+
+	if valid, msg := auth.ValidatePasswordStrength(password, email); !valid {
+		return "", errors.New(msg)
+	}
+	if valid, msg := auth.ValidatePasswordNotBreached(a.ctx, password); !valid {
+		return "", errors.New(msg)
+	}
+
 	// Call the auth package's RecoveryProcess function
 	return auth.RecoveryProcess(email, password, seedPhrase)
 }
 
+// ChangeMasterPassword replaces the master password of the logged-in user, the seed phrase is not needed.
+// Other devices have to log in again with the new password.
+func (a *App) ChangeMasterPassword(oldPassword, newPassword string) error {
+	a.touch()
+
+	// A rotation in progress is saved encrypted with the current master key
+	if service.RotationInProgress() {
+		return errors.New("finish the vault key rotation before changing the master password")
+	}
+	email := keymaster.Keys.Email()
+	if valid, msg := auth.ValidatePasswordStrength(newPassword, email); !valid {
+		return errors.New(msg)
+	}
+	if valid, msg := auth.ValidatePasswordNotBreached(a.ctx, newPassword); !valid {
+		return errors.New(msg)
+	}
+
+	if err := auth.ChangeMasterPassword(a.ctx, oldPassword, newPassword); err != nil {
+		log.Printf("ChangeMasterPassword error: %v", err)
+		return err
+	}
+	return nil
+}
+
+// CheckPasswordStrength estimates the strength of a master password for the strength meter, with the
+// same rules registration and recovery enforce
+func (a *App) CheckPasswordStrength(password, email string) auth.PasswordCheck {
+	a.touch()
+	return auth.CheckPasswordStrength(password, email)
+}
+
 // Add this function to expose RecoverySetup to the frontend if needed
 func (a *App) RecoverySetup(email string) (string, error) {
+	a.touch()
 	return auth.RecoverySetup(email)
 }
 
+// RotateVaultKey replaces the vault key and reseals the vault, resuming a rotation that stopped halfway.
+// It returns the new recovery seed phrase, the old one stops working.
+func (a *App) RotateVaultKey() (string, error) {
+	a.touch()
+
+	seedPhrase, err := service.RotateVaultKey(a.ctx, func(progress service.RotationProgress) {
+		// Keep the vault from locking halfway through a long rotation
+		a.touch()
+		runtime.EventsEmit(a.ctx, EventRotationProgress, progress)
+	})
+	if err != nil {
+		log.Printf("RotateVaultKey error: %v", err)
+		return "", err
+	}
+	return seedPhrase, nil
+}
+
+// IsKeyRotationPending reports whether a vault key rotation stopped before it was committed
+func (a *App) IsKeyRotationPending() bool {
+	return service.RotationInProgress()
+}
+
 // CreateItemClient exposes the client-side service function to the frontend
-func (a *App) CreateItemClient(title, typename string, ItemData map[string]interface{}) (*service.CreateItemResponse, error) {
-	log.Printf("CreateItemClient called with title: %s, type: %s", title, typename)
-	return service.CreateItemClient(title, typename, ItemData)
+func (a *App) CreateItemClient(input service.ItemInput) (*service.CreateItemResponse, error) {
+	a.touch()
+	log.Printf("CreateItemClient called with title: %s", input.Title)
+	return service.CreateItemClient(input)
 }
 func (a *App) ToggleBookmark(itemId uint, bookmark bool) (*service.BookmarkResponse, error) {
+	a.touch()
 	return service.BookmarkClient(itemId, bookmark)
 }
 
 // Function to get the list of categories with their counts
 func (a *App) GetCategoryList() ([]map[string]interface{}, error) {
+	a.touch()
+
 	// Get actual category data from the service
 	items, categories, err := service.GetListItemClient()
+	a.syncStatus()
 	if err != nil {
 		log.Printf("Error getting category list: %v", err)
 		return nil, err
@@ -341,21 +466,11 @@ func (a *App) GetCategoryList() ([]map[string]interface{}, error) {
 	// Create a map to store item counts for each category
 	categoryCounts := make(map[uint]int)
 
-	// Count items per category
+	// Count items per category, Data is not decrypted in the list so use the item's own CategoryID
 	if items != nil {
 		for _, item := range *items {
-			// Check the item's Data map for CategoryID
-			if categoryID, ok := item.Data["CategoryID"]; ok {
-				if floatID, isFloat := categoryID.(float64); isFloat {
-					// Convert float64 to uint
-					categoryCounts[uint(floatID)]++
-				} else if intID, isInt := categoryID.(int); isInt {
-					// Handle int type
-					categoryCounts[uint(intID)]++
-				} else if uintID, isUint := categoryID.(uint); isUint {
-					// Handle uint type directly
-					categoryCounts[uintID]++
-				}
+			if item.CategoryID != nil {
+				categoryCounts[*item.CategoryID]++
 			}
 		}
 	}
@@ -375,25 +490,30 @@ func (a *App) GetCategoryList() ([]map[string]interface{}, error) {
 
 // Function to create a new category
 func (a *App) CreateCategoryClient(categoryName string) (*service.CreateCategoryResponse, error) {
+	a.touch()
 	return service.CreateCategoryClient(categoryName)
 }
 
 // Function to delete a category
 func (a *App) DeleteCategoryClient(categoryId uint) (*service.DeleteCategoryResponse, error) {
+	a.touch()
 	return service.DeleteCategoryClient(categoryId)
 }
 
 // Function to update an existing category
-func (a *App) UpdateItemClient(itemId uint, categoryId *uint, title string, ItemData map[string]interface{}) (*service.UpdateItemResponse, error) {
-	return service.UpdateItemClient(itemId, categoryId, title, ItemData)
+func (a *App) UpdateItemClient(itemId uint, categoryId *uint, input service.ItemInput) (*service.UpdateItemResponse, error) {
+	a.touch()
+	return service.UpdateItemClient(itemId, categoryId, input)
 }
 
 // GetPasswordList returns password items with their complete information including categories
 func (a *App) GetPasswordList() ([]map[string]interface{}, error) {
+	a.touch()
 	log.Println("GetPasswordList called from frontend")
 
 	// Get both items and categories from the client service
 	items, categories, err := service.GetListItemClient()
+	a.syncStatus()
 	if err != nil {
 		log.Printf("GetPasswordList error: %v", err)
 		return nil, err
@@ -447,8 +567,234 @@ func (a *App) GetPasswordList() ([]map[string]interface{}, error) {
 	return result, nil
 }
 
+// GetItemDetail decrypts the full Data of one item, the list only carries titles and metadata
+func (a *App) GetItemDetail(itemId uint) (*service.AfterItem, error) {
+	a.touch()
+
+	item, err := service.GetItemDetail(a.ctx, itemId)
+	if err != nil {
+		log.Printf("GetItemDetail error: %v", err)
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetTOTPCode returns the current one-time code of an item and the seconds it stays valid.
+// The frontend polls it every period, so it does not count as activity; clicks are reported by ReportActivity.
+func (a *App) GetTOTPCode(itemId uint) (*service.TOTPCode, error) {
+	code, err := service.GetTOTPCode(a.ctx, itemId)
+	if err != nil {
+		log.Printf("GetTOTPCode error: %v", err)
+		return nil, err
+	}
+	return code, nil
+}
+
+// GetVaultHealthReport audits the vault for reused, weak and old passwords, http links and expiring cards
+func (a *App) GetVaultHealthReport() (*service.HealthReport, error) {
+	a.touch()
+
+	report, err := service.GetVaultHealthReport(a.ctx, service.DefaultHealthOptions())
+	if err != nil {
+		log.Printf("GetVaultHealthReport error: %v", err)
+		return nil, err
+	}
+	return report, nil
+}
+
+// CheckPasswordBreach returns how many times password appears in known data breaches.
+// Only the first 5 characters of its SHA-1 hash are sent to the range endpoint.
+func (a *App) CheckPasswordBreach(password string) (int, error) {
+	a.touch()
+	count, err := breach.Count(a.ctx, password)
+	if err != nil {
+		log.Printf("CheckPasswordBreach error: %v", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetBreachedPasswords lists the website items whose password appears in known data breaches
+func (a *App) GetBreachedPasswords() ([]service.BreachedPassword, error) {
+	a.touch()
+
+	breached, err := service.GetBreachedPasswords(a.ctx)
+	if err != nil {
+		log.Printf("GetBreachedPasswords error: %v", err)
+		return nil, err
+	}
+	return breached, nil
+}
+
+// GetPasswordHistory returns the previous passwords of a website item, newest first
+func (a *App) GetPasswordHistory(itemId uint) (schema.PasswordHistory, error) {
+	a.touch()
+
+	history, err := service.GetPasswordHistory(a.ctx, itemId)
+	if err != nil {
+		log.Printf("GetPasswordHistory error: %v", err)
+		return nil, err
+	}
+	return history, nil
+}
+
+// RestorePassword makes a previous password of a website item current again
+func (a *App) RestorePassword(itemId uint, index int) (*service.UpdateItemResponse, error) {
+	a.touch()
+	log.Printf("RestorePassword called for item %d, entry %d", itemId, index)
+
+	response, err := service.RestorePassword(a.ctx, itemId, index)
+	if err != nil {
+		log.Printf("RestorePassword error: %v", err)
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetItemVersions returns the earlier versions of an item kept by the server, newest first
+func (a *App) GetItemVersions(itemId uint) ([]service.AfterItemVersion, error) {
+	a.touch()
+
+	versions, err := service.GetItemVersions(a.ctx, itemId)
+	if err != nil {
+		log.Printf("GetItemVersions error: %v", err)
+		return nil, err
+	}
+	return versions, nil
+}
+
+// DiffItemVersions compares two versions of an item, version 0 being the item as it is now
+func (a *App) DiffItemVersions(itemId uint, from uint, to uint) (*service.ItemDiff, error) {
+	a.touch()
+
+	diff, err := service.DiffItemVersions(a.ctx, itemId, from, to)
+	if err != nil {
+		log.Printf("DiffItemVersions error: %v", err)
+		return nil, err
+	}
+	return diff, nil
+}
+
+// RestoreItemVersion makes an earlier version of an item current again
+func (a *App) RestoreItemVersion(itemId uint, version uint) (*service.UpdateItemResponse, error) {
+	a.touch()
+	log.Printf("RestoreItemVersion called for item %d, version %d", itemId, version)
+
+	response, err := service.RestoreItemVersion(a.ctx, itemId, version)
+	if err != nil {
+		log.Printf("RestoreItemVersion error: %v", err)
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetAttachments lists the files attached to an item
+func (a *App) GetAttachments(itemId uint) ([]service.Attachment, error) {
+	a.touch()
+
+	attachments, err := service.GetAttachments(a.ctx, itemId)
+	if err != nil {
+		log.Printf("GetAttachments error: %v", err)
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// AddAttachment asks for a file with the native open dialog and attaches it to an item. It returns nil
+// when the dialog is cancelled.
+func (a *App) AddAttachment(itemId uint) (*service.Attachment, error) {
+	a.touch()
+
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{Title: "Attach a file"})
+	if err != nil || path == "" {
+		return nil, err
+	}
+	log.Printf("AddAttachment called for item %d", itemId)
+
+	attached, err := service.UploadAttachment(a.ctx, itemId, path)
+	if err != nil {
+		log.Printf("AddAttachment error: %v", err)
+		return nil, err
+	}
+	return attached, nil
+}
+
+// SaveAttachment asks where to save an attachment with the native save dialog and decrypts it there.
+// It returns the path written, or an empty string when the dialog is cancelled.
+func (a *App) SaveAttachment(itemId uint, attachmentId uint, name string) (string, error) {
+	a.touch()
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Save attachment",
+		DefaultFilename: name,
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	log.Printf("SaveAttachment called for item %d, attachment %d", itemId, attachmentId)
+
+	if err := service.DownloadAttachment(a.ctx, itemId, attachmentId, path); err != nil {
+		log.Printf("SaveAttachment error: %v", err)
+		return "", err
+	}
+	return path, nil
+}
+
+// DeleteAttachment removes an attachment from its item
+func (a *App) DeleteAttachment(attachmentId uint) error {
+	a.touch()
+	log.Printf("DeleteAttachment called for attachment %d", attachmentId)
+
+	if err := service.DeleteAttachment(a.ctx, attachmentId); err != nil {
+		log.Printf("DeleteAttachment error: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetItemTypes lists the item types the vault can hold, with their sensitive and display fields
+func (a *App) GetItemTypes() []schema.TypeInfo {
+	a.touch()
+	return schema.Types()
+}
+
+// GeneratePassword generates a random password that satisfies the policy
+func (a *App) GeneratePassword(policy generator.PasswordPolicy) (*generator.Result, error) {
+	a.touch()
+	result, err := generator.Password(policy)
+	if err != nil {
+		log.Printf("GeneratePassword error: %v", err)
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GeneratePassphrase generates a passphrase from the BIP39 wordlist that satisfies the policy
+func (a *App) GeneratePassphrase(policy generator.PassphrasePolicy) (*generator.Result, error) {
+	a.touch()
+	result, err := generator.Passphrase(policy)
+	if err != nil {
+		log.Printf("GeneratePassphrase error: %v", err)
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetDefaultPasswordPolicy returns the policy the password generator starts with
+func (a *App) GetDefaultPasswordPolicy() generator.PasswordPolicy {
+	a.touch()
+	return generator.DefaultPasswordPolicy()
+}
+
+// GetDefaultPassphrasePolicy returns the policy the passphrase generator starts with
+func (a *App) GetDefaultPassphrasePolicy() generator.PassphrasePolicy {
+	a.touch()
+	return generator.DefaultPassphrasePolicy()
+}
+
 // DeleteItemClient exposes the delete item functionality to the frontend
 func (a *App) DeleteItemClient(itemId uint) (*service.DeleteItemResponse, error) {
+	a.touch()
 	log.Printf("DeleteItemClient called with itemId: %d", itemId)
 
 	response, err := service.DeleteItemClient(itemId)
@@ -463,6 +809,7 @@ func (a *App) DeleteItemClient(itemId uint) (*service.DeleteItemResponse, error)
 
 // UpdateCategoryClient exposes the category update functionality to the frontend
 func (a *App) UpdateCategoryClient(categoryId uint, categoryName string) (*service.UpdateCategoryResponse, error) {
+	a.touch()
 	log.Printf("UpdateCategoryClient called with categoryId: %d, name: %s", categoryId, categoryName)
 
 	response, err := service.UpdateCategoryClient(categoryId, categoryName)
@@ -474,3 +821,207 @@ func (a *App) UpdateCategoryClient(categoryId uint, categoryName string) (*servi
 	log.Printf("Successfully updated category %d, response: %+v", categoryId, response)
 	return response, nil
 }
+
+// GetConnectionSettings returns the backend settings currently in use
+func (a *App) GetConnectionSettings() config.Config {
+	a.touch()
+	return config.Get()
+}
+
+// UpdateConnectionSettings validates and saves new backend settings and applies them immediately
+func (a *App) UpdateConnectionSettings(cfg config.Config) error {
+	a.touch()
+	log.Printf("UpdateConnectionSettings called with backend: %s", cfg.BackendURL)
+
+	if err := config.Set(cfg); err != nil {
+		log.Printf("Error saving connection settings: %v", err)
+		return err
+	}
+
+	if err := client.Configure(config.Get()); err != nil {
+		log.Printf("Error applying connection settings: %v", err)
+		return err
+	}
+	breach.Configure(config.Get())
+
+	return nil
+}
+
+// LockVault wipes the vault keys from memory without logging out
+func (a *App) LockVault() {
+	log.Println("LockVault called")
+	a.idle.Stop()
+	keymaster.Keys.Lock()
+	service.ClearStore()
+	a.stopSSHAgent()
+}
+
+// UnlockVault reopens the vault with the master password
+func (a *App) UnlockVault(password string) map[string]interface{} {
+	a.touch()
+	if err := keymaster.Keys.Unlock(password); err != nil {
+		log.Printf("UnlockVault error: %v", err)
+		return errorResult(err)
+	}
+
+	return map[string]interface{}{
+		"success": true,
+		"code":    client.CodeNone,
+		"message": "Vault unlocked",
+	}
+}
+
+// IsVaultLocked reports whether the vault keys are currently wiped
+func (a *App) IsVaultLocked() bool {
+	return keymaster.Keys.IsLocked()
+}
+
+// IsOffline reports whether the vault is being served from the local cache
+func (a *App) IsOffline() bool {
+	return service.IsOffline()
+}
+
+// SyncVault fetches what changed since the last sync. The changes are also emitted as EventVaultChanged.
+func (a *App) SyncVault() (service.ChangeSet, error) {
+	a.touch()
+
+	changes, err := service.SyncVault(a.ctx)
+	a.syncStatus()
+	if err != nil {
+		log.Printf("SyncVault error: %v", err)
+		return service.ChangeSet{}, err
+	}
+	// Rebinds ciphertext written before item binding, once per session
+	go func() {
+		if _, err := service.MigrateBindings(a.ctx); err != nil {
+			log.Printf("MigrateBindings error: %v", err)
+		}
+	}()
+	return changes, nil
+}
+
+// GetPendingChangeCount returns how many offline changes have not reached the server yet
+func (a *App) GetPendingChangeCount() (int, error) {
+	return service.PendingCount()
+}
+
+// GetSyncConflicts returns offline changes that clashed with the server copy, with both versions
+func (a *App) GetSyncConflicts() ([]service.SyncConflict, error) {
+	a.touch()
+	return service.GetConflicts()
+}
+
+// ResolveSyncConflict keeps the local version, the server version or both for a conflict
+func (a *App) ResolveSyncConflict(conflictId string, resolution service.Resolution) error {
+	a.touch()
+
+	if err := service.ResolveConflict(conflictId, resolution); err != nil {
+		log.Printf("ResolveSyncConflict error: %v", err)
+		return err
+	}
+	a.syncStatus()
+	return nil
+}
+
+// ReportActivity is called by the frontend on user input to keep the vault unlocked
+func (a *App) ReportActivity() {
+	a.touch()
+}
+
+// GetAutoLockSettings returns the saved auto-lock preferences
+func (a *App) GetAutoLockSettings() config.Preferences {
+	a.touch()
+	return config.GetPreferences()
+}
+
+// UpdateAutoLockSettings saves the auto-lock preferences and applies the new timeout
+func (a *App) UpdateAutoLockSettings(pref config.Preferences) error {
+	a.touch()
+	log.Printf("UpdateAutoLockSettings called: enabled=%v, minutes=%d", pref.AutoLock, pref.AutoLockMinutes)
+
+	if err := config.SetPreferences(pref); err != nil {
+		log.Printf("Error saving auto-lock settings: %v", err)
+		return err
+	}
+
+	a.idle.SetTimeout(pref.AutoLockAfter())
+	return nil
+}
+
+// sshKeys hands the SSH keys of the unlocked vault to the agent
+func (a *App) sshKeys(ctx context.Context) ([]sshagent.Key, error) {
+	items, err := service.DecryptItemsOfType(ctx, schema.TypeSSHKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]sshagent.Key, 0, len(items))
+	for _, item := range items {
+		if item.Data == nil || item.Data.SSHKey == nil {
+			continue
+		}
+		keys = append(keys, sshagent.Key{ItemID: item.ItemID, Title: item.Title, Data: item.Data.SSHKey})
+	}
+	return keys, nil
+}
+
+// stopSSHAgent stops serving keys, the vault is locking or the user is logging out
+func (a *App) stopSSHAgent() {
+	if !a.sshAgent.Status().Running {
+		return
+	}
+	a.sshAgent.Stop()
+	runtime.EventsEmit(a.ctx, EventSSHAgent, a.sshAgent.Status())
+}
+
+// GenerateSSHKey creates a new key pair for an SSH key item, bits only applies to RSA
+func (a *App) GenerateSSHKey(algorithm sshagent.KeyAlgorithm, bits int, comment string) (*schema.SSHKey, error) {
+	a.touch()
+
+	key, err := sshagent.GenerateKey(algorithm, bits, comment)
+	if err != nil {
+		log.Printf("GenerateSSHKey error: %v", err)
+		return nil, err
+	}
+	return key, nil
+}
+
+// StartSSHAgent serves the vault's SSH keys on a Unix socket until the vault locks
+func (a *App) StartSSHAgent() (sshagent.Status, error) {
+	a.touch()
+	if keymaster.Keys.IsLocked() {
+		return a.sshAgent.Status(), keymaster.ErrVaultLocked
+	}
+
+	path, err := sshagent.DefaultSocketPath()
+	if err != nil {
+		log.Printf("StartSSHAgent error: %v", err)
+		return a.sshAgent.Status(), err
+	}
+	if err := a.sshAgent.Start(path); err != nil {
+		log.Printf("StartSSHAgent error: %v", err)
+		return a.sshAgent.Status(), err
+	}
+
+	status := a.sshAgent.Status()
+	runtime.EventsEmit(a.ctx, EventSSHAgent, status)
+	return status, nil
+}
+
+// StopSSHAgent stops serving SSH keys
+func (a *App) StopSSHAgent() sshagent.Status {
+	a.touch()
+	a.stopSSHAgent()
+	return a.sshAgent.Status()
+}
+
+// GetSSHAgentStatus reports whether the agent is running and the socket to point SSH_AUTH_SOCK at
+func (a *App) GetSSHAgentStatus() sshagent.Status {
+	return a.sshAgent.Status()
+}
+
+// ResolveSSHConfirmation answers an EventSSHConfirm request
+func (a *App) ResolveSSHConfirmation(requestId string, allow bool) error {
+	a.touch()
+	return a.sshAgent.Resolve(requestId, allow)
+}
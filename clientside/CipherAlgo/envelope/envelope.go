@@ -0,0 +1,209 @@
+package envelope
+
+// Self-describing ciphertext bound to what it encrypts. The header names the format version, the AEAD
+// algorithm and the ID of the key, so algorithms and keys can be rotated while older ciphertext stays
+// readable. The header and the binding (kind, ID, item type and field) are authenticated as associated
+// data, so a ciphertext copied onto another item or field fails to open even though it was sealed with
+// the same key.
+//
+// Layout (version 2): magic "MSE", version byte, algorithm byte, key ID (uint32), nonce, ciphertext with
+// tag. The nonce is 12 bytes for AES-256-GCM and 24 bytes for XChaCha20-Poly1305. The key ID is a
+// fingerprint of the key, so ciphertext sealed with a retired key is recognised without trying it.
+// Ciphertext from before envelopes, written by utils.EncryptAES256GCM (nonce and ciphertext, no header) is reported as ErrLegacy. A legacy nonce can
+// start with the magic by chance, so callers that still read legacy data try it as legacy whenever Open
+// fails, not only on ErrLegacy.
+
+import (
+	"Modsec/clientside/CipherAlgo/utils"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+var (
+	// ErrLegacy is returned by Open for ciphertext written before envelopes, which has no header
+	ErrLegacy = errors.New("ciphertext is not bound")
+	// ErrMismatch is returned when a ciphertext is bound to something else, was modified or the key is wrong
+	ErrMismatch = errors.New("ciphertext does not match its binding")
+	// ErrUnsupported is returned for a format version or algorithm this client does not know
+	ErrUnsupported = errors.New("unsupported ciphertext format")
+	// ErrWrongKey is returned when a ciphertext names another key than the one it is opened with
+	ErrWrongKey = errors.New("ciphertext was sealed with another key")
+)
+
+// Version is the format version Seal writes
+const Version byte = 2
+
+// Algorithm identifies the AEAD a ciphertext was sealed with
+type Algorithm byte
+
+const (
+	AES256GCM         Algorithm = 1
+	XChaCha20Poly1305 Algorithm = 2
+)
+
+// DefaultAlgorithm is the algorithm Seal uses
+const DefaultAlgorithm = AES256GCM
+
+func (a Algorithm) String() string {
+	switch a {
+	case AES256GCM:
+		return "AES-256-GCM"
+	case XChaCha20Poly1305:
+		return "XChaCha20-Poly1305"
+	}
+	return fmt.Sprintf("algorithm %d", byte(a))
+}
+
+const (
+	magic      = "MSE"
+	headerLen  = len(magic) + 1 + 1 + 4
+	keySize    = 32
+	minPayload = 12
+)
+
+// Header describes how a ciphertext was sealed
+type Header struct {
+	Version   byte
+	Algorithm Algorithm
+	KeyID     uint32 // Fingerprint of the key that sealed it
+}
+
+// KeyID returns the fingerprint of key written into headers. It is never 0.
+func KeyID(key []byte) uint32 {
+	sum := sha256.Sum256(append([]byte("modsec key id "), key...))
+	if id := binary.BigEndian.Uint32(sum[:4]); id != 0 {
+		return id
+	}
+	return 1
+}
+
+// Binding names what a ciphertext belongs to. It is authenticated with the ciphertext but not stored in it.
+type Binding struct {
+	Kind   string // "item", "category", "attachment", "cache" or "vaultkey"
+	ID     uint   // ID of the record, 0 while the server has not assigned one
+	Parent uint   // ID of the item an attachment belongs to, 0 for other kinds
+	Type   string // Type name of the item, empty for other kinds
+	Field  string // Field within the item, such as "title" or "data"
+}
+
+// aad encodes the header and b without ambiguity. Parent comes last and only when set, so bindings without
+// one encode as they did before it existed.
+func (b Binding) aad(header []byte) []byte {
+	out := append([]byte(nil), header...)
+	out = binary.BigEndian.AppendUint64(out, uint64(b.ID))
+	for _, part := range []string{b.Kind, b.Type, b.Field} {
+		out = binary.BigEndian.AppendUint16(out, uint16(len(part)))
+		out = append(out, part...)
+	}
+	if b.Parent != 0 {
+		out = binary.BigEndian.AppendUint64(out, uint64(b.Parent))
+	}
+	return out
+}
+
+func (b Binding) String() string {
+	if b.Parent != 0 {
+		return fmt.Sprintf("%s %d of %d %s.%s", b.Kind, b.ID, b.Parent, b.Type, b.Field)
+	}
+	return fmt.Sprintf("%s %d %s.%s", b.Kind, b.ID, b.Type, b.Field)
+}
+
+// IsLegacy reports whether ciphertext was written without an envelope
+func IsLegacy(ciphertext []byte) bool {
+	return len(ciphertext) < headerLen+minPayload || string(ciphertext[:len(magic)]) != magic
+}
+
+// ParseHeader reads the header of a ciphertext without opening it
+func ParseHeader(ciphertext []byte) (Header, error) {
+	if IsLegacy(ciphertext) {
+		return Header{}, ErrLegacy
+	}
+	if version := ciphertext[len(magic)]; version != Version {
+		return Header{}, fmt.Errorf("%w: version %d", ErrUnsupported, version)
+	}
+	return Header{
+		Version:   Version,
+		Algorithm: Algorithm(ciphertext[len(magic)+1]),
+		KeyID:     binary.BigEndian.Uint32(ciphertext[len(magic)+2 : headerLen]),
+	}, nil
+}
+
+// IsSealed reports whether ciphertext carries an envelope this client can open
+func IsSealed(ciphertext []byte) bool {
+	_, err := ParseHeader(ciphertext)
+	return err == nil
+}
+
+// Seal encrypts plaintext with key using DefaultAlgorithm and binds it to b
+func Seal(plaintext, key []byte, b Binding) ([]byte, error) {
+	return SealWith(DefaultAlgorithm, plaintext, key, b)
+}
+
+// SealWith encrypts plaintext with key using alg and binds it to b
+func SealWith(alg Algorithm, plaintext, key []byte, b Binding) ([]byte, error) {
+	aead, err := newAEAD(alg, key)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := utils.GenerateRandomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, headerLen+len(nonce)+len(plaintext)+aead.Overhead())
+	out = append(out, magic...)
+	out = append(out, Version, byte(alg))
+	out = binary.BigEndian.AppendUint32(out, KeyID(key))
+	header := out
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, b.aad(header)), nil
+}
+
+// Open decrypts ciphertext sealed for b with key
+func Open(ciphertext, key []byte, b Binding) ([]byte, error) {
+	h, err := ParseHeader(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if h.KeyID != KeyID(key) {
+		return nil, fmt.Errorf("%w: key %08x", ErrWrongKey, h.KeyID)
+	}
+	aead, err := newAEAD(h.Algorithm, key)
+	if err != nil {
+		return nil, err
+	}
+
+	body := ciphertext[headerLen:]
+	if len(body) < aead.NonceSize() {
+		return nil, fmt.Errorf("%w: truncated nonce", ErrMismatch)
+	}
+	nonce := body[:aead.NonceSize()]
+	plaintext, err := aead.Open(nil, nonce, body[aead.NonceSize():], b.aad(ciphertext[:headerLen]))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrMismatch, b)
+	}
+	return plaintext, nil
+}
+
+func newAEAD(alg Algorithm, key []byte) (cipher.AEAD, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("invalid key length: expected %d, got %d", keySize, len(key))
+	}
+	switch alg {
+	case AES256GCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+		}
+		return cipher.NewGCM(block)
+	case XChaCha20Poly1305:
+		return chacha20poly1305.NewX(key)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupported, alg)
+}
@@ -0,0 +1,107 @@
+package envelope
+
+import (
+	"Modsec/clientside/CipherAlgo/utils"
+	"errors"
+	"testing"
+)
+
+func TestBinding(t *testing.T) {
+	key := make([]byte, 32)
+	title := Binding{Kind: "item", ID: 7, Type: "login", Field: "title"}
+
+	sealed, err := Seal([]byte("Mail"), key, title)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := Open(sealed, key, title); err != nil || string(plain) != "Mail" {
+		t.Fatalf("Open = %q, %v", plain, err)
+	}
+
+	// Moving the ciphertext to another item, type or field is detected
+	for _, other := range []Binding{
+		{Kind: "item", ID: 8, Type: "login", Field: "title"},
+		{Kind: "item", ID: 7, Type: "credit", Field: "title"},
+		{Kind: "item", ID: 7, Type: "login", Field: "data"},
+		{Kind: "category", ID: 7, Field: "title"},
+		{Kind: "item", ID: 7, Parent: 1, Type: "login", Field: "title"},
+	} {
+		if _, err := Open(sealed, key, other); !errors.Is(err, ErrMismatch) {
+			t.Errorf("opened as %s: %v", other, err)
+		}
+	}
+
+	// Lengths are part of the encoding, so fields cannot borrow bytes from each other
+	a := Binding{Kind: "item", Type: "ab", Field: "c"}
+	b := Binding{Kind: "item", Type: "a", Field: "bc"}
+	sealed, _ = Seal([]byte("x"), key, a)
+	if _, err := Open(sealed, key, b); !errors.Is(err, ErrMismatch) {
+		t.Errorf("ambiguous binding opened: %v", err)
+	}
+}
+
+func TestLegacy(t *testing.T) {
+	key := make([]byte, 32)
+	legacy, err := utils.EncryptAES256GCM([]byte("old"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsLegacy(legacy) {
+		t.Fatal("legacy ciphertext not recognised")
+	}
+	if _, err := Open(legacy, key, Binding{Kind: "item", ID: 1}); !errors.Is(err, ErrLegacy) {
+		t.Fatalf("Open legacy: %v", err)
+	}
+}
+
+func TestAlgorithms(t *testing.T) {
+	key := make([]byte, 32)
+	b := Binding{Kind: "item", ID: 3, Type: "note", Field: "data"}
+
+	for _, alg := range []Algorithm{AES256GCM, XChaCha20Poly1305} {
+		sealed, err := SealWith(alg, []byte("secret"), key, b)
+		if err != nil {
+			t.Fatalf("%s: %v", alg, err)
+		}
+		h, err := ParseHeader(sealed)
+		if err != nil || h != (Header{Version: Version, Algorithm: alg, KeyID: KeyID(key)}) {
+			t.Fatalf("%s: header = %+v, %v", alg, h, err)
+		}
+		if plain, err := Open(sealed, key, b); err != nil || string(plain) != "secret" {
+			t.Fatalf("%s: Open = %q, %v", alg, plain, err)
+		}
+
+		// Another key is recognised from the header, and the header cannot be changed to hide that
+		other := make([]byte, 32)
+		other[0] = 1
+		if _, err := Open(sealed, other, b); !errors.Is(err, ErrWrongKey) {
+			t.Errorf("%s: opened with another key: %v", alg, err)
+		}
+		sealed[len(magic)+5] ^= 1
+		if _, err := Open(sealed, key, b); !errors.Is(err, ErrWrongKey) {
+			t.Errorf("%s: opened with a changed key ID: %v", alg, err)
+		}
+		sealed[len(magic)+5] ^= 1
+		sealed[len(magic)+1] = byte(AES256GCM + XChaCha20Poly1305 - alg)
+		if _, err := Open(sealed, key, b); err == nil {
+			t.Errorf("%s: opened with a changed algorithm", alg)
+		}
+	}
+
+	if _, err := SealWith(7, []byte("x"), key, b); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("sealed with an unknown algorithm: %v", err)
+	}
+}
+
+func TestUnknownVersion(t *testing.T) {
+	key := make([]byte, 32)
+	b := Binding{Kind: "category", ID: 4, Field: "name"}
+
+	sealed, _ := Seal([]byte("Work"), key, b)
+	for _, version := range []byte{1, 3} {
+		sealed[len(magic)] = version
+		if _, err := Open(sealed, key, b); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("version %d: %v", version, err)
+		}
+	}
+}
@@ -3,25 +3,243 @@ package keymaster
 // This will be critical part for encryption act similar to global varible but only function that has this package
 // can access these key
 
-var Masterkey []byte
-var Vaultkey []byte
-var Sessionkey []byte
-var IVKey []byte
+import (
+	"Modsec/clientside/CipherAlgo/envelope"
+	"Modsec/clientside/CipherAlgo/utils"
+	"Modsec/clientside/vaulterr"
+	"sync"
+)
 
-func GetSessionkey() []byte {
-	return Sessionkey
+// Errors of the key holder, defined in vaulterr
+var (
+	ErrVaultLocked   = vaulterr.ErrLocked
+	ErrWrongPassword = vaulterr.ErrWrongPassword
+	ErrNoVault       = vaulterr.ErrNoVault
+)
+
+// KeyHolder keeps the key material of the logged-in user.
+// Getters hand out copies so callers can Wipe them once done.
+type KeyHolder struct {
+	mu sync.RWMutex
+
+	email             string
+	protectedVaultkey []byte // Vault key encrypted with the master key, kept so Unlock works offline
+	bound             bool   // protectedVaultkey says every item is bound to its ID, see Protect
+
+	masterkey  []byte
+	vaultkey   []byte
+	sessionkey []byte
+}
+
+// Keys holds the keys for the current user
+var Keys = &KeyHolder{}
+
+// Wipe overwrites b with zeros
+func Wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+func clone(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	out := make([]byte, len(b))
+	copy(out, b)
+	return out
+}
+
+// Bindings of the vault key wrapped by the master key. A vault whose items are all bound to their IDs
+// has its key wrapped with boundVaultkeyBinding, so the server cannot make a client believe the vault
+// still holds unbound ciphertext.
+var (
+	vaultkeyBinding      = envelope.Binding{Kind: "vaultkey"}
+	boundVaultkeyBinding = envelope.Binding{Kind: "vaultkey", Field: "bound"}
+)
+
+// Protect wraps vaultkey with masterkey for storage on the server and in the cache. bound records that
+// every item of the vault is bound to its ID.
+func Protect(vaultkey, masterkey []byte, bound bool) ([]byte, error) {
+	if bound {
+		return envelope.Seal(vaultkey, masterkey, boundVaultkeyBinding)
+	}
+	return envelope.Seal(vaultkey, masterkey, vaultkeyBinding)
+}
+
+// Unprotect opens a vault key wrapped by Protect or, for older accounts, without an envelope
+func Unprotect(protectedVaultkey, masterkey []byte) ([]byte, error) {
+	if vaultkey, err := envelope.Open(protectedVaultkey, masterkey, boundVaultkeyBinding); err == nil {
+		return vaultkey, nil
+	}
+	if vaultkey, err := envelope.Open(protectedVaultkey, masterkey, vaultkeyBinding); err == nil {
+		return vaultkey, nil
+	}
+	// Not only on ErrLegacy, see the envelope package
+	return utils.DecryptAES256GCM(protectedVaultkey, masterkey)
+}
+
+// isBound reports whether protectedVaultkey was wrapped by Protect with bound set
+func isBound(protectedVaultkey, masterkey []byte) bool {
+	vaultkey, err := envelope.Open(protectedVaultkey, masterkey, boundVaultkeyBinding)
+	Wipe(vaultkey)
+	return err == nil
+}
+
+// Open decrypts protectedVaultkey with masterkey and unlocks the vault
+func (k *KeyHolder) Open(email string, masterkey, protectedVaultkey []byte) error {
+	vaultkey, err := Unprotect(protectedVaultkey, masterkey)
+	if err != nil {
+		return ErrWrongPassword
+	}
+
+	k.Store(email, masterkey, vaultkey, protectedVaultkey)
+	Wipe(vaultkey)
+	return nil
+}
+
+// Store replaces the held keys with copies of the given ones
+func (k *KeyHolder) Store(email string, masterkey, vaultkey, protectedVaultkey []byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.wipeKeys()
+	k.email = email
+	k.masterkey = clone(masterkey)
+	k.vaultkey = clone(vaultkey)
+	k.protectedVaultkey = clone(protectedVaultkey)
+	k.bound = isBound(protectedVaultkey, masterkey)
+}
+
+// Rekey replaces the master and vault keys of the logged-in user, after a key rotation or password change
+func (k *KeyHolder) Rekey(masterkey, vaultkey, protectedVaultkey []byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	Wipe(k.masterkey)
+	Wipe(k.vaultkey)
+	Wipe(k.protectedVaultkey)
+	k.masterkey = clone(masterkey)
+	k.vaultkey = clone(vaultkey)
+	k.protectedVaultkey = clone(protectedVaultkey)
+	k.bound = isBound(protectedVaultkey, masterkey)
+}
+
+// SetSessionkey replaces the session key used to talk to the backend
+func (k *KeyHolder) SetSessionkey(sessionkey []byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	Wipe(k.sessionkey)
+	k.sessionkey = clone(sessionkey)
+}
+
+// Masterkey returns a copy of the master key
+func (k *KeyHolder) Masterkey() ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.masterkey == nil {
+		return nil, ErrVaultLocked
+	}
+	return clone(k.masterkey), nil
+}
+
+// Vaultkey returns a copy of the vault key
+func (k *KeyHolder) Vaultkey() ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.vaultkey == nil {
+		return nil, ErrVaultLocked
+	}
+	return clone(k.vaultkey), nil
+}
+
+// Sessionkey returns a copy of the session key
+func (k *KeyHolder) Sessionkey() ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.sessionkey == nil {
+		return nil, ErrVaultLocked
+	}
+	return clone(k.sessionkey), nil
+}
+
+// ProtectedVaultkey returns a copy of the vault key wrapped by the master key
+func (k *KeyHolder) ProtectedVaultkey() ([]byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.protectedVaultkey == nil {
+		return nil, ErrNoVault
+	}
+	return clone(k.protectedVaultkey), nil
 }
 
-func GetMasterkey() []byte {
-	return Masterkey
+// Bound reports whether the wrapped vault key says every item is bound to its ID
+func (k *KeyHolder) Bound() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.bound
 }
 
-func GetVaultkey() []byte {
-	return Vaultkey
+// Email returns the email of the user the vault belongs to
+func (k *KeyHolder) Email() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.email
 }
 
-// func ExtractVaultKey(EnVaultkey []byte, key []byte, IV []byte) error {
-// 	var err error
-// 	Vaultkey, err = utils.DecryptAES256GCM(EnVaultkey, key, IV)
-// 	return err
-// }
+// IsLocked reports whether the vault key is unavailable
+func (k *KeyHolder) IsLocked() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.vaultkey == nil
+}
+
+// Lock wipes the master, vault and session keys. The protected vault key is kept for Unlock.
+func (k *KeyHolder) Lock() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.wipeKeys()
+}
+
+// Unlock derives the master key from password and reopens the vault key
+func (k *KeyHolder) Unlock(password string) error {
+	k.mu.RLock()
+	email := k.email
+	protectedVaultkey := clone(k.protectedVaultkey)
+	k.mu.RUnlock()
+
+	if email == "" || protectedVaultkey == nil {
+		return ErrNoVault
+	}
+
+	masterkey := utils.MasterPasswordGen(password, email)
+	defer Wipe(masterkey)
+
+	return k.Open(email, masterkey, protectedVaultkey)
+}
+
+// Clear wipes every key and forgets the user, used on logout
+func (k *KeyHolder) Clear() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.wipeKeys()
+	Wipe(k.protectedVaultkey)
+	k.protectedVaultkey = nil
+	k.bound = false
+	k.email = ""
+}
+
+func (k *KeyHolder) wipeKeys() {
+	Wipe(k.masterkey)
+	Wipe(k.vaultkey)
+	Wipe(k.sessionkey)
+	k.masterkey = nil
+	k.vaultkey = nil
+	k.sessionkey = nil
+}
@@ -0,0 +1,196 @@
+package attachment
+
+// Streaming encryption for file attachments. A file is split into chunks that are sealed one by one with
+// AES-256-GCM, so neither the plaintext nor the ciphertext has to fit in memory.
+//
+// Layout: header (magic "MSA1", chunk size as uint32, 7 byte random nonce prefix) followed by the sealed
+// chunks. The nonce of a chunk is prefix || counter (uint32) || last flag, and the header is the
+// additional data of every chunk. Reordered, dropped or appended chunks and a stream cut after a full
+// chunk all fail to open.
+
+import (
+	"Modsec/clientside/CipherAlgo/utils"
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+var (
+	// ErrCorrupt is returned when an encrypted stream was modified, truncated or opened with the wrong key
+	ErrCorrupt = errors.New("attachment is corrupt or the key is wrong")
+	// ErrTooLarge is returned for files above MaxSize
+	ErrTooLarge = errors.New("attachment is too large")
+)
+
+const (
+	// KeySize is the length of an attachment key
+	KeySize = 32
+	// ChunkSize is the plaintext size of every chunk but the last
+	ChunkSize = 64 * 1024
+	// MaxSize is the largest file that can be attached
+	MaxSize = 256 * 1024 * 1024
+
+	magic        = "MSA1"
+	prefixSize   = 7
+	headerSize   = len(magic) + 4 + prefixSize
+	maxChunkSize = 1024 * 1024
+	tagSize      = 16
+)
+
+// NewKey returns a random attachment key
+func NewKey() ([]byte, error) {
+	return utils.GenerateRandomBytes(KeySize)
+}
+
+// EncryptedSize returns the length of the stream Encrypt writes for size bytes of plaintext
+func EncryptedSize(size int64) int64 {
+	chunks := size / ChunkSize
+	if size%ChunkSize != 0 || size == 0 {
+		chunks++
+	}
+	return int64(headerSize) + size + chunks*tagSize
+}
+
+// Encrypt seals src with key and writes the stream to dst. It returns the number of plaintext bytes read.
+func Encrypt(dst io.Writer, src io.Reader, key []byte) (int64, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return 0, err
+	}
+
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	binary.BigEndian.PutUint32(header[len(magic):], ChunkSize)
+	prefix, err := utils.GenerateRandomBytes(prefixSize)
+	if err != nil {
+		return 0, err
+	}
+	copy(header[len(magic)+4:], prefix)
+	if _, err := dst.Write(header); err != nil {
+		return 0, err
+	}
+
+	in := bufio.NewReaderSize(src, ChunkSize)
+	plain := make([]byte, ChunkSize)
+	sealed := make([]byte, 0, ChunkSize+tagSize)
+	var total int64
+	for counter := uint64(0); ; counter++ {
+		if counter > math.MaxUint32 {
+			return total, ErrTooLarge
+		}
+		n, err := io.ReadFull(in, plain)
+		last := false
+		switch {
+		case err == io.EOF || err == io.ErrUnexpectedEOF:
+			last = true
+		case err != nil:
+			return total, err
+		default:
+			// A full chunk is the last one if nothing follows it
+			if _, err := in.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return total, err
+			}
+		}
+
+		total += int64(n)
+		if total > MaxSize {
+			return total, ErrTooLarge
+		}
+		sealed = aead.Seal(sealed[:0], chunkNonce(prefix, uint32(counter), last), plain[:n], header)
+		if _, err := dst.Write(sealed); err != nil {
+			return total, err
+		}
+		if last {
+			return total, nil
+		}
+	}
+}
+
+// Decrypt opens a stream written by Encrypt and writes the plaintext to dst. Chunks are written as they
+// are verified, so on error dst holds a partial file that must be thrown away.
+func Decrypt(dst io.Writer, src io.Reader, key []byte) (int64, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return 0, err
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(src, header); err != nil {
+		return 0, fmt.Errorf("%w: missing header", ErrCorrupt)
+	}
+	if !bytes.Equal(header[:len(magic)], []byte(magic)) {
+		return 0, fmt.Errorf("%w: unknown format", ErrCorrupt)
+	}
+	chunkSize := int(binary.BigEndian.Uint32(header[len(magic):]))
+	if chunkSize == 0 || chunkSize > maxChunkSize {
+		return 0, fmt.Errorf("%w: bad chunk size", ErrCorrupt)
+	}
+	prefix := header[len(magic)+4:]
+
+	in := bufio.NewReaderSize(src, chunkSize+tagSize)
+	sealed := make([]byte, chunkSize+tagSize)
+	plain := make([]byte, 0, chunkSize)
+	var total int64
+	for counter := uint64(0); counter <= math.MaxUint32; counter++ {
+		n, err := io.ReadFull(in, sealed)
+		last := false
+		switch {
+		case err == io.EOF:
+			// The previous chunk was not marked last, so the stream was cut
+			return total, fmt.Errorf("%w: truncated", ErrCorrupt)
+		case err == io.ErrUnexpectedEOF:
+			last = true
+		case err != nil:
+			return total, err
+		default:
+			if _, err := in.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return total, err
+			}
+		}
+
+		plain, err = aead.Open(plain[:0], chunkNonce(prefix, uint32(counter), last), sealed[:n], header)
+		if err != nil {
+			return total, fmt.Errorf("%w: chunk %d", ErrCorrupt, counter)
+		}
+		if _, err := dst.Write(plain); err != nil {
+			return total, err
+		}
+		total += int64(len(plain))
+		if last {
+			return total, nil
+		}
+	}
+	return total, ErrTooLarge
+}
+
+// chunkNonce builds the nonce of chunk counter
+func chunkNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[prefixSize:], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("attachment key must be %d bytes", KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
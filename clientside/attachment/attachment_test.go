@@ -0,0 +1,73 @@
+package attachment
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{0, 1, ChunkSize - 1, ChunkSize, ChunkSize + 1, 3*ChunkSize + 17} {
+		plain := make([]byte, size)
+		rand.Read(plain)
+
+		var sealed bytes.Buffer
+		n, err := Encrypt(&sealed, bytes.NewReader(plain), key)
+		if err != nil || n != int64(size) {
+			t.Fatalf("size %d: Encrypt = %d, %v", size, n, err)
+		}
+		if int64(sealed.Len()) != EncryptedSize(int64(size)) {
+			t.Fatalf("size %d: stream is %d bytes, EncryptedSize says %d", size, sealed.Len(), EncryptedSize(int64(size)))
+		}
+
+		var opened bytes.Buffer
+		if _, err := Decrypt(&opened, bytes.NewReader(sealed.Bytes()), key); err != nil {
+			t.Fatalf("size %d: Decrypt: %v", size, err)
+		}
+		if !bytes.Equal(opened.Bytes(), plain) {
+			t.Fatalf("size %d: plaintext differs", size)
+		}
+	}
+}
+
+func TestTampering(t *testing.T) {
+	key, _ := NewKey()
+	plain := make([]byte, 2*ChunkSize+100)
+	var sealed bytes.Buffer
+	if _, err := Encrypt(&sealed, bytes.NewReader(plain), key); err != nil {
+		t.Fatal(err)
+	}
+	stream := sealed.Bytes()
+	chunk := ChunkSize + tagSize
+
+	flipped := append([]byte{}, stream...)
+	flipped[headerSize+10] ^= 1
+
+	swapped := append([]byte{}, stream[:headerSize]...)
+	swapped = append(swapped, stream[headerSize+chunk:headerSize+2*chunk]...)
+	swapped = append(swapped, stream[headerSize:headerSize+chunk]...)
+	swapped = append(swapped, stream[headerSize+2*chunk:]...)
+
+	otherKey, _ := NewKey()
+	cases := map[string]struct {
+		stream []byte
+		key    []byte
+	}{
+		"flipped bit":      {flipped, key},
+		"swapped chunks":   {swapped, key},
+		"cut after chunk":  {stream[:headerSize+chunk], key},
+		"cut in the chunk": {stream[:len(stream)-1], key},
+		"header only":      {stream[:headerSize], key},
+		"wrong key":        {stream, otherKey},
+	}
+	for name, c := range cases {
+		if _, err := Decrypt(&bytes.Buffer{}, bytes.NewReader(c.stream), c.key); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("%s: err = %v, want ErrCorrupt", name, err)
+		}
+	}
+}
@@ -2,20 +2,16 @@ package auth
 
 import (
 	"Modsec/clientside/CipherAlgo/utils"
+	"Modsec/clientside/breach"
+	"Modsec/clientside/strength"
+	"context"
 	"crypto/rand"
 	"encoding/base64"
-)
-
-// Config holds authentication configuration
-type Config struct {
-	BackendURL string
-}
-
-var (
-	// DefaultConfig holds default configuration values
-	DefaultConfig = Config{
-		BackendURL: "http://localhost:8080",
-	}
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
 )
 
 // GenerateSessionToken creates a secure random token
@@ -34,11 +30,74 @@ func ValidateEmailFormat(email string) bool {
 	return utils.ValidateEmail(email)
 }
 
+// Master password requirements. The score is the strength estimate from 0 to 4; 3 means an offline attack
+// on the vault would need at least 10^10 guesses.
+const (
+	MinPasswordLength = 8
+	MinPasswordScore  = 3
+)
+
+// PasswordCheck is the strength estimate of a master password and whether it is accepted
+type PasswordCheck struct {
+	Acceptable bool            `json:"acceptable"`
+	Message    string          `json:"message"`
+	Strength   strength.Result `json:"strength"`
+}
+
+// CheckPasswordStrength estimates the strength of a master password, treating the parts of email as words
+// an attacker would try first
+func CheckPasswordStrength(password, email string) PasswordCheck {
+	check := PasswordCheck{Strength: strength.Estimate(password, emailInputs(email)...)}
+	switch {
+	case len([]rune(password)) < MinPasswordLength:
+		check.Message = fmt.Sprintf("Password must be at least %d characters", MinPasswordLength)
+	case check.Strength.Score < MinPasswordScore:
+		check.Message = "Password is too easy to guess"
+		if warning := check.Strength.Feedback.Warning; warning != "" {
+			check.Message += ": " + warning
+		}
+	default:
+		check.Acceptable = true
+	}
+	return check
+}
+
 // ValidatePasswordStrength checks if the password meets strength requirements
-func ValidatePasswordStrength(password string) (bool, string) {
-	if len(password) < 8 {
-		return false, "Password must be at least 8 characters"
+func ValidatePasswordStrength(password, email string) (bool, string) {
+	check := CheckPasswordStrength(password, email)
+	return check.Acceptable, check.Message
+}
+
+// ValidatePasswordNotBreached checks the password does not appear in known data breaches. It passes when
+// the breach check is turned off or the range endpoint cannot be reached, so an outage never blocks
+// registration.
+func ValidatePasswordNotBreached(ctx context.Context, password string) (bool, string) {
+	count, err := breach.Count(ctx, password)
+	if err != nil {
+		if !errors.Is(err, breach.ErrDisabled) {
+			log.Printf("Breach check failed: %v", err)
+		}
+		return true, ""
+	}
+	if count > 0 {
+		return false, fmt.Sprintf("This password has appeared %d times in data breaches. Choose a different one", count)
 	}
-	// Add more validation rules as needed
 	return true, ""
 }
+
+// emailInputs splits an email address into the words a targeted guess would start with
+func emailInputs(email string) []string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return nil
+	}
+	inputs := []string{email}
+	local, domain, _ := strings.Cut(email, "@")
+	inputs = append(inputs, local)
+	split := func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }
+	inputs = append(inputs, strings.FieldsFunc(local, split)...)
+	if labels := strings.Split(domain, "."); len(labels) > 1 {
+		inputs = append(inputs, labels[:len(labels)-1]...)
+	}
+	return inputs
+}
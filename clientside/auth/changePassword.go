@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"Modsec/clientside/CipherAlgo/keymaster"
+	"Modsec/clientside/CipherAlgo/utils"
+	"Modsec/clientside/cache"
+	"Modsec/clientside/client"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrSamePassword is returned when the new master password is the current one
+var ErrSamePassword = errors.New("the new password must differ from the current one")
+
+// ChangePasswordPayload proves the current password like a login and carries the new Sandwich hashes
+// and the vault key wrapped by the new master key
+type ChangePasswordPayload struct {
+	LoginPayload              // Current password, verified by the backend like a login
+	EncryptedHp1_HpR   string `json:"encrypted_hp1_hpr"`    // session key
+	EncryptedIteration string `json:"encrypted_iteration"`  // session key
+	ProtectedVaultKey  string `json:"protected_vault_key"`  // New Master key
+	Sessionkey         []byte `json:"encrypted_sessionkey"` // public key
+}
+
+type ChangePasswordResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// ProcessChangePassword builds the change request and the new master key. The caller wipes the key.
+func ProcessChangePassword(email, oldPassword, newPassword string, vaultKey []byte) (*ChangePasswordPayload, []byte, error) {
+	// Sandwich login proof for the current password
+	proof, err := ProcessLogin(email, oldPassword)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	publickey, err := PubKeyRequest()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch Publickey: %v", err)
+	}
+
+	sessionKey, err := utils.GenerateSessionKey()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate session key: %v", err)
+	}
+	defer keymaster.Wipe(sessionKey)
+
+	// Generate Sandwich hash for the new password
+	answer, iterations := utils.SandwichRegisOP(newPassword, email)
+
+	baseAnswer := make([]string, 0, len(answer))
+	for _, b := range answer {
+		baseAnswer = append(baseAnswer, utils.BytToBa64(b))
+	}
+	iterationStrings := make([]string, len(iterations))
+	for i, num := range iterations {
+		iterationStrings[i] = strconv.Itoa(num)
+	}
+
+	encryptedHp1HpR, err := utils.EncryptAES256GCM([]byte(strings.Join(baseAnswer, "|")), sessionKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt Hp1-HpR: %v", err)
+	}
+	encryptedIteration, err := utils.EncryptAES256GCM([]byte(strings.Join(iterationStrings, "|")), sessionKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt iterations: %v", err)
+	}
+
+	encryptedSession, err := utils.EncryptWithPublicKey(publickey, sessionKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to Encrypt Session key: %v", err)
+	}
+
+	// Rewrap the existing vault key, items stay encrypted as they are and so does the migration state
+	masterKey := utils.MasterPasswordGen(newPassword, email)
+	protectedVaultKey, err := keymaster.Protect(vaultKey, masterKey, keymaster.Keys.Bound() || cache.IsBound(email))
+	if err != nil {
+		keymaster.Wipe(masterKey)
+		return nil, nil, fmt.Errorf("failed to encrypt vault key: %v", err)
+	}
+
+	payload := &ChangePasswordPayload{
+		LoginPayload:       *proof,
+		EncryptedHp1_HpR:   utils.BytToBa64(encryptedHp1HpR),
+		EncryptedIteration: utils.BytToBa64(encryptedIteration),
+		ProtectedVaultKey:  utils.BytToBa64(protectedVaultKey),
+		Sessionkey:         encryptedSession,
+	}
+	return payload, masterKey, nil
+}
+
+// ChangeMasterPassword replaces the master password of the logged-in user without the seed phrase.
+// The backend ends every other session of the account.
+func ChangeMasterPassword(ctx context.Context, oldPassword, newPassword string) error {
+	email := keymaster.Keys.Email()
+	currentKey, err := keymaster.Keys.Masterkey()
+	if err != nil {
+		return err
+	}
+	defer keymaster.Wipe(currentKey)
+
+	// Check the current password locally first, the backend checks it again
+	oldKey := utils.MasterPasswordGen(oldPassword, email)
+	defer keymaster.Wipe(oldKey)
+	if subtle.ConstantTimeCompare(oldKey, currentKey) != 1 {
+		return client.ErrInvalidCredentials
+	}
+	if oldPassword == newPassword {
+		return ErrSamePassword
+	}
+
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return err
+	}
+	defer keymaster.Wipe(vaultKey)
+
+	payload, masterKey, err := ProcessChangePassword(email, oldPassword, newPassword, vaultKey)
+	if err != nil {
+		log.Printf("Change password processing failed: %v", err)
+		return err
+	}
+	defer keymaster.Wipe(masterKey)
+
+	protectedVaultKey := payload.ProtectedVaultKey
+	response := &ChangePasswordResponse{}
+	err = client.Backend.Do(ctx, http.MethodPost, "/changePassword", payload, response)
+	if err != nil {
+		log.Printf("Change password communication failed: %v", err)
+		// A 401 here means the current password was rejected, not that the session expired
+		if errors.Is(err, client.ErrSessionExpired) {
+			return fmt.Errorf("%w: %w", client.ErrInvalidCredentials, err)
+		}
+		// The backend may have taken the new password and only the answer was lost
+		if !errors.Is(err, client.ErrNetwork) {
+			return err
+		}
+		committed, ok := passwordChanged(ctx, email, newPassword, masterKey, vaultKey)
+		if !ok {
+			return err
+		}
+		log.Printf("Password change was committed without an answer")
+		protectedVaultKey = committed
+	} else if !response.Success {
+		return fmt.Errorf("password change failed: %s", response.Message)
+	}
+
+	protected, err := utils.Ba64ToByt(protectedVaultKey)
+	if err != nil {
+		return err
+	}
+	keymaster.Keys.Rekey(masterKey, vaultKey, protected)
+	cacheProtectedKey(email, protectedVaultKey)
+
+	log.Printf("Master password changed")
+	return nil
+}
+
+// passwordChanged reports whether the backend already accepts newPassword by logging in with it. When it does,
+// it returns the vault key the backend holds, wrapped by the new master key.
+func passwordChanged(ctx context.Context, email, newPassword string, masterKey, vaultKey []byte) (string, bool) {
+	proof, err := ProcessLogin(email, newPassword)
+	if err != nil {
+		log.Printf("Password change check failed: %v", err)
+		return "", false
+	}
+	response := &LoginResponse{}
+	if err := client.Backend.Do(ctx, http.MethodPost, "/login", proof, response); err != nil {
+		log.Printf("Password change check failed: %v", err)
+		return "", false
+	}
+
+	protected, err := utils.Ba64ToByt(response.EncryptedVault)
+	if err != nil {
+		return "", false
+	}
+	opened, err := keymaster.Unprotect(protected, masterKey)
+	if err != nil {
+		return "", false
+	}
+	defer keymaster.Wipe(opened)
+	return response.EncryptedVault, subtle.ConstantTimeCompare(opened, vaultKey) == 1
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"Modsec/clientside/CipherAlgo/keymaster"
+	"Modsec/clientside/CipherAlgo/utils"
+	"Modsec/clientside/cache"
+	"Modsec/clientside/client"
+	"Modsec/clientside/config"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+const (
+	testEmail       = "change@example.com"
+	testOldPassword = "old correct horse battery"
+	testNewPassword = "new correct horse battery"
+)
+
+// passwordBackend stands in for the server. changeStatus answers /changePassword, 0 commits the change
+// and drops the connection before answering, -1 drops it without committing.
+type passwordBackend struct {
+	mu           sync.Mutex
+	changeStatus int
+	changes      int
+	protected    string // Vault key /login hands out, replaced by a committed change
+}
+
+func newPasswordBackend(t *testing.T, protected string) *passwordBackend {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	backend := &passwordBackend{changeStatus: http.StatusOK, protected: protected}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/publickey", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(PublicKeyResponse{PublicKey: publicKey})
+	})
+	mux.HandleFunc("/changePassword", func(w http.ResponseWriter, r *http.Request) {
+		var payload ChangePasswordPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		backend.mu.Lock()
+		defer backend.mu.Unlock()
+		backend.changes++
+		switch backend.changeStatus {
+		case http.StatusOK:
+			backend.protected = payload.ProtectedVaultKey
+			json.NewEncoder(w).Encode(ChangePasswordResponse{Success: true})
+		case 0, -1:
+			if backend.changeStatus == 0 {
+				backend.protected = payload.ProtectedVaultKey
+			}
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		default:
+			http.Error(w, `{"message":"rejected"}`, backend.changeStatus)
+		}
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		backend.mu.Lock()
+		defer backend.mu.Unlock()
+		json.NewEncoder(w).Encode(LoginResponse{Success: true, EncryptedVault: backend.protected})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := config.Default()
+	cfg.BackendURL = srv.URL
+	if err := client.InitClient(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.InitClient(config.Default()) })
+	return backend
+}
+
+// passwordVault opens a vault for testEmail under testOldPassword and returns its vault key
+func passwordVault(t *testing.T) ([]byte, *passwordBackend) {
+	t.Helper()
+	t.Setenv(config.EnvConfigFile, filepath.Join(t.TempDir(), "config.json"))
+
+	vaultKey := make([]byte, 32)
+	rand.Read(vaultKey)
+	masterKey := utils.MasterPasswordGen(testOldPassword, testEmail)
+	protected, err := keymaster.Protect(vaultKey, masterKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keymaster.Keys.Store(testEmail, masterKey, vaultKey, protected)
+	t.Cleanup(keymaster.Keys.Clear)
+	return vaultKey, newPasswordBackend(t, utils.BytToBa64(protected))
+}
+
+// assertMasterPassword checks that the session and the offline copy open the vault with password
+func assertMasterPassword(t *testing.T, vaultKey []byte, password string) {
+	t.Helper()
+	want := utils.MasterPasswordGen(password, testEmail)
+	if got, err := keymaster.Keys.Masterkey(); err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("session master key is not the one of %q (%v)", password, err)
+	}
+	protected, err := cache.LoadProtectedKey(testEmail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened, err := keymaster.Unprotect(protected, want); err != nil || !bytes.Equal(opened, vaultKey) {
+		t.Fatalf("cached vault key does not open with %q (%v)", password, err)
+	}
+}
+
+func TestChangeMasterPasswordChecksLocally(t *testing.T) {
+	_, backend := passwordVault(t)
+
+	err := ChangeMasterPassword(context.Background(), "wrong password", testNewPassword)
+	if !errors.Is(err, client.ErrInvalidCredentials) {
+		t.Errorf("wrong current password: %v", err)
+	}
+	if err := ChangeMasterPassword(context.Background(), testOldPassword, testOldPassword); !errors.Is(err, ErrSamePassword) {
+		t.Errorf("same password: %v", err)
+	}
+	if backend.changes != 0 {
+		t.Errorf("%d change requests sent", backend.changes)
+	}
+}
+
+func TestChangeMasterPasswordRejected(t *testing.T) {
+	_, backend := passwordVault(t)
+	backend.changeStatus = http.StatusUnauthorized
+
+	err := ChangeMasterPassword(context.Background(), testOldPassword, testNewPassword)
+	if !errors.Is(err, client.ErrInvalidCredentials) {
+		t.Fatalf("401 from /changePassword: %v", err)
+	}
+	want := utils.MasterPasswordGen(testOldPassword, testEmail)
+	if got, _ := keymaster.Keys.Masterkey(); !bytes.Equal(got, want) {
+		t.Fatal("master key changed although the backend refused")
+	}
+}
+
+func TestChangeMasterPassword(t *testing.T) {
+	vaultKey, _ := passwordVault(t)
+
+	if err := ChangeMasterPassword(context.Background(), testOldPassword, testNewPassword); err != nil {
+		t.Fatal(err)
+	}
+	assertMasterPassword(t, vaultKey, testNewPassword)
+}
+
+func TestChangeMasterPasswordLostResponse(t *testing.T) {
+	vaultKey, backend := passwordVault(t)
+	backend.changeStatus = 0
+
+	// The backend took the new password, so the session has to follow it
+	if err := ChangeMasterPassword(context.Background(), testOldPassword, testNewPassword); err != nil {
+		t.Fatal(err)
+	}
+	assertMasterPassword(t, vaultKey, testNewPassword)
+}
+
+func TestChangeMasterPasswordLostRequest(t *testing.T) {
+	vaultKey, backend := passwordVault(t)
+	backend.changeStatus = -1
+
+	err := ChangeMasterPassword(context.Background(), testOldPassword, testNewPassword)
+	if !errors.Is(err, client.ErrNetwork) {
+		t.Fatalf("change that did not reach the backend: %v", err)
+	}
+	want := utils.MasterPasswordGen(testOldPassword, testEmail)
+	if got, _ := keymaster.Keys.Masterkey(); !bytes.Equal(got, want) {
+		t.Fatal("master key changed although the backend kept the old password")
+	}
+	if opened, _ := keymaster.Keys.Vaultkey(); !bytes.Equal(opened, vaultKey) {
+		t.Fatal("vault key changed")
+	}
+}
@@ -3,11 +3,11 @@ package auth
 import (
 	"Modsec/clientside/CipherAlgo/keymaster"
 	"Modsec/clientside/CipherAlgo/utils"
+	"Modsec/clientside/cache"
 	"Modsec/clientside/client"
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"strconv"
@@ -34,8 +34,6 @@ type LoginResponse struct {
 // ProcessLogin handles the login logic
 func ProcessLogin(email, password string) (*LoginPayload, error) {
 
-	keymaster.Masterkey = utils.MasterPasswordGen(password, email) // Forgot too added in first place
-
 	// Get Sandwich components for login
 	ArrayHq1_HqR, iterations := utils.SandwichLoginOP(password, email)
 
@@ -90,71 +88,6 @@ func ProcessLogin(email, password string) (*LoginPayload, error) {
 	return payload, nil
 }
 
-// SendLoginToBackend sends login data to the backend server
-func SendLoginToBackend(payload *LoginPayload, backendURL string) (*LoginResponse, error) {
-	// Convert payload to JSON
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode JSON: %v", err)
-	}
-
-	// Send data to backend server
-	req, err := http.NewRequest("POST", backendURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Access-Control-Allow-Credentials", "true")
-
-	//Delete after test
-	fmt.Println("Login data:", req)
-
-	resp, err := client.HMClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		// Read the error response body for more information
-		bodyBytes, err := io.ReadAll(resp.Body)
-		if err == nil && len(bodyBytes) > 0 {
-			// Try to parse the error message
-			var errorResponse map[string]interface{}
-			if err := json.Unmarshal(bodyBytes, &errorResponse); err == nil {
-				if msg, ok := errorResponse["message"].(string); ok && msg != "" {
-					return nil, fmt.Errorf("%s", msg)
-				}
-			}
-
-			// If we couldn't parse it as JSON, check if it contains "Password not match"
-			bodyStr := string(bodyBytes)
-			if strings.Contains(bodyStr, "Password not match") {
-				return nil, fmt.Errorf("Password not match")
-			}
-
-			// Return the raw body as the error message if nothing else works
-			if len(bodyStr) > 0 {
-				return nil, fmt.Errorf(bodyStr)
-			}
-		}
-
-		// Fall back to status code error if we couldn't get anything better
-		return nil, fmt.Errorf("login failed with status: %d", resp.StatusCode)
-	}
-
-	// Parse response
-	var result LoginResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-
-	return &result, nil
-}
-
 // LoginUser combines processing and backend communication
 func LoginUser(email, password string) (*LoginResponse, error) {
 	// Create a login payload
@@ -165,10 +98,14 @@ func LoginUser(email, password string) (*LoginResponse, error) {
 	}
 
 	// Send to backend server
-	backendURL := "http://localhost:8080/login"
-	response, err := SendLoginToBackend(payload, backendURL)
+	response := &LoginResponse{}
+	err = client.Backend.Do(context.Background(), http.MethodPost, "/login", payload, response)
 	if err != nil {
 		log.Printf("Login communication failed: %v", err)
+		// A 401 from /login means the credentials were rejected, not that a session expired
+		if errors.Is(err, client.ErrSessionExpired) {
+			return nil, fmt.Errorf("%w: %w", client.ErrInvalidCredentials, err)
+		}
 		return nil, err
 	}
 
@@ -180,14 +117,51 @@ func LoginUser(email, password string) (*LoginResponse, error) {
 
 	// This is where the wrong password error often occurs - add more detailed logging
 	log.Printf("Attempting to decrypt vault key with master key")
-	keymaster.Vaultkey, err = utils.DecryptAES256GCM(EncryptedVaultByte, keymaster.Masterkey)
+	masterkey := utils.MasterPasswordGen(password, email)
+	defer keymaster.Wipe(masterkey)
+
+	err = keymaster.Keys.Open(email, masterkey, EncryptedVaultByte)
 	if err != nil {
 		log.Printf("Failed to decrypt vault key: %v", err)
-		// Return a clearer error message for wrong password
-		return nil, fmt.Errorf("incorrect password")
+		// The master key is derived from the password, so a wrong password fails here
+		return nil, client.ErrInvalidCredentials
 	}
 
+	// Keep the wrapped vault key so the vault can be opened offline next time
+	cacheProtectedKey(email, response.EncryptedVault)
+
 	// Log success and return result
 	log.Printf("Login result: %v - %s", response.Success, response.Message)
 	return response, nil
 }
+
+// LoginOffline opens the vault from the local cache when the backend cannot be reached
+func LoginOffline(email, password string) error {
+	protectedVaultkey, err := cache.LoadProtectedKey(email)
+	if err != nil {
+		log.Printf("Offline login unavailable: %v", err)
+		return err
+	}
+
+	masterkey := utils.MasterPasswordGen(password, email)
+	defer keymaster.Wipe(masterkey)
+
+	if err := keymaster.Keys.Open(email, masterkey, protectedVaultkey); err != nil {
+		log.Printf("Failed to decrypt cached vault key: %v", err)
+		return client.ErrInvalidCredentials
+	}
+
+	log.Printf("Vault opened offline")
+	return nil
+}
+
+// cacheProtectedKey saves the base64 wrapped vault key for offline unlock, logging failures
+func cacheProtectedKey(email, protectedVaultKey string) {
+	protected, err := utils.Ba64ToByt(protectedVaultKey)
+	if err == nil {
+		err = cache.SaveProtectedKey(email, protected)
+	}
+	if err != nil {
+		log.Printf("Failed to cache vault key: %v", err)
+	}
+}
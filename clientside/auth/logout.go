@@ -1,9 +1,9 @@
 package auth
 
 import (
+	"Modsec/clientside/CipherAlgo/keymaster"
 	"Modsec/clientside/client"
-	"encoding/json"
-	"fmt"
+	"context"
 	"log"
 	"net/http"
 	"net/url"
@@ -48,47 +48,18 @@ func ClearAuthCookie(domain string, cookieName string) {
 	client.HMClient.Jar.SetCookies(u, []*http.Cookie{expiredCookie})
 }
 
-func SendLogoutToBackend(backendURL string) (*LogoutResponse, error) {
-	// Optional: include logout payload (if needed)
-	// For now, just send an empty POST request
-	req, err := http.NewRequest("POST", backendURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create logout request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Access-Control-Allow-Credentials", "true") // Optional
-
-	// Perform request
-	resp, err := client.HMClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %v", err)
-	}
-	defer resp.Body.Close()
-
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("logout failed with status: %d", resp.StatusCode)
-	}
-
-	// Parse response
-	var result LogoutResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-	return &result, nil
-}
-
 func LogoutUser() (*LogoutResponse, error) {
-	backendURL := "http://localhost:8080/logout" // Change as needed
-	response, err := SendLogoutToBackend(backendURL)
+	// Wipe key material even if the backend cannot be reached
+	defer keymaster.Keys.Clear()
+
+	response := &LogoutResponse{}
+	err := client.Backend.Do(context.Background(), http.MethodPost, "/logout", nil, response)
 	if err != nil {
 		log.Printf("Login communication failed: %v", err)
 		return nil, err
 	}
 	// Clear client cookie manually
-	ClearAuthCookie("http://localhost:8080", "auth_token")
+	ClearAuthCookie(client.Backend.BaseURL(), "auth_token")
 
 	// Log success and return result
 	log.Printf("Logout result: %v - %s", response.Success, response.Message)
@@ -3,13 +3,12 @@ package auth
 import (
 	"Modsec/clientside/CipherAlgo/keymaster"
 	"Modsec/clientside/CipherAlgo/utils"
+	"Modsec/clientside/cache"
 	"Modsec/clientside/client"
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
 	"net/http"
-	"net/url"
 	"strconv"
 	"strings"
 )
@@ -37,10 +36,15 @@ func ProcessRecoveryProcess(email, password string, vaultKey []byte) (*RecProces
 		return nil, fmt.Errorf("failed to fetch Publickey: %v", err)
 	}
 
-	keymaster.Vaultkey = vaultKey
+	sessionKey, err := keymaster.Keys.Sessionkey()
+	if err != nil {
+		return nil, fmt.Errorf("no session key, request recovery first: %w", err)
+	}
+	defer keymaster.Wipe(sessionKey)
 
 	// Generate master key from password
-	keymaster.Masterkey = utils.MasterPasswordGen(password, email)
+	masterKey := utils.MasterPasswordGen(password, email)
+	defer keymaster.Wipe(masterKey)
 
 	// Generate Sandwich hash for registration
 	answer, iterations := utils.SandwichRegisOP(password, email)
@@ -61,30 +65,33 @@ func ProcessRecoveryProcess(email, password string, vaultKey []byte) (*RecProces
 	hp1HpR := strings.Join(baseAnswer, "|")
 	iterationString := strings.Join(iterationStrings, "|")
 
-	encryptedEmail, err := utils.EncryptAES256GCM([]byte(email), keymaster.Sessionkey)
+	encryptedEmail, err := utils.EncryptAES256GCM([]byte(email), sessionKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt Hp1-HpR: %v", err)
 	}
 
 	// Encrypt Hp1-HpR with session key
-	encryptedHp1HpR, err := utils.EncryptAES256GCM([]byte(hp1HpR), keymaster.Sessionkey)
+	encryptedHp1HpR, err := utils.EncryptAES256GCM([]byte(hp1HpR), sessionKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt Hp1-HpR: %v", err)
 	}
 
 	// Encrypt iterations with session key
-	encryptedIteration, err := utils.EncryptAES256GCM([]byte(iterationString), keymaster.Sessionkey)
+	encryptedIteration, err := utils.EncryptAES256GCM([]byte(iterationString), sessionKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt iterations: %v", err)
 	}
 
-	// Encrypt vault key with master key
-	protectedVaultKey, err := utils.EncryptAES256GCM(vaultKey, keymaster.Masterkey)
+	// Encrypt vault key with master key. The seed phrase does not say whether the vault was migrated to
+	// bound ciphertext, so unless this device knows, the migration confirms it again.
+	protectedVaultKey, err := keymaster.Protect(vaultKey, masterKey, cache.IsBound(email))
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt vault key: %v", err)
 	}
 
-	encryptedSession, err := utils.EncryptWithPublicKey(publickey, keymaster.Sessionkey)
+	keymaster.Keys.Store(email, masterKey, vaultKey, protectedVaultKey)
+
+	encryptedSession, err := utils.EncryptWithPublicKey(publickey, sessionKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to Encrypt Session key: %v", err)
 	}
@@ -101,88 +108,6 @@ func ProcessRecoveryProcess(email, password string, vaultKey []byte) (*RecProces
 	return resData, nil
 }
 
-// SendRegistrationToBackend sends registration data to the backend server
-func SendRecoveryProcessBackend(payload *RecProcessPayload, backendURL string) (*RecProcessResponse, error) {
-	// Use the payload parameter directly
-	jsonPayload := RecProcessPayload{
-		EncryptedEmail:     payload.EncryptedEmail,
-		EncryptedHp1_HpR:   payload.EncryptedHp1_HpR,
-		EncryptedIteration: payload.EncryptedIteration,
-		ProtectedVaultKey:  payload.ProtectedVaultKey,
-		Sessionkey:         payload.Sessionkey,
-	}
-
-	// Convert payload to JSON
-	jsonData, err := json.Marshal(jsonPayload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode JSON: %v", err)
-	}
-
-	// Send data to backend server
-	req, err := http.NewRequest("POST", backendURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Access-Control-Allow-Credentials", "true")
-
-	// token := "your-jwt-token-here" // replace with actual token
-	// req.AddCookie(&http.Cookie{
-	// 	Name:  "auth_token",
-	// 	Value: token,
-	// })
-
-	// Added this part in theory it will share cookie
-	resp, err := client.HMClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	///////////////////////////////// Server side /////////////////////////////////
-	u, err := url.Parse(backendURL)
-	if err != nil {
-		fmt.Println("Invalid URL:", err)
-		return nil, err
-	}
-
-	cookies := client.HMClient.Jar.Cookies(u)
-	fmt.Println("Cookies for", backendURL)
-	for _, c := range cookies {
-		fmt.Printf(" Here %s = %s (Path=%s, HttpOnly=%t)\n", c.Name, c.Value, c.Path, c.HttpOnly)
-	}
-	///////////////////////////////// Server side /////////////////////////////////
-
-	///////////////////////////////// Client side /////////////////////////////////
-	sigma, err := url.Parse("http://localhost:5173")
-	if err != nil {
-		fmt.Println("Invalid URL:", err)
-		return nil, err
-	}
-
-	cookies2 := client.HMClient.Jar.Cookies(sigma)
-	fmt.Println("Cookies for", sigma)
-	for _, c := range cookies2 {
-		fmt.Printf(" Here %s = %s (Path=%s, HttpOnly=%t)\n", c.Name, c.Value, c.Path, c.HttpOnly)
-	}
-	//////////////////////////////// Client side /////////////////////////////////
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("backend registration failed with status: %d", resp.StatusCode)
-	}
-
-	// Parse response
-	var result RecProcessResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-
-	return &result, nil
-}
-
 // RecoveryProcess combines processing and backend communication
 func RecoveryProcess(email, password, SeedPhrase string) (string, error) {
 	// Create a Recovery request payload
@@ -212,6 +137,7 @@ func RecoveryProcess(email, password, SeedPhrase string) (string, error) {
 		log.Printf("Failed to decode concatenated key: %v", err)
 		return "", err
 	}
+	defer keymaster.Wipe(vaultKey)
 
 	// Authentication for seed key
 	if SeedInside != SeedPhrase {
@@ -226,8 +152,8 @@ func RecoveryProcess(email, password, SeedPhrase string) (string, error) {
 	}
 
 	// Send to backend server
-	backendURL := "http://localhost:8080/recovery/process" // Change as needed
-	response, err := SendRecoveryProcessBackend(payload, backendURL)
+	response := &RecProcessResponse{}
+	err = client.Backend.Do(context.Background(), http.MethodPost, "/recovery/process", payload, response)
 	if err != nil {
 		log.Printf("Recovery communication failed: %v", err)
 		return "nil", err
@@ -239,6 +165,8 @@ func RecoveryProcess(email, password, SeedPhrase string) (string, error) {
 		return "", fmt.Errorf("registration failed: %s", response.Message)
 	}
 
+	cacheProtectedKey(email, payload.ProtectedVaultKey)
+
 	seedPhrase, err := RecoverySetup(email)
 
 	if err != nil {
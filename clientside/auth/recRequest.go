@@ -4,8 +4,7 @@ import (
 	"Modsec/clientside/CipherAlgo/keymaster"
 	"Modsec/clientside/CipherAlgo/utils"
 	"Modsec/clientside/client"
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -25,32 +24,25 @@ type RecRequestResponse struct {
 func ProcessRecoveryRequest(email string) (*RecRequestPayload, error) {
 	log.Println("Processing registration for email:", email)
 
-	// Generate initialization vector
-	iv, err := utils.GenerateIV()
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate IV: %v", err)
-	}
-	keymaster.IVKey = iv
-	fmt.Printf("Generating new IV")
-
-	// Generate session key
-	keymaster.Sessionkey, err = utils.GenerateSessionKey()
-	fmt.Printf("Generating new Session key")
+	// Generate session key, kept until RecoveryProcess sends the new credentials
+	sessionKey, err := utils.GenerateSessionKey()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate session key: %v", err)
 	}
+	defer keymaster.Wipe(sessionKey)
+	keymaster.Keys.SetSessionkey(sessionKey)
 
 	//Get the Public key
 	publickey, err := PubKeyRequest()
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch Publickey: %v", err)
 	}
-	encryptedSession, err := utils.EncryptWithPublicKey(publickey, keymaster.Sessionkey)
+	encryptedSession, err := utils.EncryptWithPublicKey(publickey, sessionKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to Encrypt Session key: %v", err)
 	}
 
-	encryptedEmail, err := utils.EncryptAES256GCM(utils.EmailToSHA256(email), keymaster.Sessionkey)
+	encryptedEmail, err := utils.EncryptAES256GCM(utils.EmailToSHA256(email), sessionKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt Hp1-HpR: %v", err)
 	}
@@ -64,50 +56,6 @@ func ProcessRecoveryRequest(email string) (*RecRequestPayload, error) {
 	return resData, nil
 }
 
-// SendRegistrationToBackend sends registration data to the backend server
-func SendRecoveryRequestBackend(payload *RecRequestPayload, backendURL string) (*RecRequestResponse, error) {
-	// Use the payload parameter directly
-	jsonPayload := RecRequestPayload{
-		Email:      payload.Email,
-		Sessionkey: payload.Sessionkey,
-	}
-
-	// Convert payload to JSON
-	jsonData, err := json.Marshal(jsonPayload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode JSON: %v", err)
-	}
-
-	// Send data to backend server
-	req, err := http.NewRequest("POST", backendURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Access-Control-Allow-Credentials", "true")
-
-	resp, err := client.HMClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("backend registration failed with status: %d", resp.StatusCode)
-	}
-
-	// Parse response
-	var result RecRequestResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-
-	return &result, nil
-}
-
 // RegisterUser combines processing and backend communication
 func RecoveryRequest(email string) (*RecRequestResponse, error) {
 	// Create a registration payload
@@ -118,8 +66,8 @@ func RecoveryRequest(email string) (*RecRequestResponse, error) {
 	}
 
 	// Send to backend server
-	backendURL := "http://localhost:8080/recovery/request" // Change as needed
-	response, err := SendRecoveryRequestBackend(payload, backendURL)
+	response := &RecRequestResponse{}
+	err = client.Backend.Do(context.Background(), http.MethodPost, "/recovery/request", payload, response)
 	if err != nil {
 		log.Printf("Recovery communication failed: %v", err)
 		return nil, err
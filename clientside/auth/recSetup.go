@@ -4,8 +4,7 @@ import (
 	"Modsec/clientside/CipherAlgo/keymaster"
 	"Modsec/clientside/CipherAlgo/utils"
 	"Modsec/clientside/client"
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -25,63 +24,40 @@ type RecSetupPayload struct {
 // ProcessRegistration handles the core recovery setup logic
 func ProcessRecoverySetup(UserHashEmail, SeedPhrase string) (*RecSetupPayload, error) { // No use hash email for now
 
-	Result := utils.ConcatKeyAndSeed(keymaster.Vaultkey, SeedPhrase)
-
-	// Encrypt Recovery key with SeedPhrase
-	// Result in Base64
-
-	SeedToKey := utils.SHA256Function([]byte(SeedPhrase)) //Convert FIRST!!!!
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return nil, err
+	}
+	defer keymaster.Wipe(vaultKey)
 
-	encryptedRecoveryKey, err := utils.EncryptAES256GCM([]byte(Result), SeedToKey)
+	encryptedRecoveryKey, err := WrapRecoveryKey(vaultKey, SeedPhrase)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt Recovery key: %v", err)
+		return nil, err
 	}
 
 	// Create response data structure using DataStr.ResData
 	payload := &RecSetupPayload{
 		HashEmail:            UserHashEmail,
-		EncryptedRecoveryKey: utils.BytToBa64(encryptedRecoveryKey),
+		EncryptedRecoveryKey: encryptedRecoveryKey,
 	}
 
 	return payload, nil
 }
 
-// sends Recovery setup data to the backend server
-func SendRecoverySetupoBackend(payload *RecSetupPayload, backendURL string) (*RecSetupResponse, error) {
-	// Convert payload to JSON
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode JSON: %v", err)
-	}
+// WrapRecoveryKey encrypts vaultKey with a key derived from SeedPhrase, in base64 as the backend stores it
+func WrapRecoveryKey(vaultKey []byte, SeedPhrase string) (string, error) {
+	Result := utils.ConcatKeyAndSeed(vaultKey, SeedPhrase)
 
-	// Send data to backend server
-	req, err := http.NewRequest("POST", backendURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
+	// Encrypt Recovery key with SeedPhrase
+	// Result in Base64
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Access-Control-Allow-Credentials", "true")
+	SeedToKey := utils.SHA256Function([]byte(SeedPhrase)) //Convert FIRST!!!!
 
-	resp, err := client.HMClient.Do(req)
+	encryptedRecoveryKey, err := utils.EncryptAES256GCM([]byte(Result), SeedToKey)
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("backend registration failed with status: %d", resp.StatusCode)
+		return "", fmt.Errorf("failed to encrypt Recovery key: %v", err)
 	}
-
-	// Parse response
-	var result RecSetupResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-
-	return &result, nil
+	return utils.BytToBa64(encryptedRecoveryKey), nil
 }
 
 func RecoverySetup(email string) (string, error) {
@@ -95,8 +71,8 @@ func RecoverySetup(email string) (string, error) {
 	}
 
 	// Send to backend server
-	backendURL := "http://localhost:8080/recovery/setup" // Change as needed
-	response, err := SendRecoverySetupoBackend(payload, backendURL)
+	response := &RecSetupResponse{}
+	err = client.Backend.Do(context.Background(), http.MethodPost, "/recovery/setup", payload, response)
 	if err != nil {
 		log.Printf("Recovery setup communication failed: %v", err)
 		return "", err
@@ -4,8 +4,7 @@ import (
 	"Modsec/clientside/CipherAlgo/keymaster"
 	"Modsec/clientside/CipherAlgo/utils"
 	"Modsec/clientside/client"
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -33,8 +32,8 @@ func ProcessRegistration(email, password string) (*RegisterPayload, error) {
 	log.Println("Processing registration for email:", email)
 
 	// Generate master key from password
-	// masterKey := utils.MasterPasswordGen(password, email)
-	keymaster.Masterkey = utils.MasterPasswordGen(password, email)
+	masterKey := utils.MasterPasswordGen(password, email)
+	defer keymaster.Wipe(masterKey)
 
 	// Generate Sandwich hash for registration
 	answer, iterations := utils.SandwichRegisOP(password, email)
@@ -71,43 +70,50 @@ func ProcessRegistration(email, password string) (*RegisterPayload, error) {
 		return nil, fmt.Errorf("failed to fetch Publickey: %v", err)
 	}
 
-	keymaster.Sessionkey, _ = utils.GenerateSessionKey()
+	sessionKey, err := utils.GenerateSessionKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate session key: %v", err)
+	}
+	defer keymaster.Wipe(sessionKey)
+	keymaster.Keys.SetSessionkey(sessionKey)
 
-	encryptedSession, err := utils.EncryptWithPublicKey(publickey, keymaster.Sessionkey)
+	encryptedSession, err := utils.EncryptWithPublicKey(publickey, sessionKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to Encrypt Session key: %v", err)
 	}
 
 	// Generate vault key
-	// vaultKey, err := utils.GenerateSessionKey()
-	keymaster.Vaultkey, err = utils.GenerateSessionKey()
+	vaultKey, err := utils.GenerateSessionKey()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate vault key: %v", err)
 	}
+	defer keymaster.Wipe(vaultKey)
 
-	encryptedEmail, err := utils.EncryptAES256GCM([]byte(email), keymaster.Sessionkey)
+	encryptedEmail, err := utils.EncryptAES256GCM([]byte(email), sessionKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt Hp1-HpR: %v", err)
 	}
 
 	// Encrypt Hp1-HpR with session key
-	encryptedHp1HpR, err := utils.EncryptAES256GCM([]byte(hp1HpR), keymaster.Sessionkey)
+	encryptedHp1HpR, err := utils.EncryptAES256GCM([]byte(hp1HpR), sessionKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt Hp1-HpR: %v", err)
 	}
 
 	// Encrypt iterations with session key
-	encryptedIteration, err := utils.EncryptAES256GCM([]byte(iterationString), keymaster.Sessionkey)
+	encryptedIteration, err := utils.EncryptAES256GCM([]byte(iterationString), sessionKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt iterations: %v", err)
 	}
 
-	// Encrypt vault key with master key
-	protectedVaultKey, err := utils.EncryptAES256GCM(keymaster.Vaultkey, keymaster.Masterkey)
+	// Encrypt vault key with master key, a new vault has nothing unbound to migrate
+	protectedVaultKey, err := keymaster.Protect(vaultKey, masterKey, true)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt vault key: %v", err)
 	}
 
+	keymaster.Keys.Store(email, masterKey, vaultKey, protectedVaultKey)
+
 	// Create response data structure using DataStr.ResData
 	resData := &RegisterPayload{
 		Email:              utils.BytToBa64(encryptedEmail),
@@ -120,71 +126,6 @@ func ProcessRegistration(email, password string) (*RegisterPayload, error) {
 	return resData, nil
 }
 
-// SendRegistrationToBackend sends registration data to the backend server
-func SendRegistrationToBackend(payload *RegisterPayload, backendURL string) (*RegisterResponse, error) {
-	// Use the payload parameter directly
-	jsonPayload := RegisterPayload{
-		EncryptedHp1_HpR:   payload.EncryptedHp1_HpR,
-		EncryptedIteration: payload.EncryptedIteration,
-		ProtectedVaultKey:  payload.ProtectedVaultKey,
-		Email:              payload.Email,
-		Sessionkey:         payload.Sessionkey,
-	}
-
-	// Convert payload to JSON
-	jsonData, err := json.Marshal(jsonPayload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode JSON: %v", err)
-	}
-
-	// Send data to backend server
-	req, err := http.NewRequest("POST", backendURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Access-Control-Allow-Credentials", "true")
-
-	// Added this part in theory it will share cookie
-	resp, err := client.HMClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	///////////////////////////////// Server side /////////////////////////////////
-
-	///////////////////////////////// Client side /////////////////////////////////
-	// 	Vite Server URL: http://localhost:5173/
-	//   ➜  Local:   http://localhost:5173/
-	// Running frontend DevWatcher command: 'npm run dev'
-	// Building application for development...
-	//   ➜  Network: use --host to expose
-	//   • Generating bindings: Done.
-	//   • Compiling application: Done.
-	//   • Packaging application: Done.
-
-	// Using DevServer URL: http://localhost:34115
-	// Using Frontend DevServer URL: http://localhost:5173/
-	// Using reload debounce setting of 100 milliseconds
-	//////////////////////////////// Client side /////////////////////////////////
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("backend registration failed with status: %d", resp.StatusCode)
-	}
-
-	// Parse response
-	var result RegisterResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-
-	return &result, nil
-}
-
 // RegisterUser combines processing and backend communication
 func RegisterUser(email, password string) (string, error) {
 	// Create a registration payload
@@ -195,8 +136,8 @@ func RegisterUser(email, password string) (string, error) {
 	}
 
 	// Send to backend server
-	backendURL := "http://localhost:8080/register" // Change as needed
-	response, err := SendRegistrationToBackend(payload, backendURL)
+	response := &RegisterResponse{}
+	err = client.Backend.Do(context.Background(), http.MethodPost, "/register", payload, response)
 	if err != nil {
 		log.Printf("Registration communication failed: %v", err)
 		return "", err
@@ -209,6 +150,8 @@ func RegisterUser(email, password string) (string, error) {
 		return "", fmt.Errorf("registration failed: %s", response.Message)
 	}
 
+	cacheProtectedKey(email, payload.ProtectedVaultKey)
+
 	seedPhrase, err := RecoverySetup(email) // may remove Email in the future
 	if err != nil {
 		log.Printf("Recovery Setup failed: %v", err)
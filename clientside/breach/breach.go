@@ -0,0 +1,192 @@
+package breach
+
+// Compromised password check against a k-anonymity range API such as Have I Been Pwned. A password is
+// hashed with SHA-1 and only the first 5 hex characters of the hash leave the machine; the endpoint
+// answers with every known hash suffix under that prefix and the suffix is matched locally.
+
+import (
+	"Modsec/clientside/config"
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrDisabled is returned when the breach check is turned off in the config
+	ErrDisabled = errors.New("breach check is turned off")
+	// ErrUnavailable is returned when the range endpoint cannot be reached or answers with an error
+	ErrUnavailable = errors.New("breach check unavailable")
+)
+
+// Defaults for a new Checker
+const (
+	DefaultCacheTTL    = 24 * time.Hour
+	DefaultMinInterval = 100 * time.Millisecond
+	prefixLength       = 5
+)
+
+// Checker looks up password hashes on a range endpoint. Responses are cached per prefix and requests are
+// spaced at least MinInterval apart. It is safe for concurrent use.
+type Checker struct {
+	rangeURL    string
+	client      *http.Client
+	CacheTTL    time.Duration
+	MinInterval time.Duration
+
+	mu    sync.Mutex
+	cache map[string]rangeEntry
+	next  time.Time // Earliest time the next request may be sent
+}
+
+// rangeEntry is the cached answer for one prefix, mapping hash suffixes to breach counts
+type rangeEntry struct {
+	counts  map[string]int
+	fetched time.Time
+}
+
+// NewChecker returns a Checker for the range endpoint at rangeURL, which is called as rangeURL/<prefix>
+func NewChecker(rangeURL string, client *http.Client) *Checker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Checker{
+		rangeURL:    strings.TrimRight(rangeURL, "/"),
+		client:      client,
+		CacheTTL:    DefaultCacheTTL,
+		MinInterval: DefaultMinInterval,
+		cache:       map[string]rangeEntry{},
+	}
+}
+
+// Count returns how many times password appears in known breaches, 0 if it was never seen
+func (c *Checker) Count(ctx context.Context, password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	counts, err := c.lookup(ctx, hash[:prefixLength])
+	if err != nil {
+		return 0, err
+	}
+	return counts[hash[prefixLength:]], nil
+}
+
+// lookup returns the suffix counts for prefix from the cache or the endpoint
+func (c *Checker) lookup(ctx context.Context, prefix string) (map[string]int, error) {
+	c.mu.Lock()
+	if entry, ok := c.cache[prefix]; ok && time.Since(entry.fetched) < c.CacheTTL {
+		c.mu.Unlock()
+		return entry.counts, nil
+	}
+	wait := time.Until(c.next)
+	if wait < 0 {
+		wait = 0
+	}
+	c.next = time.Now().Add(wait + c.MinInterval)
+	c.mu.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+
+	counts, err := c.fetch(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.cache[prefix] = rangeEntry{counts: counts, fetched: time.Now()}
+	c.mu.Unlock()
+	return counts, nil
+}
+
+// fetch downloads and parses the range for prefix. Padding entries with a count of 0 are dropped.
+func (c *Checker) fetch(ctx context.Context, prefix string) (map[string]int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.rangeURL+"/"+prefix, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	// Padding hides the size of the response, which would otherwise hint at the prefix
+	req.Header.Set("Add-Padding", "true")
+	req.Header.Set("User-Agent", "Modsec")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: range endpoint returned %s", ErrUnavailable, resp.Status)
+	}
+
+	counts := map[string]int{}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		suffix, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil || n <= 0 {
+			continue
+		}
+		counts[strings.ToUpper(suffix)] = n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	return counts, nil
+}
+
+var (
+	mu      sync.RWMutex
+	current *Checker
+)
+
+// Configure applies the breach options of cfg, replacing the current checker and its cache
+func Configure(cfg config.Config) {
+	var checker *Checker
+	if cfg.Breach.Enabled {
+		checker = NewChecker(cfg.Breach.RangeURL, &http.Client{Timeout: cfg.Timeout()})
+	}
+
+	mu.Lock()
+	current = checker
+	mu.Unlock()
+}
+
+// Enabled reports whether the breach check is turned on
+func Enabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current != nil
+}
+
+// Count looks password up with the configured checker
+func Count(ctx context.Context, password string) (int, error) {
+	mu.RLock()
+	checker := current
+	mu.RUnlock()
+
+	if checker == nil {
+		return 0, ErrDisabled
+	}
+	return checker.Count(ctx, password)
+}
@@ -0,0 +1,125 @@
+package breach
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// rangeServer stands in for the range API with the given breached passwords and their counts
+func rangeServer(t *testing.T, breached map[string]int) (*httptest.Server, *int32) {
+	t.Helper()
+	byPrefix := map[string][]string{}
+	for password, count := range breached {
+		sum := sha1.Sum([]byte(password))
+		hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+		byPrefix[hash[:5]] = append(byPrefix[hash[:5]], fmt.Sprintf("%s:%d", hash[5:], count))
+	}
+
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		prefix := strings.TrimPrefix(r.URL.Path, "/range/")
+		if len(prefix) != 5 {
+			http.Error(w, "bad prefix", http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Add-Padding") != "true" {
+			t.Errorf("request without padding header")
+		}
+		lines := append([]string{"0000000000000000000000000000000000A:0"}, byPrefix[prefix]...)
+		fmt.Fprint(w, strings.Join(lines, "\r\n"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestCount(t *testing.T) {
+	srv, requests := rangeServer(t, map[string]int{"password": 9545824, "hunter2": 17})
+	c := NewChecker(srv.URL+"/range/", srv.Client())
+	c.MinInterval = 0
+
+	tests := map[string]int{"password": 9545824, "hunter2": 17, "walrus-pickle-nebula": 0}
+	for password, want := range tests {
+		got, err := c.Count(context.Background(), password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Count(%q) = %d, want %d", password, got, want)
+		}
+	}
+
+	// A second lookup of the same prefix is served from the cache
+	before := atomic.LoadInt32(requests)
+	if _, err := c.Count(context.Background(), "password"); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(requests) != before {
+		t.Error("cached prefix was fetched again")
+	}
+}
+
+func TestCountSendsOnlyPrefix(t *testing.T) {
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+	}))
+	defer srv.Close()
+
+	if _, err := NewChecker(srv.URL, srv.Client()).Count(context.Background(), "password"); err != nil {
+		t.Fatal(err)
+	}
+	// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	if path != "/5BAA6" {
+		t.Fatalf("requested %q, want /5BAA6", path)
+	}
+}
+
+func TestCountUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	if _, err := NewChecker(srv.URL, srv.Client()).Count(context.Background(), "password"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("err = %v, want ErrUnavailable", err)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	srv, _ := rangeServer(t, nil)
+	c := NewChecker(srv.URL+"/range", srv.Client())
+	c.MinInterval = 50 * time.Millisecond
+
+	start := time.Now()
+	for _, password := range []string{"one", "two", "three"} {
+		if _, err := c.Count(context.Background(), password); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("three requests took %v, want at least 100ms", elapsed)
+	}
+
+	// A cancelled context stops waiting for the next slot
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Count(ctx, "four"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	if _, err := Count(context.Background(), "password"); !errors.Is(err, ErrDisabled) {
+		t.Fatalf("err = %v, want ErrDisabled", err)
+	}
+}
@@ -0,0 +1,311 @@
+package cache
+
+// Local copy of the vault so it can be opened without the backend.
+// Everything written here is either wrapped by the master key or encrypted with the vault key.
+
+import (
+	"Modsec/clientside/CipherAlgo/envelope"
+	"Modsec/clientside/CipherAlgo/utils"
+	"Modsec/clientside/config"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrNoCache is returned when nothing has been cached for the user yet
+var ErrNoCache = errors.New("no offline copy of the vault")
+
+// Entry is the on-disk format of one user's cache file
+type Entry struct {
+	ProtectedVaultKey string    `json:"protected_vault_key"`          // Vault key encrypted with the master key
+	Vault             string    `json:"vault,omitempty"`              // Last item list response encrypted with the vault key
+	SavedAt           time.Time `json:"saved_at,omitempty"`           // When Vault was written
+	Journal           string    `json:"journal,omitempty"`            // Writes made offline, encrypted with the vault key
+	Bound             bool      `json:"bound,omitempty"`              // Every item is bound to its ID, unbound ciphertext is refused
+	Rotation          string    `json:"rotation,omitempty"`           // Vault key rotation in progress, encrypted with the master key
+	PreviousVaultKey  string    `json:"previous_vault_key,omitempty"` // Vault key the journal was sealed with before a rotation
+}
+
+// Dir returns the directory that holds the cache files
+func Dir() (string, error) {
+	path, err := config.Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "cache"), nil
+}
+
+// fileFor names the cache file after the email hash so the email is not on disk in clear
+func fileFor(email string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, hex.EncodeToString(utils.EmailToSHA256(email))+".json"), nil
+}
+
+func load(email string) (*Entry, error) {
+	path, err := fileFor(email)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoCache
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache file: %w", err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode cache file: %w", err)
+	}
+	return &entry, nil
+}
+
+func save(email string, entry *Entry) error {
+	path, err := fileFor(email)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache file: %w", err)
+	}
+
+	// Write to a temp file first so a crash never leaves a half-written cache
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace cache file: %w", err)
+	}
+	return nil
+}
+
+// loadOrNew returns the cache entry for email, or an empty one if none exists yet
+func loadOrNew(email string) (*Entry, error) {
+	entry, err := load(email)
+	if errors.Is(err, ErrNoCache) {
+		return &Entry{}, nil
+	}
+	return entry, err
+}
+
+// SaveProtectedKey stores the master-key-wrapped vault key so the vault can be unlocked offline.
+// While a journal is waiting, the key it replaces is kept until the journal is saved again, since the
+// journal is sealed with the vault key it was written under.
+func SaveProtectedKey(email string, protectedVaultkey []byte) error {
+	entry, err := loadOrNew(email)
+	if err != nil {
+		return err
+	}
+	encoded := utils.BytToBa64(protectedVaultkey)
+	if entry.Journal != "" && entry.PreviousVaultKey == "" && entry.ProtectedVaultKey != encoded {
+		entry.PreviousVaultKey = entry.ProtectedVaultKey
+	}
+	entry.ProtectedVaultKey = encoded
+	return save(email, entry)
+}
+
+// LoadProtectedKey returns the master-key-wrapped vault key saved for email
+func LoadProtectedKey(email string) ([]byte, error) {
+	entry, err := load(email)
+	if err != nil {
+		return nil, err
+	}
+	if entry.ProtectedVaultKey == "" {
+		return nil, ErrNoCache
+	}
+	return utils.Ba64ToByt(entry.ProtectedVaultKey)
+}
+
+// LoadPreviousKey returns the master-key-wrapped vault key the journal may still be sealed with
+func LoadPreviousKey(email string) ([]byte, error) {
+	entry, err := load(email)
+	if err != nil {
+		return nil, err
+	}
+	if entry.PreviousVaultKey == "" {
+		return nil, ErrNoCache
+	}
+	return utils.Ba64ToByt(entry.PreviousVaultKey)
+}
+
+// SaveVault encrypts data with vaultKey and stores it as the offline copy of the vault
+func SaveVault(email string, vaultKey, data []byte) error {
+	encrypted, err := seal(data, vaultKey, "vault")
+	if err != nil {
+		return fmt.Errorf("failed to encrypt vault cache: %w", err)
+	}
+
+	entry, err := loadOrNew(email)
+	if err != nil {
+		return err
+	}
+	entry.Vault = encrypted
+	entry.SavedAt = time.Now().UTC()
+	return save(email, entry)
+}
+
+// LoadVault decrypts the offline copy of the vault and returns it with the time it was saved
+func LoadVault(email string, vaultKey []byte) ([]byte, time.Time, error) {
+	entry, err := load(email)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if entry.Vault == "" {
+		return nil, time.Time{}, ErrNoCache
+	}
+
+	data, err := open(entry.Vault, vaultKey, "vault")
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to decrypt vault cache: %w", err)
+	}
+	return data, entry.SavedAt, nil
+}
+
+// SaveJournal encrypts data with vaultKey and stores it as the list of writes waiting to be synced.
+// An empty data clears the journal.
+func SaveJournal(email string, vaultKey, data []byte) error {
+	entry, err := loadOrNew(email)
+	if err != nil {
+		return err
+	}
+
+	// The journal is sealed with the current key from now on
+	entry.Journal = ""
+	entry.PreviousVaultKey = ""
+	if len(data) > 0 {
+		entry.Journal, err = seal(data, vaultKey, "journal")
+		if err != nil {
+			return fmt.Errorf("failed to encrypt journal: %w", err)
+		}
+	}
+	return save(email, entry)
+}
+
+// LoadJournal decrypts the writes waiting to be synced, returning nil if there are none
+func LoadJournal(email string, vaultKey []byte) ([]byte, error) {
+	entry, err := load(email)
+	if errors.Is(err, ErrNoCache) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if entry.Journal == "" {
+		return nil, nil
+	}
+
+	data, err := open(entry.Journal, vaultKey, "journal")
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt journal: %w", err)
+	}
+	return data, nil
+}
+
+// SetBound records that every item of the vault has been migrated to bound ciphertext
+func SetBound(email string) error {
+	entry, err := loadOrNew(email)
+	if err != nil {
+		return err
+	}
+	entry.Bound = true
+	return save(email, entry)
+}
+
+// IsBound reports whether SetBound was called for email
+func IsBound(email string) bool {
+	entry, err := load(email)
+	return err == nil && entry.Bound
+}
+
+// SaveRotation encrypts data with masterKey and stores it as the vault key rotation in progress
+func SaveRotation(email string, masterKey, data []byte) error {
+	encrypted, err := seal(data, masterKey, "rotation")
+	if err != nil {
+		return fmt.Errorf("failed to encrypt rotation: %w", err)
+	}
+
+	entry, err := loadOrNew(email)
+	if err != nil {
+		return err
+	}
+	entry.Rotation = encrypted
+	return save(email, entry)
+}
+
+// LoadRotation decrypts the vault key rotation in progress, returning nil if there is none
+func LoadRotation(email string, masterKey []byte) ([]byte, error) {
+	entry, err := load(email)
+	if errors.Is(err, ErrNoCache) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if entry.Rotation == "" {
+		return nil, nil
+	}
+
+	data, err := open(entry.Rotation, masterKey, "rotation")
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt rotation: %w", err)
+	}
+	return data, nil
+}
+
+// ClearRotation forgets the vault key rotation in progress
+func ClearRotation(email string) error {
+	entry, err := load(email)
+	if errors.Is(err, ErrNoCache) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	entry.Rotation = ""
+	return save(email, entry)
+}
+
+// seal encrypts one part of the cache file, bound to field so the vault copy and the journal cannot be swapped
+func seal(data, vaultKey []byte, field string) (string, error) {
+	encrypted, err := envelope.Seal(data, vaultKey, envelope.Binding{Kind: "cache", Field: field})
+	if err != nil {
+		return "", err
+	}
+	return utils.BytToBa64(encrypted), nil
+}
+
+// open decrypts a part written by seal for the same field
+func open(encoded string, vaultKey []byte, field string) ([]byte, error) {
+	encrypted, err := utils.Ba64ToByt(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return envelope.Open(encrypted, vaultKey, envelope.Binding{Kind: "cache", Field: field})
+}
+
+// Remove deletes the cache file for email
+func Remove(email string) error {
+	path, err := fileFor(email)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove cache file: %w", err)
+	}
+	return nil
+}
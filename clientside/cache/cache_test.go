@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"Modsec/clientside/config"
+	"path/filepath"
+	"testing"
+)
+
+func TestFieldsCannotBeSwapped(t *testing.T) {
+	t.Setenv(config.EnvConfigFile, filepath.Join(t.TempDir(), "config.json"))
+	email := "cache@example.com"
+	vaultKey := make([]byte, 32)
+
+	if err := SaveVault(email, vaultKey, []byte(`{"items":[]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveJournal(email, vaultKey, []byte(`[]`)); err != nil {
+		t.Fatal(err)
+	}
+	if data, _, err := LoadVault(email, vaultKey); err != nil || string(data) != `{"items":[]}` {
+		t.Fatalf("LoadVault = %q, %v", data, err)
+	}
+
+	// Both parts are sealed with the same key, the field binding keeps them apart
+	entry, err := load(email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Vault, entry.Journal = entry.Journal, entry.Vault
+	if err := save(email, entry); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := LoadVault(email, vaultKey); err == nil {
+		t.Error("journal opened as the vault copy")
+	}
+	if _, err := LoadJournal(email, vaultKey); err == nil {
+		t.Error("vault copy opened as the journal")
+	}
+}
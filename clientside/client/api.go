@@ -0,0 +1,198 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// API sends JSON requests to the backend through HMClient
+type API struct {
+	mu      sync.RWMutex
+	baseURL string
+	timeout time.Duration
+}
+
+// Backend is the API shared by the auth and service packages
+var Backend = &API{}
+
+// APIError is returned when the backend answers with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return fmt.Sprintf("backend returned status: %d", e.StatusCode)
+}
+
+// errorBody is the JSON error shape returned by the backend
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Error   string `json:"error"`
+}
+
+func (a *API) configure(baseURL string, timeout time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.baseURL = strings.TrimRight(baseURL, "/")
+	a.timeout = timeout
+}
+
+// BaseURL returns the backend URL requests are sent to
+func (a *API) BaseURL() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.baseURL
+}
+
+// URL joins path onto the backend URL
+func (a *API) URL(path string) string {
+	return a.BaseURL() + "/" + strings.TrimLeft(path, "/")
+}
+
+// Do sends in as a JSON body to path and decodes the JSON response into out.
+// in and out may be nil. The configured timeout applies unless ctx already has a deadline.
+func (a *API) Do(ctx context.Context, method, path string, in, out interface{}) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	a.mu.RLock()
+	timeout := a.timeout
+	a.mu.RUnlock()
+
+	if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var body io.Reader
+	if in != nil {
+		jsonData, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		body = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.URL(path), body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Access-Control-Allow-Credentials", "true")
+
+	resp, err := HMClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: request to %s failed: %w", ErrNetwork, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return parseError(resp)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// Upload streams body to path as application/octet-stream and decodes the JSON response into out.
+// size is the body length, or -1 if unknown. Transfers can take long, so only ctx limits the request.
+func (a *API) Upload(ctx context.Context, path string, body io.Reader, size int64, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL(path), body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := HMClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("%w: upload to %s failed: %w", ErrNetwork, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return parseError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// Download opens the body of a GET to path for streaming. The caller closes it.
+// Transfers can take long, so only ctx limits the request.
+func (a *API) Download(ctx context.Context, path string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.URL(path), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+
+	resp, err := HMClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: download from %s failed: %w", ErrNetwork, path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, parseError(resp)
+	}
+	return resp.Body, nil
+}
+
+// parseError builds an APIError from the body of a failed response
+func parseError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil || len(bodyBytes) == 0 {
+		return apiErr
+	}
+
+	var body errorBody
+	if err := json.Unmarshal(bodyBytes, &body); err == nil {
+		apiErr.Code = body.Code
+		apiErr.Message = body.Message
+		if apiErr.Message == "" {
+			apiErr.Message = body.Error
+		}
+		return apiErr
+	}
+
+	// Not JSON, use the raw body as the message
+	apiErr.Message = strings.TrimSpace(string(bodyBytes))
+	return apiErr
+}
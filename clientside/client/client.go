@@ -1,15 +1,32 @@
 package client
 
 import (
+	"Modsec/clientside/config"
 	"net/http"
 	"net/http/cookiejar"
 )
 
 var HMClient *http.Client
 
-func InitClient() {
+func InitClient(cfg config.Config) error {
 	jar, _ := cookiejar.New(nil)
 	HMClient = &http.Client{
 		Jar: jar,
 	}
+	return Configure(cfg)
+}
+
+// Configure applies the backend URL, timeout and TLS settings, keeping the cookie jar
+func Configure(cfg config.Config) error {
+	tlsConfig, err := cfg.TLSClientConfig()
+	if err != nil {
+		return err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	HMClient.Transport = transport
+	Backend.configure(cfg.BackendURL, cfg.Timeout())
+	return nil
 }
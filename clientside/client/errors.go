@@ -0,0 +1,133 @@
+package client
+
+import (
+	"Modsec/clientside/vaulterr"
+	"errors"
+	"strings"
+)
+
+// Sentinel errors returned by the auth and service packages. Check them with errors.Is.
+var (
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrNetwork            = errors.New("unable to reach the server")
+	ErrServer             = errors.New("server error")
+	ErrSessionExpired     = errors.New("session expired")
+	ErrOffline            = errors.New("offline: this action needs the server")
+)
+
+// ErrorCode is a stable identifier for an error that the frontend can switch on
+type ErrorCode string
+
+const (
+	CodeNone               ErrorCode = ""
+	CodeInvalidCredentials ErrorCode = "INVALID_CREDENTIALS"
+	CodeUserNotFound       ErrorCode = "USER_NOT_FOUND"
+	CodeNetwork            ErrorCode = "NETWORK_ERROR"
+	CodeServer             ErrorCode = "SERVER_ERROR"
+	CodeSessionExpired     ErrorCode = "SESSION_EXPIRED"
+	CodeVaultLocked        ErrorCode = "VAULT_LOCKED"
+	CodeOffline            ErrorCode = "OFFLINE"
+	CodeInvalidItem        ErrorCode = "INVALID_ITEM"
+	CodeUnknown            ErrorCode = "UNKNOWN"
+)
+
+// AllErrorCodes is bound to the frontend so it gets a matching TypeScript enum
+var AllErrorCodes = []struct {
+	Value  ErrorCode
+	TSName string
+}{
+	{CodeNone, "NONE"},
+	{CodeInvalidCredentials, "INVALID_CREDENTIALS"},
+	{CodeUserNotFound, "USER_NOT_FOUND"},
+	{CodeNetwork, "NETWORK_ERROR"},
+	{CodeServer, "SERVER_ERROR"},
+	{CodeSessionExpired, "SESSION_EXPIRED"},
+	{CodeVaultLocked, "VAULT_LOCKED"},
+	{CodeOffline, "OFFLINE"},
+	{CodeInvalidItem, "INVALID_ITEM"},
+	{CodeUnknown, "UNKNOWN"},
+}
+
+var codeErrors = []struct {
+	code ErrorCode
+	err  error
+}{
+	{CodeInvalidCredentials, ErrInvalidCredentials},
+	{CodeUserNotFound, ErrUserNotFound},
+	{CodeNetwork, ErrNetwork},
+	{CodeServer, ErrServer},
+	{CodeSessionExpired, ErrSessionExpired},
+	{CodeOffline, ErrOffline},
+	{CodeInvalidItem, vaulterr.ErrInvalidItem},
+	{CodeInvalidItem, vaulterr.ErrUnknownType},
+	{CodeVaultLocked, vaulterr.ErrLocked},
+	{CodeInvalidCredentials, vaulterr.ErrWrongPassword},
+	{CodeSessionExpired, vaulterr.ErrNoVault},
+}
+
+// CodeOf returns the ErrorCode for err
+func CodeOf(err error) ErrorCode {
+	if err == nil {
+		return CodeNone
+	}
+	for _, ce := range codeErrors {
+		if errors.Is(err, ce.err) {
+			return ce.code
+		}
+	}
+	return CodeUnknown
+}
+
+// Message returns a user-facing description of the code
+func (c ErrorCode) Message() string {
+	switch c {
+	case CodeNone:
+		return ""
+	case CodeInvalidCredentials:
+		return "Incorrect email or password"
+	case CodeUserNotFound:
+		return "Email not found"
+	case CodeNetwork:
+		return "Unable to reach the server. Check your connection"
+	case CodeServer:
+		return "Server error. Please try again later"
+	case CodeSessionExpired:
+		return "Your session has expired. Please log in again"
+	case CodeVaultLocked:
+		return "Your vault is locked. Enter your master password to unlock it"
+	case CodeOffline:
+		return "You are offline. Changes are saved locally and will sync when the server is reachable"
+	case CodeInvalidItem:
+		return "Some fields of this item are missing or not valid"
+	default:
+		return "Something went wrong. Please try again"
+	}
+}
+
+// Unwrap maps the backend error onto one of the sentinel errors
+func (e *APIError) Unwrap() error {
+	for _, ce := range codeErrors {
+		if e.Code == string(ce.code) {
+			return ce.err
+		}
+	}
+
+	// Older backends only send a message, recognise the known ones
+	msg := strings.ToLower(e.Message)
+	switch {
+	case strings.Contains(msg, "password not match"), strings.Contains(msg, "invalid password"):
+		return ErrInvalidCredentials
+	case strings.Contains(msg, "email not found"), strings.Contains(msg, "failed to find user"),
+		strings.Contains(msg, "user not found"):
+		return ErrUserNotFound
+	}
+
+	switch {
+	case e.StatusCode >= 500:
+		return ErrServer
+	case e.StatusCode == 401:
+		return ErrSessionExpired
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"Modsec/clientside/vaulterr"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorCode
+	}{
+		{"nil", nil, CodeNone},
+		{"network", ErrNetwork, CodeNetwork},
+		{"wrapped network", fmt.Errorf("%w: request to /login failed: %w", ErrNetwork, errors.New("refused")), CodeNetwork},
+		{"offline", fmt.Errorf("saving: %w", ErrOffline), CodeOffline},
+		{"invalid credentials", ErrInvalidCredentials, CodeInvalidCredentials},
+		{"unknown type", fmt.Errorf("%w: bike", vaulterr.ErrUnknownType), CodeInvalidItem},
+		{"invalid item", vaulterr.ErrInvalidItem, CodeInvalidItem},
+		{"vault locked", vaulterr.ErrLocked, CodeVaultLocked},
+		{"wrong password", vaulterr.ErrWrongPassword, CodeInvalidCredentials},
+		{"no vault", vaulterr.ErrNoVault, CodeSessionExpired},
+		{"api code", &APIError{StatusCode: 400, Code: string(CodeUserNotFound)}, CodeUserNotFound},
+		{"api status", fmt.Errorf("sync: %w", &APIError{StatusCode: 502}), CodeServer},
+		{"other", errors.New("disk full"), CodeUnknown},
+	}
+	for _, tt := range tests {
+		if got := CodeOf(tt.err); got != tt.want {
+			t.Errorf("%s: CodeOf = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAPIErrorUnwrap(t *testing.T) {
+	tests := []struct {
+		name string
+		err  APIError
+		want error
+	}{
+		// A code wins over the status and the message
+		{"code", APIError{StatusCode: 500, Code: "INVALID_CREDENTIALS", Message: "user not found"}, ErrInvalidCredentials},
+		{"session code", APIError{StatusCode: 403, Code: "SESSION_EXPIRED"}, ErrSessionExpired},
+		{"offline code", APIError{StatusCode: 503, Code: "OFFLINE"}, ErrOffline},
+		{"unknown code", APIError{StatusCode: 400, Code: "SOMETHING_NEW"}, nil},
+
+		// Older backends only send a message
+		{"password not match", APIError{StatusCode: 400, Message: "Password not match"}, ErrInvalidCredentials},
+		{"invalid password", APIError{StatusCode: 401, Message: "Invalid password"}, ErrInvalidCredentials},
+		{"email not found", APIError{StatusCode: 404, Message: "Email not found"}, ErrUserNotFound},
+		{"failed to find user", APIError{StatusCode: 500, Message: "failed to find user: no rows"}, ErrUserNotFound},
+		{"user not found", APIError{StatusCode: 400, Message: "User not found"}, ErrUserNotFound},
+
+		// Otherwise the status decides
+		{"server error", APIError{StatusCode: 500, Message: "database down"}, ErrServer},
+		{"bad gateway", APIError{StatusCode: 502}, ErrServer},
+		{"unauthorized", APIError{StatusCode: 401}, ErrSessionExpired},
+		{"bad request", APIError{StatusCode: 400, Message: "bad request"}, nil},
+		{"conflict", APIError{StatusCode: 409}, nil},
+	}
+	for _, tt := range tests {
+		err := tt.err
+		if got := err.Unwrap(); got != tt.want {
+			t.Errorf("%s: Unwrap = %v, want %v", tt.name, got, tt.want)
+		}
+		if tt.want != nil && !errors.Is(&err, tt.want) {
+			t.Errorf("%s: errors.Is does not match %v", tt.name, tt.want)
+		}
+	}
+}
+
+func TestErrorCodesComplete(t *testing.T) {
+	bound := map[ErrorCode]bool{}
+	for _, c := range AllErrorCodes {
+		bound[c.Value] = true
+		if c.TSName != string(c.Value) && c.Value != CodeNone {
+			t.Errorf("%q is bound as %s", c.Value, c.TSName)
+		}
+	}
+	for _, ce := range codeErrors {
+		if !bound[ce.code] {
+			t.Errorf("%q is returned by CodeOf but not bound to the frontend", ce.code)
+		}
+		if ce.code.Message() == CodeUnknown.Message() {
+			t.Errorf("%q has no message of its own", ce.code)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables that override values from the config file
+const (
+	EnvConfigFile    = "MODSEC_CONFIG"
+	EnvBackendURL    = "MODSEC_BACKEND_URL"
+	EnvTimeout       = "MODSEC_TIMEOUT"
+	EnvTLSInsecure   = "MODSEC_TLS_INSECURE"
+	EnvTLSCAFile     = "MODSEC_TLS_CA_FILE"
+	EnvTLSServerName = "MODSEC_TLS_SERVER_NAME"
+	EnvBreachCheck   = "MODSEC_BREACH_CHECK"
+	EnvBreachURL     = "MODSEC_BREACH_RANGE_URL"
+)
+
+// DefaultBreachRangeURL is the Have I Been Pwned range API
+const DefaultBreachRangeURL = "https://api.pwnedpasswords.com/range"
+
+// TLSConfig holds the TLS options used when talking to the backend
+type TLSConfig struct {
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+	CAFile             string `json:"ca_file,omitempty"`
+	ServerName         string `json:"server_name,omitempty"`
+}
+
+// BreachConfig holds the options of the compromised password check. Only the first 5 characters of the
+// SHA-1 hash of a password are sent to the range endpoint.
+type BreachConfig struct {
+	Enabled  bool   `json:"enabled"`
+	RangeURL string `json:"range_url"`
+}
+
+// Config holds the client configuration
+type Config struct {
+	BackendURL     string       `json:"backend_url"`
+	TimeoutSeconds int          `json:"timeout_seconds"`
+	TLS            TLSConfig    `json:"tls"`
+	Breach         BreachConfig `json:"breach"`
+}
+
+var (
+	mu      sync.RWMutex
+	current = Default()
+	file    = Default() // current without environment overrides, as in the config file
+)
+
+// Default returns the built-in configuration used when nothing else is set
+func Default() Config {
+	return Config{
+		BackendURL:     "http://localhost:8080",
+		TimeoutSeconds: 30,
+		Breach:         BreachConfig{RangeURL: DefaultBreachRangeURL},
+	}
+}
+
+// Validate checks that the configuration can be used to reach a backend
+func (c Config) Validate() error {
+	u, err := url.Parse(c.BackendURL)
+	if err != nil {
+		return fmt.Errorf("invalid backend URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid backend URL: scheme must be http or https")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid backend URL: missing host")
+	}
+	if c.TimeoutSeconds <= 0 {
+		return fmt.Errorf("timeout must be greater than zero")
+	}
+	if c.Breach.Enabled {
+		u, err := url.Parse(c.Breach.RangeURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid breach range URL: must be an http or https URL")
+		}
+	}
+	return nil
+}
+
+// Timeout returns the request timeout as a duration
+func (c Config) Timeout() time.Duration {
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+// TLSClientConfig builds the tls.Config for the HTTP transport
+func (c Config) TLSClientConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.TLS.InsecureSkipVerify,
+		ServerName:         c.TLS.ServerName,
+	}
+
+	if c.TLS.CAFile != "" {
+		pem, err := os.ReadFile(c.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", c.TLS.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// Path returns the location of the config file
+func Path() (string, error) {
+	if p := os.Getenv(EnvConfigFile); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "Modsec", "config.json"), nil
+}
+
+// Load reads the config file, applies environment overrides and makes the result current
+func Load() (Config, error) {
+	cfg := Default()
+
+	path, err := Path()
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return cfg, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return Default(), fmt.Errorf("failed to decode config file: %w", err)
+		}
+	}
+	normalize(&cfg)
+	stored := cfg
+
+	applyEnv(&cfg)
+	normalize(&cfg)
+
+	if err := cfg.Validate(); err != nil {
+		return Default(), err
+	}
+
+	mu.Lock()
+	current = cfg
+	file = stored
+	mu.Unlock()
+
+	return cfg, nil
+}
+
+// normalize trims the URLs and fills in the default breach range URL
+func normalize(cfg *Config) {
+	cfg.BackendURL = strings.TrimRight(strings.TrimSpace(cfg.BackendURL), "/")
+	cfg.Breach.RangeURL = strings.TrimRight(strings.TrimSpace(cfg.Breach.RangeURL), "/")
+	if cfg.Breach.RangeURL == "" {
+		cfg.Breach.RangeURL = DefaultBreachRangeURL
+	}
+}
+
+// envOverrides lists the environment variables that override a setting. apply reports whether the value
+// was used, keep copies the setting from src to dst.
+var envOverrides = []struct {
+	name  string
+	apply func(cfg *Config, v string) bool
+	keep  func(dst, src *Config)
+}{
+	{EnvBackendURL,
+		func(cfg *Config, v string) bool { cfg.BackendURL = v; return true },
+		func(dst, src *Config) { dst.BackendURL = src.BackendURL }},
+	{EnvTimeout,
+		func(cfg *Config, v string) bool {
+			n, err := strconv.Atoi(v)
+			if err == nil {
+				cfg.TimeoutSeconds = n
+			}
+			return err == nil
+		},
+		func(dst, src *Config) { dst.TimeoutSeconds = src.TimeoutSeconds }},
+	{EnvTLSInsecure,
+		func(cfg *Config, v string) bool {
+			b, err := strconv.ParseBool(v)
+			if err == nil {
+				cfg.TLS.InsecureSkipVerify = b
+			}
+			return err == nil
+		},
+		func(dst, src *Config) { dst.TLS.InsecureSkipVerify = src.TLS.InsecureSkipVerify }},
+	{EnvTLSCAFile,
+		func(cfg *Config, v string) bool { cfg.TLS.CAFile = v; return true },
+		func(dst, src *Config) { dst.TLS.CAFile = src.TLS.CAFile }},
+	{EnvTLSServerName,
+		func(cfg *Config, v string) bool { cfg.TLS.ServerName = v; return true },
+		func(dst, src *Config) { dst.TLS.ServerName = src.TLS.ServerName }},
+	{EnvBreachCheck,
+		func(cfg *Config, v string) bool {
+			b, err := strconv.ParseBool(v)
+			if err == nil {
+				cfg.Breach.Enabled = b
+			}
+			return err == nil
+		},
+		func(dst, src *Config) { dst.Breach.Enabled = src.Breach.Enabled }},
+	{EnvBreachURL,
+		func(cfg *Config, v string) bool { cfg.Breach.RangeURL = v; return true },
+		func(dst, src *Config) { dst.Breach.RangeURL = src.Breach.RangeURL }},
+}
+
+// applyEnv overrides cfg with any values set in the environment
+func applyEnv(cfg *Config) {
+	for _, o := range envOverrides {
+		if v := os.Getenv(o.name); v != "" {
+			o.apply(cfg, v)
+		}
+	}
+}
+
+// withoutEnv returns cfg with the settings the environment overrides taken from stored, so overrides are
+// not written to the config file
+func withoutEnv(cfg, stored Config) Config {
+	for _, o := range envOverrides {
+		if v := os.Getenv(o.name); v != "" && o.apply(&Config{}, v) {
+			o.keep(&cfg, &stored)
+		}
+	}
+	return cfg
+}
+
+// Get returns the current configuration
+func Get() Config {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Set validates cfg, writes it to the config file and makes it current. Settings overridden by the
+// environment keep their value in the file and stay overridden.
+func Set(cfg Config) error {
+	normalize(&cfg)
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	stored := withoutEnv(cfg, file)
+	effective := stored
+	applyEnv(&effective)
+	normalize(&effective)
+	if err := effective.Validate(); err != nil {
+		return err
+	}
+	// The CA file has to load before anything is saved
+	if _, err := effective.TLSClientConfig(); err != nil {
+		return err
+	}
+
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	current = effective
+	file = stored
+	return nil
+}
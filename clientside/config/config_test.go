@@ -0,0 +1,129 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tempConfig points the config file into a temporary directory, clears the overrides and returns the path
+func tempConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	t.Setenv(EnvConfigFile, path)
+	for _, o := range envOverrides {
+		t.Setenv(o.name, "")
+	}
+	t.Cleanup(func() {
+		mu.Lock()
+		current, file = Default(), Default()
+		mu.Unlock()
+	})
+	return path
+}
+
+func readConfig(t *testing.T, path string) Config {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestLoadNormalizes(t *testing.T) {
+	path := tempConfig(t)
+	os.WriteFile(path, []byte(`{"backend_url": " https://vault.example.com/ ", "timeout_seconds": 10}`), 0600)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.BackendURL != "https://vault.example.com" || cfg.Breach.RangeURL != DefaultBreachRangeURL {
+		t.Fatalf("loaded %+v", cfg)
+	}
+
+	t.Setenv(EnvBackendURL, "https://override.example.com/")
+	if cfg, err := Load(); err != nil || cfg.BackendURL != "https://override.example.com" {
+		t.Fatalf("override loaded as %q, %v", cfg.BackendURL, err)
+	}
+}
+
+func TestSetKeepsOverridesOutOfFile(t *testing.T) {
+	path := tempConfig(t)
+	os.WriteFile(path, []byte(`{"backend_url": "https://vault.example.com", "timeout_seconds": 10}`), 0600)
+	t.Setenv(EnvBackendURL, "https://override.example.com")
+	t.Setenv(EnvTimeout, "not a number")
+	if _, err := Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The settings screen sends back what Get returned, with the timeout changed
+	cfg := Get()
+	cfg.TimeoutSeconds = 60
+	if err := Set(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	stored := readConfig(t, path)
+	if stored.BackendURL != "https://vault.example.com" {
+		t.Errorf("override written to the file: %q", stored.BackendURL)
+	}
+	if stored.TimeoutSeconds != 60 {
+		t.Errorf("timeout not saved, an invalid override does not apply: %d", stored.TimeoutSeconds)
+	}
+	if got := Get(); got.BackendURL != "https://override.example.com" || got.TimeoutSeconds != 60 {
+		t.Errorf("current config = %+v", got)
+	}
+}
+
+func TestSetChecksCAFileFirst(t *testing.T) {
+	path := tempConfig(t)
+
+	cfg := Default()
+	cfg.TLS.CAFile = filepath.Join(t.TempDir(), "missing.pem")
+	if err := Set(cfg); err == nil {
+		t.Fatal("missing CA file accepted")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("config file written for a rejected config: %v", err)
+	}
+	if Get().TLS.CAFile != "" {
+		t.Fatal("rejected config became current")
+	}
+
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(notPEM, []byte("not a certificate"), 0600)
+	cfg.TLS.CAFile = notPEM
+	if err := Set(cfg); err == nil {
+		t.Fatal("CA file without certificates accepted")
+	}
+}
+
+func TestSetRoundTrip(t *testing.T) {
+	path := tempConfig(t)
+
+	cfg := Default()
+	cfg.BackendURL = "https://vault.example.com/ "
+	cfg.Breach = BreachConfig{Enabled: true}
+	if err := Set(cfg); err != nil {
+		t.Fatal(err)
+	}
+	stored := readConfig(t, path)
+	if stored.BackendURL != "https://vault.example.com" || stored.Breach.RangeURL != DefaultBreachRangeURL {
+		t.Fatalf("stored %+v", stored)
+	}
+	if loaded, err := Load(); err != nil || loaded != stored {
+		t.Fatalf("Load = %+v, %v, want %+v", loaded, err, stored)
+	}
+
+	cfg.BackendURL = "ftp://vault.example.com"
+	if err := Set(cfg); err == nil {
+		t.Fatal("ftp backend accepted")
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Preferences holds user settings that are not about the backend connection
+type Preferences struct {
+	AutoLock             bool `json:"auto_lock"`
+	AutoLockMinutes      int  `json:"auto_lock_minutes"`
+	PasswordHistoryLimit int  `json:"password_history_limit"` // Previous passwords kept per item
+}
+
+// MaxPasswordHistoryLimit is the largest password history a user can ask for
+const MaxPasswordHistoryLimit = 100
+
+var (
+	prefMu      sync.RWMutex
+	currentPref = DefaultPreferences()
+)
+
+// DefaultPreferences returns the preferences used before the user changes anything
+func DefaultPreferences() Preferences {
+	return Preferences{
+		AutoLock:             true,
+		AutoLockMinutes:      5,
+		PasswordHistoryLimit: 10,
+	}
+}
+
+// Validate checks the preference values are usable
+func (p Preferences) Validate() error {
+	if p.AutoLockMinutes < 1 {
+		return fmt.Errorf("auto-lock time must be at least 1 minute")
+	}
+	if p.PasswordHistoryLimit < 0 || p.PasswordHistoryLimit > MaxPasswordHistoryLimit {
+		return fmt.Errorf("password history must keep between 0 and %d passwords", MaxPasswordHistoryLimit)
+	}
+	return nil
+}
+
+// AutoLockAfter returns the idle time before the vault locks, or 0 when auto-lock is off
+func (p Preferences) AutoLockAfter() time.Duration {
+	if !p.AutoLock {
+		return 0
+	}
+	return time.Duration(p.AutoLockMinutes) * time.Minute
+}
+
+// PreferencesPath returns the location of the preferences file, next to the config file
+func PreferencesPath() (string, error) {
+	path, err := Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "preferences.json"), nil
+}
+
+// LoadPreferences reads the preferences file and makes the result current
+func LoadPreferences() (Preferences, error) {
+	pref := DefaultPreferences()
+
+	path, err := PreferencesPath()
+	if err != nil {
+		return pref, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return pref, nil
+	}
+	if err != nil {
+		return pref, fmt.Errorf("failed to read preferences file: %w", err)
+	}
+	if err := json.Unmarshal(data, &pref); err != nil {
+		return DefaultPreferences(), fmt.Errorf("failed to decode preferences file: %w", err)
+	}
+	if err := pref.Validate(); err != nil {
+		return DefaultPreferences(), err
+	}
+
+	prefMu.Lock()
+	currentPref = pref
+	prefMu.Unlock()
+
+	return pref, nil
+}
+
+// GetPreferences returns the current preferences
+func GetPreferences() Preferences {
+	prefMu.RLock()
+	defer prefMu.RUnlock()
+	return currentPref
+}
+
+// SetPreferences validates pref, makes it current and writes it to the preferences file
+func SetPreferences(pref Preferences) error {
+	if err := pref.Validate(); err != nil {
+		return err
+	}
+
+	path, err := PreferencesPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(pref, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode preferences: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write preferences file: %w", err)
+	}
+
+	prefMu.Lock()
+	currentPref = pref
+	prefMu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,286 @@
+package generator
+
+// Password and passphrase generation. All randomness comes from crypto/rand and every choice is
+// uniform, so the entropy reported with a result is exact for passphrases and for passwords without
+// minimum counts. Minimum counts rule out some passwords, so for those it is an upper bound.
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// ErrInvalidPolicy is returned for policies that cannot produce a result
+var ErrInvalidPolicy = errors.New("invalid generator policy")
+
+// Character classes
+const (
+	Lowercase      = "abcdefghijklmnopqrstuvwxyz"
+	Uppercase      = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	Digits         = "0123456789"
+	DefaultSymbols = "!@#$%^&*_+-|."
+	// Ambiguous characters are easy to misread or mistype
+	Ambiguous = "Il1|O0o`'\""
+)
+
+// Length limits, MinLength matches the minimum the backend accepts for a password
+const (
+	MinLength = 8
+	MaxLength = 128
+
+	MinWords = 3
+	MaxWords = 24
+)
+
+// PasswordPolicy describes a random password
+type PasswordPolicy struct {
+	Length           int    `json:"length"`
+	Lowercase        bool   `json:"lowercase"`
+	Uppercase        bool   `json:"uppercase"`
+	Digits           bool   `json:"digits"`
+	Symbols          bool   `json:"symbols"`
+	SymbolSet        string `json:"symbolSet,omitempty"` // Defaults to DefaultSymbols
+	MinLowercase     int    `json:"minLowercase"`
+	MinUppercase     int    `json:"minUppercase"`
+	MinDigits        int    `json:"minDigits"`
+	MinSymbols       int    `json:"minSymbols"`
+	ExcludeAmbiguous bool   `json:"excludeAmbiguous"`
+}
+
+// PassphrasePolicy describes a diceware-style passphrase drawn from the BIP39 English wordlist
+type PassphrasePolicy struct {
+	Words         int    `json:"words"`
+	Separator     string `json:"separator"`
+	Capitalize    bool   `json:"capitalize"`
+	IncludeNumber bool   `json:"includeNumber"` // Appends a random digit to one random word
+}
+
+// Result is a generated secret and its entropy in bits
+type Result struct {
+	Value   string  `json:"value"`
+	Entropy float64 `json:"entropy"`
+}
+
+// DefaultPasswordPolicy returns the policy the generator starts with
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		Length:       20,
+		Lowercase:    true,
+		Uppercase:    true,
+		Digits:       true,
+		Symbols:      true,
+		MinLowercase: 1,
+		MinUppercase: 1,
+		MinDigits:    1,
+		MinSymbols:   1,
+	}
+}
+
+// DefaultPassphrasePolicy returns the passphrase policy the generator starts with
+func DefaultPassphrasePolicy() PassphrasePolicy {
+	return PassphrasePolicy{Words: 6, Separator: "-"}
+}
+
+// class is one set of characters with its minimum count
+type class struct {
+	name  string
+	chars string
+	min   int
+}
+
+// classes returns the enabled character classes, with ambiguous characters removed if asked
+func (p PasswordPolicy) classes() []class {
+	symbols := p.SymbolSet
+	if symbols == "" {
+		symbols = DefaultSymbols
+	}
+
+	all := []struct {
+		enabled bool
+		class
+	}{
+		{p.Lowercase, class{"lowercase", Lowercase, p.MinLowercase}},
+		{p.Uppercase, class{"uppercase", Uppercase, p.MinUppercase}},
+		{p.Digits, class{"digits", Digits, p.MinDigits}},
+		{p.Symbols, class{"symbols", symbols, p.MinSymbols}},
+	}
+
+	var enabled []class
+	for _, c := range all {
+		if !c.enabled {
+			continue
+		}
+		if p.ExcludeAmbiguous {
+			c.chars = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(Ambiguous, r) {
+					return -1
+				}
+				return r
+			}, c.chars)
+		}
+		enabled = append(enabled, c.class)
+	}
+	return enabled
+}
+
+// Validate checks the policy can produce a password
+func (p PasswordPolicy) Validate() error {
+	if p.Length < MinLength || p.Length > MaxLength {
+		return fmt.Errorf("%w: length must be %d to %d", ErrInvalidPolicy, MinLength, MaxLength)
+	}
+	if p.MinLowercase < 0 || p.MinUppercase < 0 || p.MinDigits < 0 || p.MinSymbols < 0 {
+		return fmt.Errorf("%w: minimum counts cannot be negative", ErrInvalidPolicy)
+	}
+	if !p.Lowercase && p.MinLowercase > 0 || !p.Uppercase && p.MinUppercase > 0 ||
+		!p.Digits && p.MinDigits > 0 || !p.Symbols && p.MinSymbols > 0 {
+		return fmt.Errorf("%w: a minimum is set for a character class that is turned off", ErrInvalidPolicy)
+	}
+
+	if p.Symbols {
+		if err := validSymbols(p.SymbolSet); err != nil {
+			return err
+		}
+	}
+
+	classes := p.classes()
+	if len(classes) == 0 {
+		return fmt.Errorf("%w: turn on at least one character class", ErrInvalidPolicy)
+	}
+	required := 0
+	for _, c := range classes {
+		if c.chars == "" {
+			return fmt.Errorf("%w: no %s left to choose from", ErrInvalidPolicy, c.name)
+		}
+		required += c.min
+	}
+	if required > p.Length {
+		return fmt.Errorf("%w: minimum counts add up to %d, more than the length %d", ErrInvalidPolicy, required, p.Length)
+	}
+	return nil
+}
+
+// validSymbols checks a custom symbol set. Passwords are built byte by byte from classes that must not
+// overlap, so symbols are printable ASCII other than letters and digits, each listed once.
+func validSymbols(symbols string) error {
+	for i := 0; i < len(symbols); i++ {
+		ch := symbols[i]
+		if ch <= ' ' || ch > '~' || strings.IndexByte(Lowercase+Uppercase+Digits, ch) >= 0 {
+			return fmt.Errorf("%w: symbols must be printable ASCII other than letters and digits", ErrInvalidPolicy)
+		}
+		if strings.IndexByte(symbols[:i], ch) >= 0 {
+			return fmt.Errorf("%w: symbol %q is listed twice", ErrInvalidPolicy, ch)
+		}
+	}
+	return nil
+}
+
+// Password generates a password that satisfies p
+func Password(p PasswordPolicy) (Result, error) {
+	if err := p.Validate(); err != nil {
+		return Result{}, err
+	}
+	classes := p.classes()
+
+	// Required characters from each class first, the rest from every enabled class
+	var pool strings.Builder
+	password := make([]byte, 0, p.Length)
+	for _, c := range classes {
+		pool.WriteString(c.chars)
+		for i := 0; i < c.min; i++ {
+			ch, err := pick(c.chars)
+			if err != nil {
+				return Result{}, err
+			}
+			password = append(password, ch)
+		}
+	}
+	chars := pool.String()
+	for len(password) < p.Length {
+		ch, err := pick(chars)
+		if err != nil {
+			return Result{}, err
+		}
+		password = append(password, ch)
+	}
+
+	// Shuffle so the required characters are not always at the front
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomIndex(i + 1)
+		if err != nil {
+			return Result{}, err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+
+	return Result{Value: string(password), Entropy: float64(p.Length) * math.Log2(float64(len(chars)))}, nil
+}
+
+// Validate checks the policy can produce a passphrase
+func (p PassphrasePolicy) Validate() error {
+	if p.Words < MinWords || p.Words > MaxWords {
+		return fmt.Errorf("%w: word count must be %d to %d", ErrInvalidPolicy, MinWords, MaxWords)
+	}
+	if len(p.Separator) > 3 {
+		return fmt.Errorf("%w: separator can be at most 3 characters", ErrInvalidPolicy)
+	}
+	return nil
+}
+
+// Passphrase generates a passphrase that satisfies p
+func Passphrase(p PassphrasePolicy) (Result, error) {
+	if err := p.Validate(); err != nil {
+		return Result{}, err
+	}
+	wordlist := bip39.GetWordList()
+
+	words := make([]string, p.Words)
+	for i := range words {
+		n, err := randomIndex(len(wordlist))
+		if err != nil {
+			return Result{}, err
+		}
+		words[i] = wordlist[n]
+		if p.Capitalize {
+			words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+		}
+	}
+	entropy := float64(p.Words) * math.Log2(float64(len(wordlist)))
+
+	if p.IncludeNumber {
+		word, err := randomIndex(len(words))
+		if err != nil {
+			return Result{}, err
+		}
+		digit, err := randomIndex(10)
+		if err != nil {
+			return Result{}, err
+		}
+		words[word] += Digits[digit : digit+1]
+		entropy += math.Log2(float64(len(words) * 10))
+	}
+
+	return Result{Value: strings.Join(words, p.Separator), Entropy: entropy}, nil
+}
+
+// pick returns a uniformly chosen byte of chars
+func pick(chars string) (byte, error) {
+	i, err := randomIndex(len(chars))
+	if err != nil {
+		return 0, err
+	}
+	return chars[i], nil
+}
+
+// randomIndex returns a uniform integer in [0, n) from crypto/rand
+func randomIndex(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return int(v.Int64()), nil
+}
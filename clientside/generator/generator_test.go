@@ -0,0 +1,112 @@
+package generator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func count(s, chars string) int {
+	n := 0
+	for _, r := range s {
+		if strings.ContainsRune(chars, r) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestPasswordMinimums(t *testing.T) {
+	p := PasswordPolicy{Length: 12, Lowercase: true, Uppercase: true, Digits: true, Symbols: true,
+		MinUppercase: 3, MinDigits: 4, MinSymbols: 2}
+	for i := 0; i < 200; i++ {
+		r, err := Password(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Value) != 12 {
+			t.Fatalf("length = %d", len(r.Value))
+		}
+		if count(r.Value, Uppercase) < 3 || count(r.Value, Digits) < 4 || count(r.Value, DefaultSymbols) < 2 {
+			t.Fatalf("%q does not meet the minimums", r.Value)
+		}
+	}
+}
+
+func TestPasswordExcludeAmbiguous(t *testing.T) {
+	p := DefaultPasswordPolicy()
+	p.Length = 128
+	p.ExcludeAmbiguous = true
+	for i := 0; i < 50; i++ {
+		r, err := Password(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.ContainsAny(r.Value, Ambiguous) {
+			t.Fatalf("%q contains an ambiguous character", r.Value)
+		}
+	}
+}
+
+func TestPasswordOnlyEnabledClasses(t *testing.T) {
+	r, err := Password(PasswordPolicy{Length: 64, Digits: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count(r.Value, Digits) != 64 {
+		t.Fatalf("%q has characters outside digits", r.Value)
+	}
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	tests := map[string]PasswordPolicy{
+		"too short":          {Length: 4, Lowercase: true},
+		"too long":           {Length: 129, Lowercase: true},
+		"no classes":         {Length: 16},
+		"minimums too large": {Length: 8, Digits: true, Symbols: true, MinDigits: 5, MinSymbols: 4},
+		"minimum on off":     {Length: 16, Lowercase: true, MinDigits: 1},
+		"negative minimum":   {Length: 16, Lowercase: true, MinLowercase: -1},
+		"empty after filter": {Length: 16, Symbols: true, SymbolSet: "|`", ExcludeAmbiguous: true},
+		"non-ASCII symbol":   {Length: 16, Symbols: true, SymbolSet: "!§"},
+		"space symbol":       {Length: 16, Symbols: true, SymbolSet: "! "},
+		"letter symbol":      {Length: 16, Lowercase: true, Symbols: true, SymbolSet: "!a"},
+		"duplicate symbol":   {Length: 16, Symbols: true, SymbolSet: "!!?"},
+	}
+	for name, p := range tests {
+		if _, err := Password(p); !errors.Is(err, ErrInvalidPolicy) {
+			t.Errorf("%s: err = %v, want ErrInvalidPolicy", name, err)
+		}
+	}
+	if err := DefaultPasswordPolicy().Validate(); err != nil {
+		t.Errorf("default policy: %v", err)
+	}
+	if _, err := Password(PasswordPolicy{Length: 16, Lowercase: true, SymbolSet: "§"}); err != nil {
+		t.Errorf("symbol set of turned off symbols: %v", err)
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+	r, err := Passphrase(PassphrasePolicy{Words: 7, Separator: " ", Capitalize: true, IncludeNumber: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := strings.Split(r.Value, " ")
+	if len(words) != 7 {
+		t.Fatalf("%q has %d words", r.Value, len(words))
+	}
+	if count(r.Value, Digits) != 1 {
+		t.Fatalf("%q should contain exactly one digit", r.Value)
+	}
+	for _, w := range words {
+		if w[0] < 'A' || w[0] > 'Z' {
+			t.Fatalf("%q is not capitalized", w)
+		}
+	}
+	if r.Entropy < 77 {
+		t.Fatalf("entropy = %.1f, want at least 77 bits", r.Entropy)
+	}
+
+	if _, err := Passphrase(PassphrasePolicy{Words: 2}); !errors.Is(err, ErrInvalidPolicy) {
+		t.Fatalf("err = %v, want ErrInvalidPolicy", err)
+	}
+}
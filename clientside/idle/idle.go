@@ -0,0 +1,81 @@
+package idle
+
+import (
+	"sync"
+	"time"
+)
+
+// Timer calls onExpire once no activity has been reported for the timeout.
+// It only runs after Touch, and stays stopped after it fires until the next Touch.
+type Timer struct {
+	mu       sync.Mutex
+	timeout  time.Duration
+	timer    *time.Timer
+	gen      uint64 // Bumped on every reset so a stale callback can tell it was superseded
+	onExpire func()
+}
+
+// NewTimer creates a stopped Timer. A timeout of 0 disables it.
+func NewTimer(timeout time.Duration, onExpire func()) *Timer {
+	return &Timer{
+		timeout:  timeout,
+		onExpire: onExpire,
+	}
+}
+
+// Touch records activity and restarts the countdown
+func (t *Timer) Touch() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.restart()
+}
+
+// SetTimeout changes the idle timeout and restarts the countdown. A timeout of 0 disables it.
+func (t *Timer) SetTimeout(timeout time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timeout = timeout
+	t.restart()
+}
+
+// Timeout returns the current idle timeout
+func (t *Timer) Timeout() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.timeout
+}
+
+// Stop cancels the countdown
+func (t *Timer) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stop()
+}
+
+func (t *Timer) stop() {
+	t.gen++
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
+
+func (t *Timer) restart() {
+	t.stop()
+	if t.timeout <= 0 {
+		return
+	}
+
+	gen := t.gen
+	t.timer = time.AfterFunc(t.timeout, func() {
+		t.mu.Lock()
+		if gen != t.gen {
+			t.mu.Unlock()
+			return
+		}
+		t.timer = nil
+		t.mu.Unlock()
+
+		t.onExpire()
+	})
+}
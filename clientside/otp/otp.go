@@ -0,0 +1,224 @@
+package otp
+
+// One-time passwords: HOTP (RFC 4226) and TOTP (RFC 6238), configured from otpauth:// URIs
+// (https://github.com/google/google-authenticator/wiki/Key-Uri-Format) or bare base32 secrets.
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidKey is returned for secrets and URIs that cannot be used
+var ErrInvalidKey = errors.New("invalid one-time password key")
+
+// Kind tells counter-based and time-based keys apart
+type Kind string
+
+const (
+	HOTP Kind = "hotp"
+	TOTP Kind = "totp"
+)
+
+// Algorithm is the HMAC hash a key uses
+type Algorithm string
+
+const (
+	SHA1   Algorithm = "SHA1"
+	SHA256 Algorithm = "SHA256"
+	SHA512 Algorithm = "SHA512"
+)
+
+// Defaults used by authenticator apps when a URI leaves a parameter out
+const (
+	DefaultDigits = 6
+	DefaultPeriod = 30 * time.Second
+)
+
+// Key holds everything needed to produce codes
+type Key struct {
+	Kind      Kind
+	Issuer    string
+	Account   string
+	Secret    []byte
+	Algorithm Algorithm
+	Digits    int
+	Period    time.Duration // TOTP only
+	Counter   uint64        // HOTP only, the counter of the next code
+}
+
+// Parse reads an otpauth:// URI, or a bare base32 secret which is taken as a default TOTP key
+func Parse(s string) (*Key, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(strings.ToLower(s), "otpauth://") {
+		secret, err := decodeSecret(s)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{Kind: TOTP, Secret: secret, Algorithm: SHA1, Digits: DefaultDigits, Period: DefaultPeriod}, nil
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	key := &Key{Kind: Kind(strings.ToLower(u.Host)), Algorithm: SHA1, Digits: DefaultDigits, Period: DefaultPeriod}
+	if key.Kind != HOTP && key.Kind != TOTP {
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidKey, u.Host)
+	}
+
+	// The label is "issuer:account" or just "account"
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		key.Issuer, key.Account = strings.TrimSpace(issuer), strings.TrimSpace(account)
+	} else {
+		key.Account = label
+	}
+
+	q := u.Query()
+	if key.Secret, err = decodeSecret(q.Get("secret")); err != nil {
+		return nil, err
+	}
+	if issuer := q.Get("issuer"); issuer != "" {
+		key.Issuer = issuer
+	}
+	if alg := q.Get("algorithm"); alg != "" {
+		key.Algorithm = Algorithm(strings.ToUpper(alg))
+	}
+	if digits := q.Get("digits"); digits != "" {
+		if key.Digits, err = strconv.Atoi(digits); err != nil {
+			return nil, fmt.Errorf("%w: digits %q", ErrInvalidKey, digits)
+		}
+	}
+	if period := q.Get("period"); period != "" {
+		seconds, err := strconv.Atoi(period)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("%w: period %q", ErrInvalidKey, period)
+		}
+		key.Period = time.Duration(seconds) * time.Second
+	}
+	if key.Kind == HOTP {
+		counter := q.Get("counter")
+		if counter == "" {
+			return nil, fmt.Errorf("%w: hotp needs a counter", ErrInvalidKey)
+		}
+		if key.Counter, err = strconv.ParseUint(counter, 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: counter %q", ErrInvalidKey, counter)
+		}
+	}
+
+	return key, key.validate()
+}
+
+// decodeSecret reads base32 the way people paste it: any case, with spaces, with or without padding
+func decodeSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(s))
+	s = strings.TrimRight(s, "=")
+	if s == "" {
+		return nil, fmt.Errorf("%w: missing secret", ErrInvalidKey)
+	}
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: secret is not base32", ErrInvalidKey)
+	}
+	return secret, nil
+}
+
+func (k *Key) validate() error {
+	if _, err := k.hash(); err != nil {
+		return err
+	}
+	if k.Digits < 6 || k.Digits > 8 {
+		return fmt.Errorf("%w: digits must be 6 to 8, got %d", ErrInvalidKey, k.Digits)
+	}
+	return nil
+}
+
+func (k *Key) hash() (func() hash.Hash, error) {
+	switch k.Algorithm {
+	case SHA1:
+		return sha1.New, nil
+	case SHA256:
+		return sha256.New, nil
+	case SHA512:
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("%w: unknown algorithm %q", ErrInvalidKey, k.Algorithm)
+}
+
+// Generate returns the code for counter, as defined by RFC 4226 section 5.3
+func (k *Key) Generate(counter uint64) (string, error) {
+	if err := k.validate(); err != nil {
+		return "", err
+	}
+	newHash, _ := k.hash()
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(newHash, k.Secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < k.Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", k.Digits, value%mod), nil
+}
+
+// At returns the TOTP code for t and how long it stays valid
+func (k *Key) At(t time.Time) (string, time.Duration, error) {
+	if k.Kind != TOTP {
+		return "", 0, fmt.Errorf("%w: %s keys are not time based", ErrInvalidKey, k.Kind)
+	}
+	if k.Period <= 0 {
+		return "", 0, fmt.Errorf("%w: period must be positive", ErrInvalidKey)
+	}
+
+	step := uint64(k.Period / time.Second)
+	now := uint64(t.Unix())
+	code, err := k.Generate(now / step)
+	if err != nil {
+		return "", 0, err
+	}
+	remaining := time.Duration(step-now%step) * time.Second
+	return code, remaining, nil
+}
+
+// URI renders the key as an otpauth:// URI, used to store the next HOTP counter
+func (k *Key) URI() string {
+	label := k.Account
+	if k.Issuer != "" {
+		label = k.Issuer + ":" + k.Account
+	}
+
+	q := url.Values{}
+	q.Set("secret", base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(k.Secret))
+	if k.Issuer != "" {
+		q.Set("issuer", k.Issuer)
+	}
+	q.Set("algorithm", string(k.Algorithm))
+	q.Set("digits", strconv.Itoa(k.Digits))
+	switch k.Kind {
+	case TOTP:
+		q.Set("period", strconv.Itoa(int(k.Period/time.Second)))
+	case HOTP:
+		q.Set("counter", strconv.FormatUint(k.Counter, 10))
+	}
+
+	u := url.URL{Scheme: "otpauth", Host: string(k.Kind), Path: "/" + label, RawQuery: q.Encode()}
+	return u.String()
+}
@@ -0,0 +1,112 @@
+package otp
+
+import (
+	"encoding/base32"
+	"errors"
+	"testing"
+	"time"
+)
+
+// RFC 4226 appendix D
+func TestHOTPVectors(t *testing.T) {
+	key := &Key{Kind: HOTP, Secret: []byte("12345678901234567890"), Algorithm: SHA1, Digits: 6}
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, code := range want {
+		got, err := key.Generate(uint64(counter))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != code {
+			t.Errorf("counter %d: got %s, want %s", counter, got, code)
+		}
+	}
+}
+
+// RFC 6238 appendix B
+func TestTOTPVectors(t *testing.T) {
+	secrets := map[Algorithm]string{
+		SHA1:   "12345678901234567890",
+		SHA256: "12345678901234567890123456789012",
+		SHA512: "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+	tests := []struct {
+		unix int64
+		want map[Algorithm]string
+	}{
+		{59, map[Algorithm]string{SHA1: "94287082", SHA256: "46119246", SHA512: "90693936"}},
+		{1111111109, map[Algorithm]string{SHA1: "07081804", SHA256: "68084774", SHA512: "25091201"}},
+		{1234567890, map[Algorithm]string{SHA1: "89005924", SHA256: "91819424", SHA512: "93441116"}},
+		{20000000000, map[Algorithm]string{SHA1: "65353130", SHA256: "77737706", SHA512: "47863826"}},
+	}
+	for _, tt := range tests {
+		for alg, want := range tt.want {
+			key := &Key{Kind: TOTP, Secret: []byte(secrets[alg]), Algorithm: alg, Digits: 8, Period: 30 * time.Second}
+			got, _, err := key.At(time.Unix(tt.unix, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("%s at %d: got %s, want %s", alg, tt.unix, got, want)
+			}
+		}
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	key, err := Parse("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, remaining, _ := key.At(time.Unix(65, 0)); remaining != 25*time.Second {
+		t.Fatalf("remaining = %v, want 25s", remaining)
+	}
+}
+
+func TestParseURI(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	key, err := Parse("otpauth://totp/ACME%20Co:ada@example.com?secret=" + secret + "&issuer=ACME%20Co&algorithm=SHA256&digits=8&period=60")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Kind != TOTP || key.Issuer != "ACME Co" || key.Account != "ada@example.com" ||
+		key.Algorithm != SHA256 || key.Digits != 8 || key.Period != time.Minute || string(key.Secret) != "12345678901234567890" {
+		t.Fatalf("parsed %+v", key)
+	}
+
+	again, err := Parse(key.URI())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.URI() != key.URI() {
+		t.Fatalf("URI does not round trip: %s != %s", again.URI(), key.URI())
+	}
+}
+
+func TestParseHOTP(t *testing.T) {
+	key, err := Parse("otpauth://hotp/ada?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&counter=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := key.Generate(key.Counter); code != "969429" {
+		t.Fatalf("got %s, want 969429", code)
+	}
+	if _, _, err := key.At(time.Now()); err == nil {
+		t.Fatal("At accepted an HOTP key")
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"not base32!",
+		"otpauth://totp/ada",
+		"otpauth://totp/ada?secret=JBSWY3DPEHPK3PXP&digits=9",
+		"otpauth://totp/ada?secret=JBSWY3DPEHPK3PXP&algorithm=MD5",
+		"otpauth://hotp/ada?secret=JBSWY3DPEHPK3PXP",
+		"otpauth://push/ada?secret=JBSWY3DPEHPK3PXP",
+	} {
+		if _, err := Parse(s); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Parse(%q) err = %v, want ErrInvalidKey", s, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package schema
+
+import (
+	"Modsec/clientside/otp"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// FieldKind is the kind of a custom field, it decides how the value is validated and shown
+type FieldKind string
+
+const (
+	FieldText   FieldKind = "text"
+	FieldHidden FieldKind = "hidden"
+	FieldURL    FieldKind = "url"
+	FieldDate   FieldKind = "date"
+	FieldTOTP   FieldKind = "totp"
+)
+
+// AllFieldKinds is bound to the frontend so it gets a matching TypeScript enum
+var AllFieldKinds = []struct {
+	Value  FieldKind
+	TSName string
+}{
+	{FieldText, "TEXT"},
+	{FieldHidden, "HIDDEN"},
+	{FieldURL, "URL"},
+	{FieldDate, "DATE"},
+	{FieldTOTP, "TOTP"},
+}
+
+// DateLayout is the format of date custom fields
+const DateLayout = "2006-01-02"
+
+const (
+	maxCustomFields = 50
+	maxLabelLength  = 100
+)
+
+// CustomField is a user-defined field, kept in the order the user arranged them
+type CustomField struct {
+	Label string    `json:"label"`
+	Kind  FieldKind `json:"kind"`
+	Value string    `json:"value"`
+}
+
+// CustomFields is the ordered list of custom fields every item type carries
+type CustomFields []CustomField
+
+// validate checks every field of an item of type t
+func (fields CustomFields) validate(t Type) error {
+	if len(fields) > maxCustomFields {
+		return invalid(t, "has more than %d custom fields", maxCustomFields)
+	}
+	for i, f := range fields {
+		label := strings.TrimSpace(f.Label)
+		if label == "" {
+			return invalid(t, "custom field %d needs a label", i+1)
+		}
+		if len(label) > maxLabelLength {
+			return invalid(t, "custom field %q has a label longer than %d characters", label, maxLabelLength)
+		}
+		if f.Value == "" {
+			continue
+		}
+
+		switch f.Kind {
+		case FieldText, FieldHidden:
+		case FieldURL:
+			if u, err := url.Parse(f.Value); err != nil || u.Scheme == "" || u.Host == "" {
+				return invalid(t, "custom field %q is not a valid URL", label)
+			}
+		case FieldDate:
+			if _, err := time.Parse(DateLayout, f.Value); err != nil {
+				return invalid(t, "custom field %q must be a date like 2024-12-31", label)
+			}
+		case FieldTOTP:
+			if _, err := otp.Parse(f.Value); err != nil {
+				return invalid(t, "custom field %q is not a usable one-time password key: %v", label, err)
+			}
+		default:
+			return invalid(t, "custom field %q has unknown kind %q", label, f.Kind)
+		}
+	}
+	return nil
+}
+
+// CustomFieldsOf returns the custom fields of d so they can be read or changed in place
+func CustomFieldsOf(d Data) *CustomFields {
+	field := reflect.ValueOf(d).Elem().FieldByName("CustomFields")
+	if !field.IsValid() {
+		return nil
+	}
+	fields, _ := field.Addr().Interface().(*CustomFields)
+	return fields
+}
@@ -0,0 +1,49 @@
+package schema
+
+import "time"
+
+// MaxPasswordHistory is the most previous passwords an item keeps
+const MaxPasswordHistory = 100
+
+// PasswordEntry is a password an item used before and when it was replaced
+type PasswordEntry struct {
+	Password string    `json:"password"`
+	Replaced time.Time `json:"replaced"`
+}
+
+// PasswordHistory lists previous passwords, newest first
+type PasswordHistory []PasswordEntry
+
+// Record returns the history after current replaced old at time at, keeping at most limit entries.
+// An entry equal to the new password is dropped, so restoring an old password moves it out of the history.
+func (h PasswordHistory) Record(old, current string, at time.Time, limit int) PasswordHistory {
+	out := PasswordHistory{}
+	if old != "" && old != current {
+		out = append(out, PasswordEntry{Password: old, Replaced: at})
+	}
+	for _, e := range h {
+		if e.Password != current {
+			out = append(out, e)
+		}
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// validate checks the history of an item of type t
+func (h PasswordHistory) validate(t Type) error {
+	if len(h) > MaxPasswordHistory {
+		return invalid(t, "keeps more than %d previous passwords", MaxPasswordHistory)
+	}
+	for i, e := range h {
+		if e.Password == "" {
+			return invalid(t, "previous password %d is empty", i+1)
+		}
+	}
+	return nil
+}
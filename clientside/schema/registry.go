@@ -0,0 +1,151 @@
+package schema
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// TypeInfo describes an item type. Types register themselves from init, everything that converts
+// between backend and frontend names or needs an empty value of a type goes through the registry.
+type TypeInfo struct {
+	Type        Type     `json:"type"`
+	BackendName string   `json:"backendName"`
+	Aliases     []string `json:"aliases,omitempty"` // older backend names that are read as this type
+	Label       string   `json:"label"`
+	Description string   `json:"description"`
+	Sensitive   []string `json:"sensitive"` // fields hidden until the user reveals them
+	Display     []string `json:"display"`   // fields that summarise the item, in order
+
+	new func() Data
+}
+
+var registry = struct {
+	sync.RWMutex
+	byType    map[Type]*TypeInfo
+	byBackend map[string]*TypeInfo
+	order     []Type
+}{
+	byType:    make(map[Type]*TypeInfo),
+	byBackend: make(map[string]*TypeInfo),
+}
+
+// Register adds an item type. new must return an empty value whose Type() is info.Type, and
+// info.Type must be the JSON name of a member of Fields. It panics on duplicates or unknown field names.
+func Register(info TypeInfo, new func() Data) {
+	if new == nil || new().Type() != info.Type {
+		panic(fmt.Sprintf("schema: Register %s with a constructor of another type", info.Type))
+	}
+	if _, ok := fieldsMember(info.Type); !ok {
+		panic(fmt.Sprintf("schema: Register %s without a member in Fields", info.Type))
+	}
+	known := jsonNames(reflect.TypeOf(new()).Elem())
+	for _, name := range append(append([]string{}, info.Sensitive...), info.Display...) {
+		if !known[name] {
+			panic(fmt.Sprintf("schema: Register %s with unknown field %q", info.Type, name))
+		}
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, dup := registry.byType[info.Type]; dup {
+		panic(fmt.Sprintf("schema: Register called twice for %s", info.Type))
+	}
+	info.new = new
+	registry.byType[info.Type] = &info
+	for _, name := range append([]string{info.BackendName}, info.Aliases...) {
+		if _, dup := registry.byBackend[name]; dup {
+			panic(fmt.Sprintf("schema: backend name %q registered twice", name))
+		}
+		registry.byBackend[name] = &info
+	}
+	registry.order = append(registry.order, info.Type)
+}
+
+// Types returns every registered type in registration order
+func Types() []TypeInfo {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	types := make([]TypeInfo, 0, len(registry.order))
+	for _, t := range registry.order {
+		types = append(types, *registry.byType[t])
+	}
+	return types
+}
+
+// Lookup returns the registered type t
+func Lookup(t Type) (TypeInfo, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	info, ok := registry.byType[t]
+	if !ok {
+		return TypeInfo{}, false
+	}
+	return *info, true
+}
+
+// FrontendName maps a type name stored by the backend to its frontend type.
+// Unknown names are passed through so items of newer types still show up.
+func FrontendName(backendName string) Type {
+	registry.RLock()
+	info, ok := registry.byBackend[backendName]
+	registry.RUnlock()
+
+	if !ok {
+		log.Printf("Unknown type name: %s, using as-is", backendName)
+		return Type(backendName)
+	}
+	return info.Type
+}
+
+// BackendName maps a frontend type to the name the backend stores
+func BackendName(t Type) string {
+	info, ok := Lookup(t)
+	if !ok {
+		log.Printf("Unknown item type: %s, using as-is", t)
+		return string(t)
+	}
+	return info.BackendName
+}
+
+// New returns an empty Data for t
+func New(t Type) (Data, error) {
+	info, ok := Lookup(t)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, t)
+	}
+	return info.new(), nil
+}
+
+// fieldsMember returns the index of the Fields member holding type t
+func fieldsMember(t Type) (int, bool) {
+	ft := reflect.TypeOf(Fields{})
+	for i := 0; i < ft.NumField(); i++ {
+		if jsonName(ft.Field(i)) == string(t) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// jsonNames returns the JSON names of the fields of struct type st
+func jsonNames(st reflect.Type) map[string]bool {
+	names := make(map[string]bool, st.NumField())
+	for i := 0; i < st.NumField(); i++ {
+		names[jsonName(st.Field(i))] = true
+	}
+	return names
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
@@ -0,0 +1,192 @@
+package schema
+
+// Typed contents of vault items. Every item type has a struct that knows how to validate itself,
+// and the JSON that gets encrypted carries a version so older formats can be migrated on read.
+
+import (
+	"Modsec/clientside/vaulterr"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Version is written into the data of every item saved by this client.
+// Version 2 added custom fields, version 3 the website TOTP key and version 4 the website password history;
+// older clients would drop them when saving.
+const Version = 4
+
+// versionKey is the JSON key holding the version, items without it are version 0
+const versionKey = "schema_version"
+
+// Errors of the item schemas, ErrInvalidItem and ErrUnknownType are defined in vaulterr
+var (
+	ErrInvalidItem = vaulterr.ErrInvalidItem
+	ErrUnknownType = vaulterr.ErrUnknownType
+	// ErrUnsupportedVersion is returned for data written by a newer client
+	ErrUnsupportedVersion = errors.New("item data was saved by a newer version of the app")
+)
+
+// Type is the frontend name of an item type
+type Type string
+
+const (
+	TypeWebsite  Type = "website"
+	TypeCard     Type = "card"
+	TypeIdentity Type = "identity"
+	TypeCrypto   Type = "crypto"
+	TypeMemo     Type = "memo"
+)
+
+// Data is the decrypted content of one item
+type Data interface {
+	Type() Type
+	Validate() error
+}
+
+// Fields carries item data to and from the frontend. Exactly one member is set, and each
+// member is named after the Type it holds.
+type Fields struct {
+	Website  *Website      `json:"website,omitempty"`
+	Card     *Card         `json:"card,omitempty"`
+	Identity *Identity     `json:"identity,omitempty"`
+	Crypto   *CryptoWallet `json:"crypto,omitempty"`
+	Memo     *Memo         `json:"memo,omitempty"`
+	SSHKey   *SSHKey       `json:"ssh_key,omitempty"`
+}
+
+// Data returns the member that is set
+func (f Fields) Data() (Data, error) {
+	var set []Data
+	v := reflect.ValueOf(f)
+	for i := 0; i < v.NumField(); i++ {
+		if member := v.Field(i); !member.IsNil() {
+			set = append(set, member.Interface().(Data))
+		}
+	}
+
+	if len(set) != 1 {
+		return nil, fmt.Errorf("%w: expected the fields of exactly one item type, got %d", ErrInvalidItem, len(set))
+	}
+	return set[0], nil
+}
+
+// FieldsOf wraps d for the frontend
+func FieldsOf(d Data) *Fields {
+	i, ok := fieldsMember(d.Type())
+	if !ok {
+		return nil
+	}
+	f := &Fields{}
+	reflect.ValueOf(f).Elem().Field(i).Set(reflect.ValueOf(d))
+	return f
+}
+
+// Encode validates d and returns the versioned JSON that gets encrypted
+func Encode(d Data) ([]byte, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode item: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("failed to encode item: %w", err)
+	}
+	fields[versionKey] = json.RawMessage(fmt.Sprint(Version))
+	return json.Marshal(fields)
+}
+
+// Decode reads decrypted item JSON of type t, migrating older versions
+func Decode(t Type, raw []byte) (Data, error) {
+	d, err := New(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode item: %w", err)
+	}
+
+	version := 0
+	if v, ok := fields[versionKey].(float64); ok {
+		version = int(v)
+	}
+	delete(fields, versionKey)
+
+	switch {
+	case version > Version:
+		return nil, fmt.Errorf("%w (version %d)", ErrUnsupportedVersion, version)
+	case version == 0:
+		fields = migrateV0(fields)
+	}
+
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode item: %w", err)
+	}
+	if err := json.Unmarshal(body, d); err != nil {
+		return nil, fmt.Errorf("failed to decode item: %w", err)
+	}
+	return d, nil
+}
+
+// legacyNames maps field names older frontends saved onto the current ones
+var legacyNames = map[string]string{
+	"cardholder": "cardholderName",
+	"number":     "cardNumber",
+	"expMonth":   "expirationMonth",
+	"expYear":    "expirationYear",
+}
+
+// migrateV0 renames legacy fields and turns every value into a string, which is all version 0 stored
+func migrateV0(fields map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		if value == nil {
+			continue
+		}
+		if current, ok := legacyNames[key]; ok {
+			if _, taken := fields[current]; taken {
+				continue
+			}
+			key = current
+		}
+		switch v := value.(type) {
+		case string:
+			out[key] = v
+		case float64, bool:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+	return out
+}
+
+// FromMap builds Data of type t from loosely typed fields, rejecting names the schema does not know
+func FromMap(t Type, m map[string]interface{}) (Data, error) {
+	d, err := New(t)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode item: %w", err)
+	}
+	dec := json.NewDecoder(strings.NewReader(string(body)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(d); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidItem, err)
+	}
+	return d, d.Validate()
+}
+
+// invalid builds a validation error for the given item type
+func invalid(t Type, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s %s", ErrInvalidItem, t, fmt.Sprintf(format, args...))
+}
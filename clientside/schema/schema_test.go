@@ -0,0 +1,185 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	card := &Card{CardholderName: "Ada Lovelace", CardNumber: "4111 1111 1111 1111", ExpirationMonth: "08", ExpirationYear: "2030", CVV: "123",
+		CustomFields: CustomFields{
+			{Label: "Support PIN", Kind: FieldHidden, Value: "4821"},
+			{Label: "Account number", Kind: FieldText, Value: "00123"},
+		}}
+
+	raw, err := Encode(card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields[versionKey] != float64(Version) {
+		t.Fatalf("encoded %s without version %d", raw, Version)
+	}
+
+	got, err := Decode(TypeCard, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, card) {
+		t.Fatalf("got %+v, want %+v", got, card)
+	}
+}
+
+func TestDecodeMigratesLegacyFields(t *testing.T) {
+	raw := []byte(`{"cardholder":"Ada","number":"4111111111111111","expMonth":8,"expYear":"30","title":"stray","isBookmarked":false}`)
+
+	got, err := Decode(TypeCard, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Card{CardholderName: "Ada", CardNumber: "4111111111111111", ExpirationMonth: "8", ExpirationYear: "30"}
+	if !reflect.DeepEqual(*got.(*Card), want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeRejectsNewerVersion(t *testing.T) {
+	if _, err := Decode(TypeMemo, []byte(`{"schema_version":99,"content":"x"}`)); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("err = %v, want ErrUnsupportedVersion", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  Data
+		valid bool
+	}{
+		{"website with url", &Website{URL: "example.com"}, true},
+		{"website without url", &Website{Username: "ada"}, false},
+		{"card", &Card{CardNumber: "4111-1111-1111-1111"}, true},
+		{"card without number", &Card{CardholderName: "Ada"}, false},
+		{"card failing luhn", &Card{CardNumber: "4111111111111112"}, false},
+		{"card bad month", &Card{CardNumber: "4111111111111111", ExpirationMonth: "13"}, false},
+		{"identity", &Identity{FirstName: "Ada", Email: "ada@example.com", Phone: "+44 (20) 7946-0000"}, true},
+		{"identity bad email", &Identity{FirstName: "Ada", Email: "ada"}, false},
+		{"crypto with address", &CryptoWallet{Address: "bc1q"}, true},
+		{"crypto empty", &CryptoWallet{WalletName: "cold"}, false},
+		{"memo", &Memo{Content: "hello"}, true},
+		{"empty memo", &Memo{}, false},
+	}
+	for _, tt := range tests {
+		err := tt.data.Validate()
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: Validate() = %v, want valid %v", tt.name, err, tt.valid)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidItem) {
+			t.Errorf("%s: error %v is not ErrInvalidItem", tt.name, err)
+		}
+	}
+}
+
+func TestFromMapRejectsUnknownFields(t *testing.T) {
+	if _, err := FromMap(TypeWebsite, map[string]interface{}{"url": "example.com", "user": "ada"}); !errors.Is(err, ErrInvalidItem) {
+		t.Fatalf("err = %v, want ErrInvalidItem", err)
+	}
+	if _, err := FromMap(TypeWebsite, map[string]interface{}{"url": "example.com", "username": "ada"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFieldsNeedExactlyOneType(t *testing.T) {
+	if _, err := (Fields{}).Data(); !errors.Is(err, ErrInvalidItem) {
+		t.Fatalf("empty fields: err = %v", err)
+	}
+	if _, err := (Fields{Memo: &Memo{}, Website: &Website{}}).Data(); !errors.Is(err, ErrInvalidItem) {
+		t.Fatalf("two types: err = %v", err)
+	}
+}
+
+func TestRegistryRoundTrip(t *testing.T) {
+	types := Types()
+	if len(types) != 6 {
+		t.Fatalf("got %d registered types, want 6", len(types))
+	}
+	for _, info := range types {
+		if got := FrontendName(BackendName(info.Type)); got != info.Type {
+			t.Errorf("%s: round trip gave %s", info.Type, got)
+		}
+		d, err := New(info.Type)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f := FieldsOf(d); f == nil {
+			t.Errorf("%s: no Fields member", info.Type)
+		} else if got, err := f.Data(); err != nil || got != d {
+			t.Errorf("%s: Fields.Data() = %v, %v", info.Type, got, err)
+		}
+	}
+	if got := FrontendName("card"); got != TypeCard {
+		t.Errorf("alias card mapped to %s", got)
+	}
+}
+
+func TestRegisterRejectsUnknownFields(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Register accepted a display field the schema does not have")
+		}
+	}()
+	Register(TypeInfo{Type: TypeMemo, BackendName: "memo2", Display: []string{"body"}}, func() Data { return &Memo{} })
+}
+
+func TestValidateCustomFields(t *testing.T) {
+	tests := []struct {
+		field CustomField
+		valid bool
+	}{
+		{CustomField{Label: "PIN", Kind: FieldHidden, Value: "1234"}, true},
+		{CustomField{Label: "", Kind: FieldText, Value: "x"}, false},
+		{CustomField{Label: "Portal", Kind: FieldURL, Value: "https://bank.example.com"}, true},
+		{CustomField{Label: "Portal", Kind: FieldURL, Value: "bank"}, false},
+		{CustomField{Label: "Renewal", Kind: FieldDate, Value: "2025-02-28"}, true},
+		{CustomField{Label: "Renewal", Kind: FieldDate, Value: "28/02/2025"}, false},
+		{CustomField{Label: "2FA", Kind: FieldTOTP, Value: "JBSW Y3DP EHPK 3PXP"}, true},
+		{CustomField{Label: "2FA", Kind: FieldTOTP, Value: "otpauth://totp/Example:ada?secret=JBSWY3DPEHPK3PXP"}, true},
+		{CustomField{Label: "2FA", Kind: FieldTOTP, Value: "not base32!"}, false},
+		{CustomField{Label: "Other", Kind: "color", Value: "red"}, false},
+	}
+	for _, tt := range tests {
+		memo := &Memo{Content: "x", CustomFields: CustomFields{tt.field}}
+		if err := memo.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%+v: Validate() = %v, want valid %v", tt.field, err, tt.valid)
+		}
+	}
+}
+
+func TestPasswordHistoryRecord(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var h PasswordHistory
+
+	h = h.Record("one", "two", t0, 3)
+	h = h.Record("two", "two", t0.Add(time.Hour), 3) // Unchanged, nothing recorded
+	h = h.Record("two", "three", t0.Add(2*time.Hour), 3)
+	h = h.Record("three", "four", t0.Add(3*time.Hour), 3)
+	h = h.Record("four", "five", t0.Add(4*time.Hour), 3)
+	if len(h) != 3 || h[0].Password != "four" || h[2].Password != "two" || !h[0].Replaced.Equal(t0.Add(4*time.Hour)) {
+		t.Fatalf("history = %+v", h)
+	}
+
+	// Restoring an old password takes it out of the history and records the one it replaces
+	h = h.Record("five", "three", t0.Add(5*time.Hour), 3)
+	if len(h) != 3 || h[0].Password != "five" || h[1].Password != "four" || h[2].Password != "two" {
+		t.Fatalf("history after restore = %+v", h)
+	}
+
+	if h := h.Record("x", "y", t0, 0); len(h) != 0 {
+		t.Fatalf("limit 0 kept %+v", h)
+	}
+}
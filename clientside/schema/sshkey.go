@@ -0,0 +1,68 @@
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const TypeSSHKey Type = "ssh_key"
+
+func init() {
+	Register(TypeInfo{
+		Type:        TypeSSHKey,
+		BackendName: "ssh_key",
+		Label:       "SSH Key",
+		Description: "Keep SSH keys and use them through the built-in agent",
+		Sensitive:   []string{"privateKey"},
+		Display:     []string{"comment", "publicKey"},
+	}, func() Data { return &SSHKey{} })
+}
+
+// SSHKey is an SSH key pair, the private key in OpenSSH PEM form and the public key in authorized_keys form
+type SSHKey struct {
+	PrivateKey   string       `json:"privateKey"`
+	PublicKey    string       `json:"publicKey"`
+	Comment      string       `json:"comment"`
+	Notes        string       `json:"notes"`
+	CustomFields CustomFields `json:"customFields,omitempty"`
+}
+
+func (*SSHKey) Type() Type { return TypeSSHKey }
+
+// Validate requires a private key without a passphrase, and a public key that belongs to it when one is set
+func (k *SSHKey) Validate() error {
+	if err := k.CustomFields.validate(k.Type()); err != nil {
+		return err
+	}
+	if strings.TrimSpace(k.PrivateKey) == "" {
+		return invalid(TypeSSHKey, "needs a private key")
+	}
+
+	signer, err := k.Signer()
+	var missing *ssh.PassphraseMissingError
+	switch {
+	case errors.As(err, &missing):
+		return invalid(TypeSSHKey, "private key is protected by a passphrase, remove it before storing the key")
+	case err != nil:
+		return invalid(TypeSSHKey, "private key is not valid: %v", err)
+	}
+
+	if strings.TrimSpace(k.PublicKey) != "" {
+		public, _, _, _, err := ssh.ParseAuthorizedKey([]byte(k.PublicKey))
+		if err != nil {
+			return invalid(TypeSSHKey, "public key is not valid: %v", err)
+		}
+		if !bytes.Equal(public.Marshal(), signer.PublicKey().Marshal()) {
+			return invalid(TypeSSHKey, "public key does not belong to the private key")
+		}
+	}
+	return nil
+}
+
+// Signer parses the private key
+func (k *SSHKey) Signer() (ssh.Signer, error) {
+	return ssh.ParsePrivateKey([]byte(k.PrivateKey))
+}
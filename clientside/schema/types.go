@@ -0,0 +1,259 @@
+package schema
+
+import (
+	"Modsec/clientside/otp"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Website is a login for a site
+type Website struct {
+	Username        string          `json:"username"`
+	Password        string          `json:"password"`
+	URL             string          `json:"url"`
+	TOTP            string          `json:"totp,omitempty"` // otpauth:// URI or base32 secret
+	Notes           string          `json:"notes"`
+	CustomFields    CustomFields    `json:"customFields,omitempty"`
+	PasswordHistory PasswordHistory `json:"passwordHistory,omitempty"`
+}
+
+func init() {
+	Register(TypeInfo{
+		Type:        TypeWebsite,
+		BackendName: "login",
+		Label:       "Website",
+		Description: "Store login credentials for websites",
+		Sensitive:   []string{"password", "totp", "passwordHistory"},
+		Display:     []string{"username", "url"},
+	}, func() Data { return &Website{} })
+}
+
+func (*Website) Type() Type { return TypeWebsite }
+
+// Validate requires a URL with a host, the scheme may be left out, and a usable one-time password key when one is set
+func (w *Website) Validate() error {
+	if err := w.CustomFields.validate(w.Type()); err != nil {
+		return err
+	}
+	if err := w.PasswordHistory.validate(w.Type()); err != nil {
+		return err
+	}
+	if w.TOTP != "" {
+		if _, err := otp.Parse(w.TOTP); err != nil {
+			return invalid(TypeWebsite, "one-time password key is not valid: %v", err)
+		}
+	}
+	raw := strings.TrimSpace(w.URL)
+	if raw == "" {
+		return invalid(TypeWebsite, "needs a URL")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	if u, err := url.Parse(raw); err != nil || u.Host == "" {
+		return invalid(TypeWebsite, "URL %q is not valid", w.URL)
+	}
+	return nil
+}
+
+// Card is a payment card
+type Card struct {
+	CardholderName  string       `json:"cardholderName"`
+	CardNumber      string       `json:"cardNumber"`
+	ExpirationMonth string       `json:"expirationMonth"`
+	ExpirationYear  string       `json:"expirationYear"`
+	CVV             string       `json:"cvv"`
+	Notes           string       `json:"notes"`
+	CustomFields    CustomFields `json:"customFields,omitempty"`
+}
+
+func init() {
+	Register(TypeInfo{
+		Type:        TypeCard,
+		BackendName: "credit",
+		Aliases:     []string{"card"},
+		Label:       "Payment Card",
+		Description: "Secure your payment card information",
+		Sensitive:   []string{"cardNumber", "cvv"},
+		Display:     []string{"cardholderName", "cardNumber"},
+	}, func() Data { return &Card{} })
+}
+
+func (*Card) Type() Type { return TypeCard }
+
+// Validate requires a card number that passes the Luhn check, the other fields are checked when set
+func (c *Card) Validate() error {
+	if err := c.CustomFields.validate(c.Type()); err != nil {
+		return err
+	}
+	number := strings.NewReplacer(" ", "", "-", "").Replace(c.CardNumber)
+	if number == "" {
+		return invalid(TypeCard, "needs a card number")
+	}
+	if !digits(number) || len(number) < 12 || len(number) > 19 || !luhn(number) {
+		return invalid(TypeCard, "number is not valid")
+	}
+
+	if c.ExpirationMonth != "" {
+		month, err := strconv.Atoi(c.ExpirationMonth)
+		if err != nil || month < 1 || month > 12 {
+			return invalid(TypeCard, "expiration month must be 1-12")
+		}
+	}
+	if c.ExpirationYear != "" && (!digits(c.ExpirationYear) || (len(c.ExpirationYear) != 2 && len(c.ExpirationYear) != 4)) {
+		return invalid(TypeCard, "expiration year must have 2 or 4 digits")
+	}
+	if c.CVV != "" && (!digits(c.CVV) || len(c.CVV) < 3 || len(c.CVV) > 4) {
+		return invalid(TypeCard, "CVV must have 3 or 4 digits")
+	}
+	return nil
+}
+
+// Identity is personal details
+type Identity struct {
+	FirstName    string       `json:"firstName"`
+	LastName     string       `json:"lastName"`
+	Email        string       `json:"email"`
+	Phone        string       `json:"phone"`
+	Address      string       `json:"address"`
+	Notes        string       `json:"notes"`
+	CustomFields CustomFields `json:"customFields,omitempty"`
+}
+
+func init() {
+	Register(TypeInfo{
+		Type:        TypeIdentity,
+		BackendName: "identity",
+		Label:       "Identity",
+		Description: "Save personal identification details",
+		Sensitive:   []string{},
+		Display:     []string{"firstName", "lastName", "email"},
+	}, func() Data { return &Identity{} })
+}
+
+func (*Identity) Type() Type { return TypeIdentity }
+
+// Validate requires a name, email and phone are checked when set
+func (i *Identity) Validate() error {
+	if err := i.CustomFields.validate(i.Type()); err != nil {
+		return err
+	}
+	if strings.TrimSpace(i.FirstName) == "" && strings.TrimSpace(i.LastName) == "" {
+		return invalid(TypeIdentity, "needs a first or last name")
+	}
+	if i.Email != "" {
+		if _, err := mail.ParseAddress(i.Email); err != nil {
+			return invalid(TypeIdentity, "email %q is not valid", i.Email)
+		}
+	}
+	if i.Phone != "" {
+		count := 0
+		for _, r := range i.Phone {
+			switch {
+			case r >= '0' && r <= '9':
+				count++
+			case strings.ContainsRune(" +-().", r):
+			default:
+				return invalid(TypeIdentity, "phone %q is not valid", i.Phone)
+			}
+		}
+		if count < 3 {
+			return invalid(TypeIdentity, "phone %q is not valid", i.Phone)
+		}
+	}
+	return nil
+}
+
+// CryptoWallet is a cryptocurrency wallet
+type CryptoWallet struct {
+	WalletName   string       `json:"walletName"`
+	Address      string       `json:"address"`
+	PrivateKey   string       `json:"privateKey"`
+	Notes        string       `json:"notes"`
+	CustomFields CustomFields `json:"customFields,omitempty"`
+}
+
+func init() {
+	Register(TypeInfo{
+		Type:        TypeCrypto,
+		BackendName: "cryptowallet",
+		Label:       "Crypto Wallet",
+		Description: "Store cryptocurrency wallet details",
+		Sensitive:   []string{"privateKey"},
+		Display:     []string{"walletName", "address"},
+	}, func() Data { return &CryptoWallet{} })
+}
+
+func (*CryptoWallet) Type() Type { return TypeCrypto }
+
+// Validate requires an address or a private key
+func (c *CryptoWallet) Validate() error {
+	if err := c.CustomFields.validate(c.Type()); err != nil {
+		return err
+	}
+	if strings.TrimSpace(c.Address) == "" && strings.TrimSpace(c.PrivateKey) == "" {
+		return invalid(TypeCrypto, "needs an address or a private key")
+	}
+	return nil
+}
+
+// Memo is a free text note
+type Memo struct {
+	Content      string       `json:"content"`
+	Notes        string       `json:"notes"`
+	CustomFields CustomFields `json:"customFields,omitempty"`
+}
+
+func init() {
+	Register(TypeInfo{
+		Type:        TypeMemo,
+		BackendName: "note",
+		Label:       "Secure Note",
+		Description: "Keep sensitive notes and information",
+		Sensitive:   []string{},
+		Display:     []string{"content"},
+	}, func() Data { return &Memo{} })
+}
+
+func (*Memo) Type() Type { return TypeMemo }
+
+// Validate requires some content
+func (m *Memo) Validate() error {
+	if err := m.CustomFields.validate(m.Type()); err != nil {
+		return err
+	}
+	if strings.TrimSpace(m.Content) == "" {
+		return invalid(TypeMemo, "needs content")
+	}
+	return nil
+}
+
+// digits reports whether s is made of ASCII digits only
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// luhn checks the card number checksum
+func luhn(number string) bool {
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
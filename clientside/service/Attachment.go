@@ -0,0 +1,319 @@
+package service
+
+import (
+	"Modsec/clientside/CipherAlgo/envelope"
+	"Modsec/clientside/CipherAlgo/keymaster"
+	"Modsec/clientside/CipherAlgo/utils"
+	"Modsec/clientside/attachment"
+	"Modsec/clientside/client"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// ErrAttachmentNotFound is returned when an item has no attachment with the requested ID
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+// Attachment is a file attached to an item, as shown to the user
+type Attachment struct {
+	AttachmentID uint      `json:"attachmentId"`
+	ItemID       uint      `json:"itemId"`
+	Name         string    `json:"name"`
+	Size         int64     `json:"size"` // Plaintext size in bytes
+	DateCreate   time.Time `json:"dateCreate"`
+}
+
+// AttachmentRecord is an attachment as the backend stores it. The name is encrypted with the vault key and
+// the file key is wrapped by it, the file itself is a separate encrypted stream.
+type AttachmentRecord struct {
+	AttachmentID uint      `json:"attachment_id"`
+	ItemID       uint      `json:"item_id"`
+	Name         string    `json:"name"`
+	Size         int64     `json:"size"`
+	Key          string    `json:"key"`
+	DateCreate   time.Time `json:"date_create"`
+}
+
+// CreateAttachmentPayload creates an empty record, the name and key follow in an UpdateAttachmentPayload
+// once they can be bound to the new ID
+type CreateAttachmentPayload struct {
+	ItemID uint  `json:"item_id"`
+	Size   int64 `json:"size"`
+}
+
+type UpdateAttachmentPayload struct {
+	AttachmentID uint   `json:"attachment_id"`
+	Name         string `json:"name"`
+	Key          string `json:"key"`
+}
+
+type CreateAttachmentResponse struct {
+	AttachmentID uint   `json:"attachment_id"`
+	Message      string `json:"message"`
+}
+
+type GetAttachmentsResponse struct {
+	Attachments []AttachmentRecord `json:"attachments"`
+}
+
+type DeleteAttachmentPayload struct {
+	AttachmentID uint `json:"attachment_id"`
+}
+
+// requireSavedItem stops attachment calls for items the server does not know yet
+func requireSavedItem(itemID uint) error {
+	if err := requireUnlocked(); err != nil {
+		return err
+	}
+	if err := requireOnline(); err != nil {
+		return err
+	}
+	if isTempID(itemID) {
+		return fmt.Errorf("%w: item %d has not reached the server yet", client.ErrOffline, itemID)
+	}
+	return nil
+}
+
+// UploadAttachment encrypts the file at path with a new key and attaches it to an item. The file is read
+// and sent in chunks, so it never sits in memory as a whole.
+func UploadAttachment(ctx context.Context, itemID uint, path string) (*Attachment, error) {
+	if err := requireSavedItem(itemID); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("%s is not a regular file", info.Name())
+	}
+	if info.Size() > attachment.MaxSize {
+		return nil, fmt.Errorf("%w: %s is over %d MB", attachment.ErrTooLarge, info.Name(), attachment.MaxSize>>20)
+	}
+	size := info.Size()
+
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return nil, err
+	}
+	defer keymaster.Wipe(vaultKey)
+
+	fileKey, err := attachment.NewKey()
+	if err != nil {
+		return nil, err
+	}
+	defer keymaster.Wipe(fileKey)
+
+	created := &CreateAttachmentResponse{}
+	err = client.Backend.Do(ctx, http.MethodPost, "/createAttachment", &CreateAttachmentPayload{ItemID: itemID, Size: size}, created)
+	if err != nil {
+		log.Printf("CreateAttachment communication failed: %v", err)
+		return nil, err
+	}
+	// Do not leave an entry without a key or file behind
+	discard := func() {
+		if delErr := DeleteAttachment(context.Background(), created.AttachmentID); delErr != nil {
+			log.Printf("Failed to remove incomplete attachment %d: %v", created.AttachmentID, delErr)
+		}
+	}
+
+	wrappedKey, err := envelope.Seal(fileKey, vaultKey, attachmentBinding(itemID, created.AttachmentID, fieldAttachmentKey))
+	if err != nil {
+		discard()
+		return nil, fmt.Errorf("failed to wrap attachment key: %w", err)
+	}
+	encryptedName, err := envelope.Seal([]byte(info.Name()), vaultKey, attachmentBinding(itemID, created.AttachmentID, fieldAttachmentName))
+	if err != nil {
+		discard()
+		return nil, fmt.Errorf("failed to encrypt name: %v", err)
+	}
+	update := &UpdateAttachmentPayload{
+		AttachmentID: created.AttachmentID,
+		Name:         utils.BytToBa64(encryptedName),
+		Key:          utils.BytToBa64(wrappedKey),
+	}
+	if err := client.Backend.Do(ctx, http.MethodPost, "/updateAttachment", update, nil); err != nil {
+		log.Printf("UpdateAttachment communication failed: %v", err)
+		discard()
+		return nil, err
+	}
+
+	// Encrypt while sending, the pipe hands each sealed chunk straight to the request body
+	reader, writer := io.Pipe()
+	go func() {
+		_, err := attachment.Encrypt(writer, io.LimitReader(file, size), fileKey)
+		writer.CloseWithError(err)
+	}()
+
+	uploadPath := "/uploadAttachment?attachment_id=" + strconv.FormatUint(uint64(created.AttachmentID), 10)
+	err = client.Backend.Upload(ctx, uploadPath, reader, attachment.EncryptedSize(size), nil)
+	reader.Close()
+	if err != nil {
+		log.Printf("UploadAttachment failed for item %d: %v", itemID, err)
+		discard()
+		return nil, err
+	}
+
+	log.Printf("UploadAttachment result: item %d, attachment %d, %d bytes", itemID, created.AttachmentID, size)
+	return &Attachment{
+		AttachmentID: created.AttachmentID,
+		ItemID:       itemID,
+		Name:         info.Name(),
+		Size:         size,
+		DateCreate:   time.Now(),
+	}, nil
+}
+
+// fetchAttachments downloads the attachment records of an item
+func fetchAttachments(ctx context.Context, itemID uint) ([]AttachmentRecord, error) {
+	response := &GetAttachmentsResponse{}
+	path := "/getAttachments?item_id=" + strconv.FormatUint(uint64(itemID), 10)
+	if err := client.Backend.Do(ctx, http.MethodGet, path, nil, response); err != nil {
+		log.Printf("GetAttachments communication failed: %v", err)
+		return nil, err
+	}
+	return response.Attachments, nil
+}
+
+// GetAttachments lists the attachments of an item with their names decrypted
+func GetAttachments(ctx context.Context, itemID uint) ([]Attachment, error) {
+	if err := requireUnlocked(); err != nil {
+		return nil, err
+	}
+	// Nothing can be attached before the item exists on the server
+	if isTempID(itemID) || IsOffline() {
+		return []Attachment{}, nil
+	}
+
+	records, err := fetchAttachments(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return nil, err
+	}
+	defer keymaster.Wipe(vaultKey)
+
+	result := make([]Attachment, 0, len(records))
+	for _, record := range records {
+		name := fmt.Sprintf("[Attachment %d]", record.AttachmentID)
+		if raw, err := utils.Ba64ToByt(record.Name); err == nil {
+			if decrypted, err := openAttachmentField(raw, vaultKey, record.ItemID, record.AttachmentID, fieldAttachmentName); err == nil {
+				name = string(decrypted)
+			} else {
+				log.Printf("Error decrypting name of attachment %d: %v", record.AttachmentID, err)
+			}
+		}
+		result = append(result, Attachment{
+			AttachmentID: record.AttachmentID,
+			ItemID:       record.ItemID,
+			Name:         name,
+			Size:         record.Size,
+			DateCreate:   record.DateCreate,
+		})
+	}
+	return result, nil
+}
+
+// DownloadAttachment decrypts an attachment of an item into the file at dest. The plaintext goes to a
+// temporary file next to dest that only replaces dest once every chunk has been verified.
+func DownloadAttachment(ctx context.Context, itemID, attachmentID uint, dest string) error {
+	if err := requireSavedItem(itemID); err != nil {
+		return err
+	}
+
+	records, err := fetchAttachments(ctx, itemID)
+	if err != nil {
+		return err
+	}
+	var record *AttachmentRecord
+	for i := range records {
+		if records[i].AttachmentID == attachmentID {
+			record = &records[i]
+			break
+		}
+	}
+	if record == nil {
+		return fmt.Errorf("%w: item %d has no attachment %d", ErrAttachmentNotFound, itemID, attachmentID)
+	}
+
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return err
+	}
+	wrappedKey, err := utils.Ba64ToByt(record.Key)
+	if err != nil {
+		keymaster.Wipe(vaultKey)
+		return fmt.Errorf("%w: bad key encoding", attachment.ErrCorrupt)
+	}
+	fileKey, err := openAttachmentField(wrappedKey, vaultKey, itemID, attachmentID, fieldAttachmentKey)
+	keymaster.Wipe(vaultKey)
+	if err != nil || len(fileKey) != attachment.KeySize {
+		keymaster.Wipe(fileKey)
+		return fmt.Errorf("%w: cannot unwrap key", attachment.ErrCorrupt)
+	}
+	defer keymaster.Wipe(fileKey)
+
+	body, err := client.Backend.Download(ctx, "/downloadAttachment?attachment_id="+strconv.FormatUint(uint64(attachmentID), 10))
+	if err != nil {
+		log.Printf("DownloadAttachment communication failed: %v", err)
+		return err
+	}
+	defer body.Close()
+
+	// CreateTemp makes the file readable by the user only, which suits keys and certificates
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".modsec-download-*")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := attachment.Decrypt(tmp, body, fileKey)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Printf("DownloadAttachment failed for attachment %d: %v", attachmentID, err)
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return fmt.Errorf("failed to save file: %w", err)
+	}
+
+	log.Printf("DownloadAttachment result: attachment %d, %d bytes", attachmentID, n)
+	return nil
+}
+
+// DeleteAttachment removes an attachment and its file from the server
+func DeleteAttachment(ctx context.Context, attachmentID uint) error {
+	if err := requireUnlocked(); err != nil {
+		return err
+	}
+	if err := requireOnline(); err != nil {
+		return err
+	}
+
+	payload := &DeleteAttachmentPayload{AttachmentID: attachmentID}
+	if err := client.Backend.Do(ctx, http.MethodPost, "/deleteAttachment", payload, nil); err != nil {
+		log.Printf("DeleteAttachment communication failed: %v", err)
+		return err
+	}
+	log.Printf("DeleteAttachment result: attachment %d", attachmentID)
+	return nil
+}
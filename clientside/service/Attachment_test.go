@@ -0,0 +1,152 @@
+package service
+
+import (
+	"Modsec/clientside/CipherAlgo/envelope"
+	"Modsec/clientside/CipherAlgo/keymaster"
+	"Modsec/clientside/CipherAlgo/utils"
+	"Modsec/clientside/attachment"
+	"Modsec/clientside/client"
+	"Modsec/clientside/config"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// attachmentServer stands in for the backend attachment endpoints and keeps what it is sent
+func attachmentServer(t *testing.T) (*[]AttachmentRecord, map[uint][]byte) {
+	t.Helper()
+	var records []AttachmentRecord
+	files := map[uint][]byte{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/createAttachment", func(w http.ResponseWriter, r *http.Request) {
+		var payload CreateAttachmentPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		id := uint(len(records) + 1)
+		records = append(records, AttachmentRecord{AttachmentID: id, ItemID: payload.ItemID, Size: payload.Size})
+		json.NewEncoder(w).Encode(CreateAttachmentResponse{AttachmentID: id})
+	})
+	mux.HandleFunc("/updateAttachment", func(w http.ResponseWriter, r *http.Request) {
+		var payload UpdateAttachmentPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		for i := range records {
+			if records[i].AttachmentID == payload.AttachmentID {
+				records[i].Name, records[i].Key = payload.Name, payload.Key
+			}
+		}
+	})
+	mux.HandleFunc("/uploadAttachment", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.URL.Query().Get("attachment_id"))
+		files[uint(id)], _ = io.ReadAll(r.Body)
+	})
+	mux.HandleFunc("/getAttachments", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(GetAttachmentsResponse{Attachments: records})
+	})
+	mux.HandleFunc("/downloadAttachment", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.URL.Query().Get("attachment_id"))
+		w.Write(files[uint(id)])
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := config.Default()
+	cfg.BackendURL = srv.URL
+	if err := client.InitClient(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.InitClient(config.Default()) })
+	return &records, files
+}
+
+func TestAttachmentRoundTrip(t *testing.T) {
+	testVault(t, 1)
+	_, files := attachmentServer(t)
+	dir := t.TempDir()
+
+	plain := make([]byte, 200*1024+5)
+	rand.Read(plain)
+	src := filepath.Join(dir, "vpn.conf")
+	if err := os.WriteFile(src, plain, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	uploaded, err := UploadAttachment(ctx, 1, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(files[uploaded.AttachmentID], plain[:64]) {
+		t.Fatal("server received plaintext")
+	}
+
+	list, err := GetAttachments(ctx, 1)
+	if err != nil || len(list) != 1 || list[0].Name != "vpn.conf" || list[0].Size != int64(len(plain)) {
+		t.Fatalf("GetAttachments = %+v, %v", list, err)
+	}
+
+	dest := filepath.Join(dir, "copy.conf")
+	if err := DownloadAttachment(ctx, 1, uploaded.AttachmentID, dest); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(dest); !bytes.Equal(got, plain) {
+		t.Fatal("downloaded file differs")
+	}
+
+	// A tampered file is refused and leaves nothing behind
+	files[uploaded.AttachmentID][100] ^= 1
+	bad := filepath.Join(dir, "bad.conf")
+	if err := DownloadAttachment(ctx, 1, uploaded.AttachmentID, bad); err == nil {
+		t.Fatal("tampered download succeeded")
+	}
+	if _, err := os.Stat(bad); !os.IsNotExist(err) {
+		t.Fatalf("tampered download left %s", bad)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Fatalf("download left temporary files: %v", entries)
+	}
+}
+
+func TestAttachmentBinding(t *testing.T) {
+	testVault(t, 1)
+	records, _ := attachmentServer(t)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		src := filepath.Join(dir, name)
+		os.WriteFile(src, []byte(name), 0o600)
+		if _, err := UploadAttachment(ctx, 1, src); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A record sealed for one attachment does not open as another one of the same item
+	list := *records
+	list[0].Name, list[1].Name = list[1].Name, list[0].Name
+	list[0].Key, list[1].Key = list[1].Key, list[0].Key
+	got, err := GetAttachments(ctx, 1)
+	if err != nil || got[0].Name != "[Attachment 1]" || got[1].Name != "[Attachment 2]" {
+		t.Fatalf("swapped records listed as %+v, %v", got, err)
+	}
+	if err := DownloadAttachment(ctx, 1, 1, filepath.Join(dir, "swapped")); !errors.Is(err, attachment.ErrCorrupt) {
+		t.Fatalf("swapped key: %v", err)
+	}
+
+	// Records bound only to their item, as written before attachment IDs were bound, still open
+	vaultKey, _ := keymaster.Keys.Vaultkey()
+	defer keymaster.Wipe(vaultKey)
+	name, _ := envelope.Seal([]byte("old.txt"), vaultKey, itemAttachmentBinding(1, fieldAttachmentName))
+	list[0].Name = utils.BytToBa64(name)
+	if got, err := GetAttachments(ctx, 1); err != nil || got[0].Name != "old.txt" {
+		t.Fatalf("item bound record listed as %+v, %v", got, err)
+	}
+}
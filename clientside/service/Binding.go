@@ -0,0 +1,539 @@
+package service
+
+// Item titles and data, category names and attachment records are sealed with an envelope.Binding, so the
+// server cannot move a ciphertext onto another item or field. IDs come from the server, so content that
+// is sealed before its ID is known (items and categories created offline) is bound to ID 0 until it is
+// rebound. Items and categories reach the server bound to their real ID: they are created as an empty
+// placeholder and their content follows in an update.
+
+import (
+	"Modsec/clientside/CipherAlgo/envelope"
+	"Modsec/clientside/CipherAlgo/keymaster"
+	"Modsec/clientside/CipherAlgo/utils"
+	"Modsec/clientside/cache"
+	"Modsec/clientside/client"
+	"Modsec/clientside/schema"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// Fields named in bindings
+const (
+	fieldTitle          = "title"
+	fieldData           = "data"
+	fieldName           = "name"
+	fieldAttachmentName = "attachment.name"
+	fieldAttachmentKey  = "attachment.key"
+)
+
+// sealState says how a ciphertext that opened was bound
+type sealState int
+
+const (
+	sealBound   sealState = iota // Bound to its own ID
+	sealPending                  // Bound to ID 0, sealed before the server assigned an ID
+	sealLegacy                   // Written before bindings
+)
+
+// itemBinding binds field of item id. Backend type names are normalised so older aliases still match.
+func itemBinding(id uint, typeName string, field string) envelope.Binding {
+	return envelope.Binding{Kind: "item", ID: id, Type: string(schema.FrontendName(typeName)), Field: field}
+}
+
+// categoryBinding binds the name of category id
+func categoryBinding(id uint) envelope.Binding {
+	return envelope.Binding{Kind: "category", ID: id, Field: fieldName}
+}
+
+// attachmentBinding binds field of attachment id to the item it belongs to
+func attachmentBinding(itemID, id uint, field string) envelope.Binding {
+	return envelope.Binding{Kind: "attachment", ID: id, Parent: itemID, Field: field}
+}
+
+// itemAttachmentBinding is how attachment records were bound before they were bound to their own ID
+func itemAttachmentBinding(itemID uint, field string) envelope.Binding {
+	return envelope.Binding{Kind: "item", ID: itemID, Field: field}
+}
+
+// openAttachmentField opens field of an attachment record. Records are kept as they were written, so
+// ones bound only to their item, to ID 0 or not at all are still read.
+func openAttachmentField(ciphertext, vaultKey []byte, itemID, id uint, field string) ([]byte, error) {
+	plain, _, err := openArchivedField(ciphertext, vaultKey, attachmentBinding(itemID, id, field))
+	if err == nil {
+		return plain, nil
+	}
+	if plain, _, itemErr := openArchivedField(ciphertext, vaultKey, itemAttachmentBinding(itemID, field)); itemErr == nil {
+		return plain, nil
+	}
+	return nil, err
+}
+
+// tempBinding binds to ID 0 while id is a temporary ID from the journal
+func tempBinding(b envelope.Binding) envelope.Binding {
+	if isTempID(b.ID) {
+		b.ID = 0
+	}
+	return b
+}
+
+// strictState caches whether unbound ciphertext is refused for the logged-in user
+var strictState struct {
+	mu     sync.Mutex
+	email  string
+	strict bool
+}
+
+// strictBinding reports whether the vault was fully migrated, after which legacy ciphertext is refused. The
+// wrapped vault key says so for every device; the cache file only for the one that ran the migration, until
+// the backend stores the bound wrap.
+func strictBinding() bool {
+	if keymaster.Keys.Bound() {
+		return true
+	}
+	email := keymaster.Keys.Email()
+
+	strictState.mu.Lock()
+	defer strictState.mu.Unlock()
+	if strictState.email != email {
+		strictState.email = email
+		strictState.strict = email != "" && cache.IsBound(email)
+	}
+	return strictState.strict
+}
+
+// setStrictBinding records that every item of the logged-in user is bound
+func setStrictBinding() error {
+	email := keymaster.Keys.Email()
+	if err := cache.SetBound(email); err != nil {
+		return err
+	}
+	strictState.mu.Lock()
+	strictState.email = email
+	strictState.strict = true
+	strictState.mu.Unlock()
+	log.Printf("Every item is bound, unbound ciphertext is refused from now on")
+	return nil
+}
+
+// openField opens a ciphertext sealed for b. Ciphertext bound to ID 0 instead of b.ID, or without a
+// binding, is accepted only until the vault has been migrated. Journaled content of temporary IDs is
+// sealed for ID 0 itself, see tempBinding.
+func openField(ciphertext, vaultKey []byte, b envelope.Binding) ([]byte, sealState, error) {
+	strict := strictBinding()
+	return openFieldAs(ciphertext, vaultKey, b, !strict, !strict)
+}
+
+// openForRebind is openField for MigrateBindings and the key rotation, which reseal what they open bound to
+// its own ID. Content sealed before its ID was known is accepted even after the vault was migrated.
+func openForRebind(ciphertext, vaultKey []byte, b envelope.Binding) ([]byte, sealState, error) {
+	return openFieldAs(ciphertext, vaultKey, b, true, !strictBinding())
+}
+
+// openFieldAs opens a ciphertext sealed for b, also accepting ID 0 and unbound ciphertext as asked
+func openFieldAs(ciphertext, vaultKey []byte, b envelope.Binding, pending, legacy bool) ([]byte, sealState, error) {
+	plain, err := envelope.Open(ciphertext, vaultKey, b)
+	if err == nil {
+		return plain, sealBound, nil
+	}
+	if pending && b.ID != 0 && !errors.Is(err, envelope.ErrLegacy) {
+		unassigned := b
+		unassigned.ID = 0
+		if plain, pendingErr := envelope.Open(ciphertext, vaultKey, unassigned); pendingErr == nil {
+			return plain, sealPending, nil
+		}
+	}
+	if !legacy {
+		return nil, 0, err
+	}
+	// Not only on ErrLegacy, see the envelope package
+	plain, legacyErr := utils.DecryptAES256GCM(ciphertext, vaultKey)
+	if legacyErr != nil {
+		return nil, 0, err
+	}
+	return plain, sealLegacy, nil
+}
+
+// openBase64Field is openField for ciphertext sent as base64
+func openBase64Field(encoded string, vaultKey []byte, b envelope.Binding) ([]byte, sealState, error) {
+	return openBase64With(openField, encoded, vaultKey, b)
+}
+
+// openBase64With opens ciphertext sent as base64 with open
+func openBase64With(open fieldOpener, encoded string, vaultKey []byte, b envelope.Binding) ([]byte, sealState, error) {
+	ciphertext, err := utils.Ba64ToByt(encoded)
+	if err != nil {
+		return nil, 0, err
+	}
+	return open(ciphertext, vaultKey, b)
+}
+
+// isSealedField reports whether a title or name from the server is encrypted. Envelopes say so in their
+// header; for ciphertext from before envelopes only the base64 encoding is left to go by.
+func isSealedField(encoded string) bool {
+	if encoded == "" {
+		return false
+	}
+	if raw, err := utils.Ba64ToByt(encoded); err == nil && envelope.IsSealed(raw) {
+		return true
+	}
+	return isBase64(encoded)
+}
+
+// fieldOpener opens a ciphertext sealed for a binding, openField or openArchivedField
+type fieldOpener func(ciphertext, vaultKey []byte, b envelope.Binding) ([]byte, sealState, error)
+
+// openArchivedField is openField for records the backend keeps as they were written, item versions and
+// attachments. The migration cannot rewrite them, so the ones from before bindings or from before their ID
+// was known stay readable.
+func openArchivedField(ciphertext, vaultKey []byte, b envelope.Binding) ([]byte, sealState, error) {
+	plain, state, err := openFieldAs(ciphertext, vaultKey, b, true, !strictBinding())
+	if err == nil || !envelope.IsLegacy(ciphertext) {
+		return plain, state, err
+	}
+	if plain, legacyErr := utils.DecryptAES256GCM(ciphertext, vaultKey); legacyErr == nil {
+		return plain, sealLegacy, nil
+	}
+	return nil, 0, err
+}
+
+// sealItem seals the title and data of an item for id, which may be 0 or a temporary ID
+func sealItem(vaultKey []byte, id uint, typeName string, title, data []byte) (string, []byte, error) {
+	sealedTitle, err := envelope.Seal(title, vaultKey, tempBinding(itemBinding(id, typeName, fieldTitle)))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encrypt Title: %v", err)
+	}
+	sealedData, err := envelope.Seal(data, vaultKey, tempBinding(itemBinding(id, typeName, fieldData)))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encrypt Itemdata: %v", err)
+	}
+	return utils.BytToBa64(sealedTitle), sealedData, nil
+}
+
+// openItemPayload opens the title and data of a journaled item write sealed for id
+func openItemPayload(vaultKey []byte, id uint, typeName string, title string, data []byte) ([]byte, []byte, error) {
+	plainTitle, _, err := openBase64Field(title, vaultKey, tempBinding(itemBinding(id, typeName, fieldTitle)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt Title: %w", err)
+	}
+	plainData, _, err := openField(data, vaultKey, tempBinding(itemBinding(id, typeName, fieldData)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt Itemdata: %w", err)
+	}
+	return plainTitle, plainData, nil
+}
+
+// rebindItemPayload reseals a journaled update written for item from so it is bound to payload.Item_id
+func rebindItemPayload(payload *UpdateItemPayload, from uint, typeName string) error {
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return err
+	}
+	defer keymaster.Wipe(vaultKey)
+
+	title, data, err := openItemPayload(vaultKey, from, typeName, payload.Title, payload.Data)
+	if err != nil {
+		return err
+	}
+	payload.Title, payload.Data, err = sealItem(vaultKey, payload.Item_id, typeName, title, data)
+	return err
+}
+
+// createBound creates an item on the server as an empty placeholder, then saves its content bound to the
+// ID the server assigned. The content is never stored on the server under an unassigned binding.
+// If the second step fails, the returned update payload is what still has to be sent.
+func createBound(ctx context.Context, typeName string, title, data []byte, categoryID *uint) (*CreateItemResponse, *UpdateItemPayload, error) {
+	response, update, err := createPlaceholder(ctx, typeName, title, data, categoryID)
+	if err != nil {
+		return response, nil, err
+	}
+	if err := client.Backend.Do(ctx, http.MethodPost, "/updateItem", update, &UpdateItemResponse{}); err != nil {
+		return response, update, err
+	}
+	return response, nil, nil
+}
+
+// createPlaceholder is the first step of createBound. It creates the empty placeholder and returns the update
+// that saves the content bound to the ID the server assigned.
+func createPlaceholder(ctx context.Context, typeName string, title, data []byte, categoryID *uint) (*CreateItemResponse, *UpdateItemPayload, error) {
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer keymaster.Wipe(vaultKey)
+
+	placeholderTitle, placeholderData, err := sealItem(vaultKey, 0, typeName, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	response := &CreateItemResponse{}
+	placeholder := &CreateItemPayload{Title: placeholderTitle, Type: typeName, Data: placeholderData}
+	if err := client.Backend.Do(ctx, http.MethodPost, "/createItem", placeholder, response); err != nil {
+		return nil, nil, err
+	}
+
+	update := &UpdateItemPayload{Item_id: response.ItemID, Category_id: categoryID}
+	update.Title, update.Data, err = sealItem(vaultKey, response.ItemID, typeName, title, data)
+	if err != nil {
+		return response, nil, err
+	}
+	return response, update, nil
+}
+
+// createCategoryBound creates a category on the server with an empty placeholder name, then saves its name
+// bound to the ID the server assigned. If the second step fails, the returned update payload is what still
+// has to be sent.
+func createCategoryBound(ctx context.Context, name []byte) (*CreateCategoryResponse, *UpdateCategoryPayload, error) {
+	response, update, err := createCategoryPlaceholder(ctx, name)
+	if err != nil {
+		return response, nil, err
+	}
+	if err := client.Backend.Do(ctx, http.MethodPost, "/updateCategory", update, &UpdateCategoryResponse{}); err != nil {
+		return response, update, err
+	}
+	return response, nil, nil
+}
+
+// createCategoryPlaceholder is the first step of createCategoryBound. It creates the category with an empty
+// placeholder name and returns the update that saves the name bound to the ID the server assigned.
+func createCategoryPlaceholder(ctx context.Context, name []byte) (*CreateCategoryResponse, *UpdateCategoryPayload, error) {
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer keymaster.Wipe(vaultKey)
+
+	placeholder, err := envelope.Seal(nil, vaultKey, categoryBinding(0))
+	if err != nil {
+		return nil, nil, err
+	}
+	response := &CreateCategoryResponse{}
+	if err := client.Backend.Do(ctx, http.MethodPost, "/createCategory", &CreateCategoryPayload{Category: utils.BytToBa64(placeholder)}, response); err != nil {
+		return nil, nil, err
+	}
+	if response.CategoryID == 0 {
+		return nil, nil, ErrNoCategoryID
+	}
+
+	sealed, err := envelope.Seal(name, vaultKey, categoryBinding(response.CategoryID))
+	if err != nil {
+		return response, nil, err
+	}
+	return response, &UpdateCategoryPayload{Category_id: response.CategoryID, CategoryName: utils.BytToBa64(sealed)}, nil
+}
+
+// rebindCategoryName reseals a journaled category name written for category from so it is bound to to
+func rebindCategoryName(encoded string, from, to uint) (string, error) {
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return "", err
+	}
+	defer keymaster.Wipe(vaultKey)
+
+	name, _, err := openBase64Field(encoded, vaultKey, tempBinding(categoryBinding(from)))
+	if err != nil {
+		return "", err
+	}
+	sealed, err := envelope.Seal(name, vaultKey, tempBinding(categoryBinding(to)))
+	if err != nil {
+		return "", err
+	}
+	return utils.BytToBa64(sealed), nil
+}
+
+// bindingMigration remembers for which user the migration already ran this session
+var bindingMigration struct {
+	mu    sync.Mutex
+	email string
+}
+
+// resetBindingMigration lets the migration run again, for the next login
+func resetBindingMigration() {
+	bindingMigration.mu.Lock()
+	bindingMigration.email = ""
+	bindingMigration.mu.Unlock()
+}
+
+// MigrateBindings reseals every item title and data and every category name that is not bound to its own
+// ID yet. Once nothing unbound is left, unbound ciphertext is refused for this user. It runs once per
+// session and returns the number of items and categories rewritten. Each rewrite is conditional on the
+// record being unchanged on the server, so an edit saved meanwhile is never overwritten.
+func MigrateBindings(ctx context.Context) (int, error) {
+	if err := requireUnlocked(); err != nil {
+		return 0, err
+	}
+	if IsOffline() {
+		return 0, nil
+	}
+
+	bindingMigration.mu.Lock()
+	defer bindingMigration.mu.Unlock()
+	email := keymaster.Keys.Email()
+	if bindingMigration.email == email {
+		return 0, nil
+	}
+
+	if !store.isLoaded() {
+		if _, err := SyncVault(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return 0, err
+	}
+	defer keymaster.Wipe(vaultKey)
+
+	raw := store.rawResponse()
+	migrated, failed := 0, 0
+
+	for _, item := range raw.Items {
+		if isTempID(item.ItemID) {
+			continue
+		}
+		title, titleState, err := openBase64With(openForRebind, item.Title, vaultKey, itemBinding(item.ItemID, item.TypeName, fieldTitle))
+		if err != nil {
+			log.Printf("Cannot migrate item %d, title does not open: %v", item.ItemID, err)
+			failed++
+			continue
+		}
+		var data []byte
+		dataState := sealBound
+		if item.Data != "" {
+			data, dataState, err = openBase64With(openForRebind, item.Data, vaultKey, itemBinding(item.ItemID, item.TypeName, fieldData))
+			if err != nil {
+				log.Printf("Cannot migrate item %d, data does not open: %v", item.ItemID, err)
+				failed++
+				continue
+			}
+		}
+		if titleState == sealBound && dataState == sealBound {
+			continue
+		}
+
+		modified := item.DateModify
+		payload := &UpdateItemPayload{Item_id: item.ItemID, Category_id: item.CategoryID, BaseModify: &modified, Rebind: true}
+		if payload.Title, payload.Data, err = sealItem(vaultKey, item.ItemID, item.TypeName, title, data); err != nil {
+			return migrated, err
+		}
+		if err := client.Backend.Do(ctx, http.MethodPost, "/updateItem", payload, &UpdateItemResponse{}); err != nil {
+			logMigrationError("item", item.ItemID, err)
+			failed++
+			continue
+		}
+		migrated++
+	}
+
+	for _, category := range raw.Categorys {
+		if !isSealedField(category.CategoryName) || isTempID(category.CategoryID) {
+			continue
+		}
+		name, state, err := openBase64With(openForRebind, category.CategoryName, vaultKey, categoryBinding(category.CategoryID))
+		if err != nil {
+			log.Printf("Cannot migrate category %d: %v", category.CategoryID, err)
+			failed++
+			continue
+		}
+		if state == sealBound {
+			continue
+		}
+		sealed, err := envelope.Seal(name, vaultKey, categoryBinding(category.CategoryID))
+		if err != nil {
+			return migrated, err
+		}
+		payload := &UpdateCategoryPayload{Category_id: category.CategoryID, CategoryName: utils.BytToBa64(sealed),
+			BaseName: category.CategoryName}
+		if err := client.Backend.Do(ctx, http.MethodPost, "/updateCategory", payload, &UpdateCategoryResponse{}); err != nil {
+			logMigrationError("category", category.CategoryID, err)
+			failed++
+			continue
+		}
+		migrated++
+	}
+
+	bindingMigration.email = email
+	if failed == 0 && !strictBinding() {
+		if err := setStrictBinding(); err != nil {
+			log.Printf("Failed to record binding migration: %v", err)
+		}
+	}
+	if failed == 0 && !keymaster.Keys.Bound() {
+		if err := publishBindingMigration(ctx); err != nil {
+			log.Printf("Failed to store binding migration: %v", err)
+		}
+	}
+	log.Printf("Binding migration: %d rewritten, %d failed", migrated, failed)
+
+	// Pick up the rewritten ciphertext so the store holds what the server now has
+	if migrated > 0 {
+		if _, err := SyncVault(ctx); err != nil {
+			return migrated, err
+		}
+	}
+	return migrated, nil
+}
+
+// UpdateProtectedKeyPayload replaces the vault key wrapped by the master key
+type UpdateProtectedKeyPayload struct {
+	ProtectedVaultKey     string `json:"protected_vault_key"`
+	BaseProtectedVaultKey string `json:"base_protected_vault_key"` // Refused with 409 if the stored key is no longer this
+}
+
+// publishBindingMigration rewraps the vault key as bound and stores it on the server, so every device and a
+// fresh install refuse unbound ciphertext too. A backend without /updateProtectedVaultKey leaves it to the
+// cache file of this device.
+func publishBindingMigration(ctx context.Context) error {
+	masterKey, err := keymaster.Keys.Masterkey()
+	if err != nil {
+		return err
+	}
+	defer keymaster.Wipe(masterKey)
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return err
+	}
+	defer keymaster.Wipe(vaultKey)
+	base, err := keymaster.Keys.ProtectedVaultkey()
+	if err != nil {
+		return err
+	}
+
+	protected, err := keymaster.Protect(vaultKey, masterKey, true)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt vault key: %v", err)
+	}
+	payload := &UpdateProtectedKeyPayload{
+		ProtectedVaultKey:     utils.BytToBa64(protected),
+		BaseProtectedVaultKey: utils.BytToBa64(base),
+	}
+	err = client.Backend.Do(ctx, http.MethodPost, "/updateProtectedVaultKey", payload, nil)
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound && apiErr.Code == "" {
+		log.Printf("Backend cannot store the binding migration, only this device refuses unbound ciphertext")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	keymaster.Keys.Rekey(masterKey, vaultKey, protected)
+	if err := cache.SaveProtectedKey(keymaster.Keys.Email(), protected); err != nil {
+		log.Printf("Failed to cache the bound vault key: %v", err)
+	}
+	log.Printf("Binding migration stored with the vault key")
+	return nil
+}
+
+// logMigrationError logs a rewrite that failed. A record changed meanwhile is left for the next session.
+func logMigrationError(kind string, id uint, err error) {
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+		log.Printf("Not migrating %s %d, it changed on the server, trying again next session", kind, id)
+		return
+	}
+	log.Printf("Failed to migrate %s %d: %v", kind, id, err)
+}
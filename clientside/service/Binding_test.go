@@ -0,0 +1,305 @@
+package service
+
+import (
+	"Modsec/clientside/CipherAlgo/keymaster"
+	"Modsec/clientside/CipherAlgo/utils"
+	"Modsec/clientside/client"
+	"Modsec/clientside/config"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// bindingVault unlocks a test vault whose cache lives in a temporary directory
+func bindingVault(t *testing.T) []byte {
+	t.Helper()
+	t.Setenv(config.EnvConfigFile, filepath.Join(t.TempDir(), "config.json"))
+	testVault(t, 0)
+
+	resetStrict := func() {
+		strictState.mu.Lock()
+		strictState.email = ""
+		strictState.mu.Unlock()
+	}
+	resetStrict()
+	t.Cleanup(resetStrict)
+
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { keymaster.Wipe(vaultKey) })
+	return vaultKey
+}
+
+func TestOpenField(t *testing.T) {
+	vaultKey := bindingVault(t)
+
+	bound, _, err := sealItem(vaultKey, 7, "login", []byte("Mail"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending, _, _ := sealItem(vaultKey, 0, "login", []byte("Bank"), nil)
+	legacy, _ := utils.EncryptAES256GCM([]byte("Forum"), vaultKey)
+
+	for _, tc := range []struct {
+		name       string
+		ciphertext string
+		want       string
+		state      sealState
+	}{
+		{"bound", bound, "Mail", sealBound},
+		{"pending", pending, "Bank", sealPending},
+		{"legacy", utils.BytToBa64(legacy), "Forum", sealLegacy},
+	} {
+		plain, state, err := openBase64Field(tc.ciphertext, vaultKey, itemBinding(7, "login", fieldTitle))
+		if err != nil || string(plain) != tc.want || state != tc.state {
+			t.Errorf("%s: got %q, %d, %v", tc.name, plain, state, err)
+		}
+	}
+
+	// A title moved onto another item or field does not open
+	if _, _, err := openBase64Field(bound, vaultKey, itemBinding(8, "login", fieldTitle)); err == nil {
+		t.Error("title opened for another item")
+	}
+	if _, _, err := openBase64Field(bound, vaultKey, itemBinding(7, "login", fieldData)); err == nil {
+		t.Error("title opened as data")
+	}
+
+	// Once the vault is migrated, unbound ciphertext and ciphertext bound to ID 0 are refused
+	if err := setStrictBinding(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := openBase64Field(utils.BytToBa64(legacy), vaultKey, itemBinding(7, "login", fieldTitle)); err == nil {
+		t.Error("legacy title opened in strict mode")
+	}
+	if _, _, err := openBase64Field(pending, vaultKey, itemBinding(7, "login", fieldTitle)); err == nil {
+		t.Error("ID 0 title opened in strict mode")
+	}
+	if _, state, err := openBase64With(openForRebind, pending, vaultKey, itemBinding(7, "login", fieldTitle)); err != nil || state != sealPending {
+		t.Errorf("ID 0 title not opened for rebinding: %d, %v", state, err)
+	}
+	if _, _, err := openBase64Field(bound, vaultKey, itemBinding(7, "login", fieldTitle)); err != nil {
+		t.Errorf("bound title refused in strict mode: %v", err)
+	}
+}
+
+func TestCreateBound(t *testing.T) {
+	vaultKey := bindingVault(t)
+
+	var created CreateItemPayload
+	var updated UpdateItemPayload
+	mux := http.NewServeMux()
+	mux.HandleFunc("/createItem", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&created)
+		json.NewEncoder(w).Encode(CreateItemResponse{ItemID: 42})
+	})
+	mux.HandleFunc("/updateItem", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&updated)
+		json.NewEncoder(w).Encode(UpdateItemResponse{})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	cfg := config.Default()
+	cfg.BackendURL = srv.URL
+	if err := client.InitClient(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.InitClient(config.Default()) })
+
+	response, pending, err := createBound(context.Background(), "login", []byte("Mail"), []byte(`{"username":"me"}`), nil)
+	if err != nil || pending != nil || response.ItemID != 42 {
+		t.Fatalf("createBound = %+v, %+v, %v", response, pending, err)
+	}
+
+	// The placeholder carries no content, the update carries it bound to the assigned ID
+	title, data, err := openItemPayload(vaultKey, 0, "login", created.Title, created.Data)
+	if err != nil || len(title) != 0 || len(data) != 0 {
+		t.Fatalf("placeholder = %q, %q, %v", title, data, err)
+	}
+	if updated.Item_id != 42 {
+		t.Fatalf("update sent for item %d", updated.Item_id)
+	}
+	title, state, err := openBase64Field(updated.Title, vaultKey, itemBinding(42, "login", fieldTitle))
+	if err != nil || string(title) != "Mail" || state != sealBound {
+		t.Fatalf("title = %q, %d, %v", title, state, err)
+	}
+}
+
+func TestMigrateBindingsIsConditional(t *testing.T) {
+	vaultKey := bindingVault(t)
+
+	modified := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var items []Item
+	for id := uint(1); id <= 2; id++ {
+		title, _ := utils.EncryptAES256GCM([]byte("Legacy"), vaultKey)
+		items = append(items, Item{ItemID: id, Title: utils.BytToBa64(title), TypeName: "login", DateModify: modified})
+	}
+	var rewrites []UpdateItemPayload
+	mux := http.NewServeMux()
+	mux.HandleFunc("/syncItems", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(GetListItemResponse{Items: items, Revision: 1, Full: true})
+	})
+	mux.HandleFunc("/updateItem", func(w http.ResponseWriter, r *http.Request) {
+		var payload UpdateItemPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		// Item 1 was edited by the user while the migration ran
+		if payload.Item_id == 1 {
+			http.Error(w, `{"error":"modified"}`, http.StatusConflict)
+			return
+		}
+		rewrites = append(rewrites, payload)
+		json.NewEncoder(w).Encode(UpdateItemResponse{ItemID: payload.Item_id})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	cfg := config.Default()
+	cfg.BackendURL = srv.URL
+	if err := client.InitClient(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.InitClient(config.Default()) })
+	ClearStore()
+	t.Cleanup(ClearStore)
+
+	migrated, err := MigrateBindings(context.Background())
+	if err != nil || migrated != 1 {
+		t.Fatalf("MigrateBindings = %d, %v", migrated, err)
+	}
+	if len(rewrites) != 1 || !rewrites[0].Rebind || rewrites[0].BaseModify == nil || !rewrites[0].BaseModify.Equal(modified) {
+		t.Fatalf("rewrite = %+v", rewrites)
+	}
+	// The refused item is left for the next session, so unbound ciphertext is still accepted
+	if strictBinding() {
+		t.Fatal("strict mode turned on although an item was not migrated")
+	}
+}
+
+func TestCreateCategoryBound(t *testing.T) {
+	vaultKey := bindingVault(t)
+
+	var created CreateCategoryPayload
+	var updated UpdateCategoryPayload
+	mux := http.NewServeMux()
+	mux.HandleFunc("/createCategory", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&created)
+		json.NewEncoder(w).Encode(CreateCategoryResponse{CategoryID: 9})
+	})
+	mux.HandleFunc("/updateCategory", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&updated)
+		json.NewEncoder(w).Encode(UpdateCategoryResponse{CategoryID: 9})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	cfg := config.Default()
+	cfg.BackendURL = srv.URL
+	if err := client.InitClient(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.InitClient(config.Default()) })
+
+	response, err := CreateCategoryClient("Work")
+	if err != nil || response.CategoryID != 9 {
+		t.Fatalf("CreateCategoryClient = %+v, %v", response, err)
+	}
+	if name, _, err := openBase64Field(created.Category, vaultKey, categoryBinding(0)); err != nil || len(name) != 0 {
+		t.Fatalf("placeholder = %q, %v", name, err)
+	}
+	name, state, err := openBase64Field(updated.CategoryName, vaultKey, categoryBinding(9))
+	if err != nil || state != sealBound || string(name) != "Work" {
+		t.Fatalf("name = %q, %d, %v", name, state, err)
+	}
+}
+
+// migrationVault unlocks a vault with a real wrapped key, bound or not, and serves one legacy item
+func migrationVault(t *testing.T, bound bool) (vaultKey, protected []byte, rewrites *[]UpdateItemPayload, keys *[]UpdateProtectedKeyPayload) {
+	t.Helper()
+	vaultKey = bindingVault(t)
+	resetBindingMigration()
+	t.Cleanup(resetBindingMigration)
+
+	masterKey, _ := utils.GenerateRandomBytes(32)
+	protected, err := keymaster.Protect(vaultKey, masterKey, bound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keymaster.Keys.Store("bench@example.com", masterKey, vaultKey, protected)
+
+	title, _ := utils.EncryptAES256GCM([]byte("Legacy"), vaultKey)
+	items := []Item{{ItemID: 1, Title: utils.BytToBa64(title), TypeName: "login", DateModify: time.Now()}}
+	rewrites, keys = &[]UpdateItemPayload{}, &[]UpdateProtectedKeyPayload{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/syncItems", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(GetListItemResponse{Items: items, Revision: 1, Full: true})
+	})
+	mux.HandleFunc("/updateItem", func(w http.ResponseWriter, r *http.Request) {
+		var payload UpdateItemPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		*rewrites = append(*rewrites, payload)
+		json.NewEncoder(w).Encode(UpdateItemResponse{ItemID: payload.Item_id})
+	})
+	mux.HandleFunc("/updateProtectedVaultKey", func(w http.ResponseWriter, r *http.Request) {
+		var payload UpdateProtectedKeyPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		*keys = append(*keys, payload)
+		w.Write([]byte(`{}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	cfg := config.Default()
+	cfg.BackendURL = srv.URL
+	if err := client.InitClient(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.InitClient(config.Default()) })
+	ClearStore()
+	t.Cleanup(ClearStore)
+	return vaultKey, protected, rewrites, keys
+}
+
+func TestMigrateBindingsStoresBoundKey(t *testing.T) {
+	vaultKey, protected, rewrites, keys := migrationVault(t, false)
+
+	migrated, err := MigrateBindings(context.Background())
+	if err != nil || migrated != 1 || len(*rewrites) != 1 {
+		t.Fatalf("MigrateBindings = %d, %v, rewrites %d", migrated, err, len(*rewrites))
+	}
+	if len(*keys) != 1 || (*keys)[0].BaseProtectedVaultKey != utils.BytToBa64(protected) {
+		t.Fatalf("bound key upload = %+v", *keys)
+	}
+	if !keymaster.Keys.Bound() {
+		t.Fatal("the session did not switch to the bound vault key")
+	}
+	stored, _ := utils.Ba64ToByt((*keys)[0].ProtectedVaultKey)
+	masterKey, _ := keymaster.Keys.Masterkey()
+	defer keymaster.Wipe(masterKey)
+	opened, err := keymaster.Unprotect(stored, masterKey)
+	if err != nil || !bytes.Equal(opened, vaultKey) {
+		t.Fatalf("bound vault key does not open: %v", err)
+	}
+}
+
+func TestBoundVaultKeyRefusesLegacy(t *testing.T) {
+	// A new device: the cache file knows nothing, the wrapped vault key says the vault was migrated
+	vaultKey, _, rewrites, keys := migrationVault(t, true)
+	if !strictBinding() {
+		t.Fatal("strict mode is off although the vault key is bound")
+	}
+	legacy, _ := utils.EncryptAES256GCM([]byte("Swapped"), vaultKey)
+	if _, _, err := openForRebind(legacy, vaultKey, itemBinding(1, "login", fieldTitle)); err == nil {
+		t.Fatal("openForRebind accepted unbound ciphertext of a migrated vault")
+	}
+
+	migrated, err := MigrateBindings(context.Background())
+	if err != nil || migrated != 0 || len(*rewrites) != 0 {
+		t.Fatalf("MigrateBindings = %d, %v, rewrote %+v", migrated, err, *rewrites)
+	}
+	if len(*keys) != 0 {
+		t.Fatalf("vault key uploaded again: %+v", *keys)
+	}
+}
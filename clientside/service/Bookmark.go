@@ -2,9 +2,7 @@ package service
 
 import (
 	"Modsec/clientside/client"
-	"bytes"
-	"encoding/json"
-	"fmt"
+	"context"
 	"log"
 	"net/http"
 )
@@ -19,45 +17,14 @@ type BookmarkResponse struct {
 	Status  string `json:"status"`
 }
 
-// SendLoginToBackend sends login data to the backend server
-func SendBookmarkToBackend(payload *BookmarkPayload, backendURL string) (*BookmarkResponse, error) {
-	// Convert payload to JSON
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode JSON: %v", err)
-	}
-
-	// Send data to backend server
-	req, err := http.NewRequest("POST", backendURL, bytes.NewBuffer(jsonData)) // Change DELETE to POST
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Access-Control-Allow-Credentials", "true")
-
-	resp, err := client.HMClient.Do(req)
-	if err != nil {
+func BookmarkClient(item_id uint, bookmark bool) (*BookmarkResponse, error) {
+	if err := requireUnlocked(); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("bookmark update failed with status: %d", resp.StatusCode) // Update error message
-	}
-
-	// Parse response
-	var result BookmarkResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+	if err := requireOnline(); err != nil {
+		return nil, err
 	}
 
-	return &result, nil
-}
-
-func BookmarkClient(item_id uint, bookmark bool) (*BookmarkResponse, error) {
 	// Create a item payload
 	payload := &BookmarkPayload{
 		Item_Id:  item_id,
@@ -65,8 +32,8 @@ func BookmarkClient(item_id uint, bookmark bool) (*BookmarkResponse, error) {
 	}
 
 	// Send to backend server
-	backendURL := "http://localhost:8080/bookmark" // Change as needed
-	response, err := SendBookmarkToBackend(payload, backendURL)
+	response := &BookmarkResponse{}
+	err := client.Backend.Do(context.Background(), http.MethodPost, "/bookmark", payload, response)
 	if err != nil {
 		log.Printf("Bookmark communication failed: %v", err)
 		return nil, err
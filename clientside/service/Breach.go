@@ -0,0 +1,65 @@
+package service
+
+import (
+	"Modsec/clientside/breach"
+	"context"
+	"sort"
+)
+
+// BreachedPassword is a website password that appears in known data breaches
+type BreachedPassword struct {
+	Item  HealthItem `json:"item"`
+	Count int        `json:"count"` // Times the password was seen in breaches
+}
+
+// GetBreachedPasswords checks the password of every website item against the breach range endpoint.
+// Each distinct password is looked up once.
+func GetBreachedPasswords(ctx context.Context) ([]BreachedPassword, error) {
+	if err := requireUnlocked(); err != nil {
+		return nil, err
+	}
+	if !breach.Enabled() {
+		return nil, breach.ErrDisabled
+	}
+	if !store.isLoaded() {
+		if _, err := SyncVault(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	items, err := ProcessGetListItem(store.rawResponse())
+	if err != nil {
+		return nil, err
+	}
+	return findBreached(ctx, *items, breach.Count)
+}
+
+// findBreached looks up the website passwords of items with count
+func findBreached(ctx context.Context, items []AfterItem, count func(context.Context, string) (int, error)) ([]BreachedPassword, error) {
+	seen := map[string]int{}
+	result := []BreachedPassword{}
+	for _, item := range items {
+		if item.Data == nil || item.Data.Website == nil || item.Data.Website.Password == "" {
+			continue
+		}
+		password := item.Data.Website.Password
+
+		n, ok := seen[password]
+		if !ok {
+			var err error
+			if n, err = count(ctx, password); err != nil {
+				return nil, err
+			}
+			seen[password] = n
+		}
+		if n > 0 {
+			result = append(result, BreachedPassword{
+				Item:  HealthItem{ItemID: item.ItemID, Title: item.Title, TypeName: item.TypeName},
+				Count: n,
+			})
+		}
+	}
+
+	sort.SliceStable(result, func(a, b int) bool { return result[a].Count > result[b].Count })
+	return result, nil
+}
@@ -2,9 +2,7 @@ package service
 
 import (
 	"Modsec/clientside/client"
-	"bytes"
-	"encoding/json"
-	"fmt"
+	"context"
 	"log"
 	"net/http"
 )
@@ -18,53 +16,22 @@ type CopyCountResponse struct {
 	Status  string `json:"status"`
 }
 
-// SendLoginToBackend sends login data to the backend server
-func SendCopyCountToBackend(payload *CopyCountPayload, backendURL string) (*CopyCountResponse, error) {
-	// Convert payload to JSON
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode JSON: %v", err)
-	}
-
-	// Send data to backend server
-	req, err := http.NewRequest("POST", backendURL, bytes.NewBuffer(jsonData)) // Change DELETE to POST
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Access-Control-Allow-Credentials", "true")
-
-	resp, err := client.HMClient.Do(req)
-	if err != nil {
+func CopyCountClient(item_id uint) (*CopyCountResponse, error) {
+	if err := requireUnlocked(); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("CopyCount update failed with status: %d", resp.StatusCode) // Update error message
-	}
-
-	// Parse response
-	var result CopyCountResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+	if err := requireOnline(); err != nil {
+		return nil, err
 	}
 
-	return &result, nil
-}
-
-func CopyCountClient(item_id uint) (*CopyCountResponse, error) {
 	// Create a item payload
 	payload := &CopyCountPayload{
 		Item_Id: item_id,
 	}
 
 	// Send to backend server
-	backendURL := "http://localhost:8080/copyCount" // Change as needed
-	response, err := SendCopyCountToBackend(payload, backendURL)
+	response := &CopyCountResponse{}
+	err := client.Backend.Do(context.Background(), http.MethodPost, "/copyCount", payload, response)
 	if err != nil {
 		log.Printf("CopyCount communication failed: %v", err)
 		return nil, err
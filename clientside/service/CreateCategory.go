@@ -1,14 +1,14 @@
 package service
 
 import (
+	"Modsec/clientside/CipherAlgo/envelope"
 	"Modsec/clientside/CipherAlgo/keymaster"
 	"Modsec/clientside/CipherAlgo/utils"
 	"Modsec/clientside/client"
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"net/http"
 )
 
 type CreateCategoryPayload struct {
@@ -16,13 +16,23 @@ type CreateCategoryPayload struct {
 }
 
 type CreateCategoryResponse struct {
-	Category string `json:"Category"`
-	Status   string `json:"status"`
+	CategoryID uint   `json:"category_id"`
+	Category   string `json:"Category"`
+	Status     string `json:"status"`
 }
 
+// ErrNoCategoryID is returned when the backend does not say which ID it gave a new category
+var ErrNoCategoryID = errors.New("the server did not return the ID of the new category")
+
 func ProcessCreateCategory(categoryname string) (*CreateCategoryPayload, error) {
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return nil, err
+	}
+	defer keymaster.Wipe(vaultKey)
 
-	encryptedCategory, err := utils.EncryptAES256GCM([]byte(categoryname), keymaster.Vaultkey)
+	// Only used for categories created offline, the name is bound to ID 0 until the server assigns one
+	encryptedCategory, err := envelope.Seal([]byte(categoryname), vaultKey, categoryBinding(0))
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt categoryname: %v", err)
 	}
@@ -37,64 +47,40 @@ func ProcessCreateCategory(categoryname string) (*CreateCategoryPayload, error)
 	return payload, nil
 }
 
-// SendLoginToBackend sends login data to the backend server
-func SendCreateCategoryToBackend(payload *CreateCategoryPayload, backendURL string) (*CreateCategoryResponse, error) {
-	// Convert payload to JSON
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode JSON: %v", err)
-	}
-
-	// Send data to backend server
-	req, err := http.NewRequest("POST", backendURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
+func CreateCategoryClient(categoryname string) (*CreateCategoryResponse, error) {
+	// Keep the write for later while the server is unreachable
+	if IsOffline() {
+		return queueNewCategory(categoryname)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Access-Control-Allow-Credentials", "true")
-
-	//Delete after test
-	fmt.Println("Item data:", req)
-
-	resp, err := client.HMClient.Do(req)
-	if err != nil {
+	// Send to backend server, the name follows bound to the new ID
+	response, pending, err := createCategoryBound(context.Background(), []byte(categoryname))
+	switch {
+	case pending != nil && errors.Is(err, client.ErrNetwork):
+		log.Printf("CreateCategory could not name category %d, queueing: %v", response.CategoryID, err)
+		if _, err := queueUpdateCategory(pending); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, client.ErrNetwork):
+		log.Printf("CreateCategory could not reach the server, queueing: %v", err)
+		return queueNewCategory(categoryname)
+	case err != nil:
+		log.Printf("CreateCategory communication failed: %v", err)
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("CreateItem failed with status: %d", resp.StatusCode)
-	}
-
-	// Parse response
-	var result CreateCategoryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
 
-	return &result, nil
+	// Log success and return result
+	log.Printf("CreateCategory result: CategoryID:%d, %s", response.CategoryID, response.Status)
+	response.Category = categoryname
+	return response, nil
 }
 
-func CreateCategoryClient(categoryname string) (*CreateCategoryResponse, error) {
-	// Create a item payload
+// queueNewCategory journals a category created while the server is unreachable
+func queueNewCategory(categoryname string) (*CreateCategoryResponse, error) {
 	payload, err := ProcessCreateCategory(categoryname)
 	if err != nil {
 		log.Printf("CreateCategory processing failed: %v", err)
 		return nil, err
 	}
-
-	// Send to backend server
-	backendURL := "http://localhost:8080/createCategory" // Change as needed
-	response, err := SendCreateCategoryToBackend(payload, backendURL)
-	if err != nil {
-		log.Printf("CreateCategory communication failed: %v", err)
-		return nil, err
-	}
-
-	// Log success and return result
-	log.Printf("CreateCategory result: %s", response.Status)
-	return response, nil
+	return queueCreateCategory(payload, categoryname)
 }
@@ -2,11 +2,10 @@ package service
 
 import (
 	"Modsec/clientside/CipherAlgo/keymaster"
-	"Modsec/clientside/CipherAlgo/utils"
 	"Modsec/clientside/client"
-	"bytes"
-	"encoding/json"
-	"fmt"
+	"Modsec/clientside/schema"
+	"context"
+	"errors"
 	"log"
 	"net/http"
 	"time"
@@ -25,91 +24,86 @@ type CreateItemResponse struct {
 	Message  string    `json:"message"`
 }
 
-func ProcessCreateItem(title, typename string, itemdata map[string]interface{}) (*CreateItemPayload, error) {
-
-	//Encode JSON to byte
-	itemdatabyte, err := json.Marshal(itemdata)
+// ProcessCreateItem seals a new item for the journal. Its ID is not known yet, so it is bound to ID 0 and
+// rebound once the server assigns one.
+func ProcessCreateItem(title string, itemdata schema.Data) (*CreateItemPayload, error) {
+	vaultKey, err := keymaster.Keys.Vaultkey()
 	if err != nil {
-		return nil, fmt.Errorf("failed to encode JSON: %v", err)
+		return nil, err
 	}
+	defer keymaster.Wipe(vaultKey)
 
-	// Encrypt data with sq
-	encryptedItemdata, err := utils.EncryptAES256GCM(itemdatabyte, keymaster.Vaultkey)
+	// Validate and encode with the schema version
+	itemdatabyte, err := schema.Encode(itemdata)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt Itemdata: %v", err)
+		return nil, err
 	}
 
-	encryptedTitle, err := utils.EncryptAES256GCM([]byte(title), keymaster.Vaultkey)
+	typeName := schema.BackendName(itemdata.Type())
+	encryptedTitle, encryptedItemdata, err := sealItem(vaultKey, 0, typeName, []byte(title), itemdatabyte)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt Title: %v", err)
+		return nil, err
 	}
 
-	StrencryptedTitle := utils.BytToBa64(encryptedTitle)
-
 	// Create login payload
 	payload := &CreateItemPayload{
-		Title: StrencryptedTitle,
-		Type:  typename,
+		Title: encryptedTitle,
+		Type:  typeName,
 		Data:  encryptedItemdata,
 	}
 
 	return payload, nil
 }
 
-// SendLoginToBackend sends login data to the backend server
-func SendCreateItemToBackend(payload *CreateItemPayload, backendURL string) (*CreateItemResponse, error) {
-	// Convert payload to JSON
-	jsonData, err := json.Marshal(payload)
+// queueNewItem keeps a new item in the journal until the server is reachable
+func queueNewItem(title string, itemdata schema.Data) (*CreateItemResponse, error) {
+	payload, err := ProcessCreateItem(title, itemdata)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encode JSON: %v", err)
-	}
-
-	// Send data to backend server
-	req, err := http.NewRequest("POST", backendURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
+		log.Printf("CreateItem processing failed: %v", err)
+		return nil, err
 	}
+	return queueCreateItem(payload, title)
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Access-Control-Allow-Credentials", "true")
-
-	//Delete after test
-	fmt.Println("Item data:", req)
-
-	resp, err := client.HMClient.Do(req)
+func CreateItemClient(input ItemInput) (*CreateItemResponse, error) {
+	ItemData, err := input.data()
 	if err != nil {
+		log.Printf("CreateItem validation failed: %v", err)
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("CreateItem failed with status: %d", resp.StatusCode)
-	}
 
-	// Parse response
-	var result CreateItemResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+	// Keep the write for later while the server is unreachable
+	if IsOffline() {
+		return queueNewItem(input.Title, ItemData)
 	}
 
-	return &result, nil
-}
-
-func CreateItemClient(title, typename string, ItemData map[string]interface{}) (*CreateItemResponse, error) {
-	// Create a item payload
-	payload, err := ProcessCreateItem(title, typename, ItemData)
+	itemdatabyte, err := schema.Encode(ItemData)
 	if err != nil {
-		log.Printf("Login processing failed: %v", err)
 		return nil, err
 	}
 
 	// Send to backend server
-	backendURL := "http://localhost:8080/createItem" // Change as needed
-	response, err := SendCreateItemToBackend(payload, backendURL)
-	if err != nil {
+	ctx := context.Background()
+	response, pending, err := createBound(ctx, schema.BackendName(ItemData.Type()), []byte(input.Title), itemdatabyte, nil)
+	switch {
+	case response == nil && errors.Is(err, client.ErrNetwork):
+		log.Printf("CreateItem could not reach the server, queueing: %v", err)
+		return queueNewItem(input.Title, ItemData)
+	case pending != nil && errors.Is(err, client.ErrNetwork):
+		// The item exists but its content did not arrive, send it with the next sync
+		log.Printf("CreateItem could not send the content of item %d, queueing: %v", response.ItemID, err)
+		if _, err := queueUpdateItem(pending); err != nil {
+			return nil, err
+		}
+	case err != nil:
 		log.Printf("CreateItem communication failed: %v", err)
+		if response != nil {
+			// Do not leave an empty placeholder behind
+			deleteErr := client.Backend.Do(ctx, http.MethodDelete, "/deleteItem", &DeleteItemPayload{ItemID: response.ItemID}, nil)
+			if deleteErr != nil {
+				log.Printf("Failed to remove placeholder item %d: %v", response.ItemID, deleteErr)
+			}
+		}
 		return nil, err
 	}
 
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"Modsec/clientside/CipherAlgo/keymaster"
+	"Modsec/clientside/CipherAlgo/utils"
+	"Modsec/clientside/schema"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"sync"
+)
+
+// ErrItemNotFound is returned by GetItemDetail for an item that is not in the vault
+var ErrItemNotFound = errors.New("item not found")
+
+// decryptWorkers bounds how many goroutines decrypt items at once
+var decryptWorkers = runtime.NumCPU()
+
+// decryptBatch is how many items a worker takes at a time, so small items are not dominated by channel overhead
+const decryptBatch = 32
+
+// DecryptItems decrypts items on a bounded pool of workers and returns them in the same order.
+// Titles and metadata are always decrypted, Data only when withData is set; otherwise it is left nil
+// and fetched later through GetItemDetail. Cancelling ctx stops the workers and returns ctx.Err().
+func DecryptItems(ctx context.Context, items []Item, withData bool) ([]AfterItem, error) {
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return nil, err
+	}
+	defer keymaster.Wipe(vaultKey)
+
+	result := make([]AfterItem, len(items))
+	err = parallelBatches(ctx, len(items), func(start, end int) {
+		for i := start; i < end; i++ {
+			result[i] = openItem(vaultKey, items[i], withData, openField)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// parallelBatches calls fn for consecutive [start, end) ranges of n on up to decryptWorkers goroutines.
+// Each index is written by exactly one call, which is what keeps the results in order.
+func parallelBatches(ctx context.Context, n int, fn func(start, end int)) error {
+	if n == 0 {
+		return ctx.Err()
+	}
+
+	workers := decryptWorkers
+	if batches := (n + decryptBatch - 1) / decryptBatch; workers > batches {
+		workers = batches
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range jobs {
+				end := start + decryptBatch
+				if end > n {
+					end = n
+				}
+				fn(start, end)
+			}
+		}()
+	}
+
+	var err error
+feed:
+	for start := 0; start < n; start += decryptBatch {
+		select {
+		case jobs <- start:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err == nil {
+		err = ctx.Err()
+	}
+	return err
+}
+
+// openItem decrypts the title of item and, if withData is set, its Data, with open.
+// Anything that fails to decrypt gets a placeholder so one bad item does not hide the rest.
+func openItem(vaultKey []byte, item Item, withData bool, open fieldOpener) AfterItem {
+	after := AfterItem{
+		ItemID:     item.ItemID,
+		CategoryID: item.CategoryID,
+		Title:      item.Title,
+		TypeName:   string(schema.FrontendName(item.TypeName)),
+		DateCreate: item.DateCreate,
+		DateModify: item.DateModify,
+		IsBookmark: item.IsBookmark,
+	}
+
+	// Titles that were never encrypted are used as-is
+	if bytetitle, err := utils.Ba64ToByt(item.Title); err == nil && isSealedField(item.Title) {
+		decryptedTitle, _, err := open(bytetitle, vaultKey, itemBinding(item.ItemID, item.TypeName, fieldTitle))
+		if err != nil {
+			log.Printf("Error decrypting title for item ID %d: %v", item.ItemID, err)
+			after.Title = ""
+		} else {
+			after.Title = string(decryptedTitle)
+		}
+	}
+	if after.Title == "" {
+		after.Title = fmt.Sprintf("[Item %d]", item.ItemID)
+	}
+
+	if withData {
+		after.Data = openItemData(vaultKey, item, open)
+	}
+	return after
+}
+
+// openItemData decrypts the Data of item into its schema, returning nil if it cannot be read
+func openItemData(vaultKey []byte, item Item, open fieldOpener) *schema.Fields {
+	if item.Data == "" {
+		return nil
+	}
+
+	dataBytes, err := utils.Ba64ToByt(item.Data)
+	if err != nil {
+		log.Printf("Error decoding data for item ID %d: %v", item.ItemID, err)
+		return nil
+	}
+	decryptedData, _, err := open(dataBytes, vaultKey, itemBinding(item.ItemID, item.TypeName, fieldData))
+	if err != nil {
+		log.Printf("Error decrypting data for item ID %d: %v", item.ItemID, err)
+		return nil
+	}
+	data, err := schema.Decode(schema.FrontendName(item.TypeName), decryptedData)
+	if err != nil {
+		log.Printf("Error decoding item data for item ID %d: %v", item.ItemID, err)
+		return nil
+	}
+	return schema.FieldsOf(data)
+}
+
+// GetItemDetail decrypts the Data of one item on demand
+func GetItemDetail(ctx context.Context, itemID uint) (*AfterItem, error) {
+	if err := requireUnlocked(); err != nil {
+		return nil, err
+	}
+
+	raw, ok := store.rawItem(itemID)
+	if !ok && !store.isLoaded() {
+		if _, err := SyncVault(ctx); err != nil {
+			return nil, err
+		}
+		raw, ok = store.rawItem(itemID)
+	}
+	if !ok {
+		return nil, ErrItemNotFound
+	}
+
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return nil, err
+	}
+	defer keymaster.Wipe(vaultKey)
+
+	detail := openItem(vaultKey, raw, true, openField)
+	return &detail, nil
+}
+
+// DecryptItemsOfType decrypts every item of type t, Data included
+func DecryptItemsOfType(ctx context.Context, t schema.Type) ([]AfterItem, error) {
+	if err := requireUnlocked(); err != nil {
+		return nil, err
+	}
+	if !store.isLoaded() {
+		if _, err := SyncVault(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var matching []Item
+	for _, item := range store.rawResponse().Items {
+		if schema.FrontendName(item.TypeName) == t {
+			matching = append(matching, item)
+		}
+	}
+	return DecryptItems(ctx, matching, true)
+}
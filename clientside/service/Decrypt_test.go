@@ -0,0 +1,117 @@
+package service
+
+// cd clientside/service
+// go test -bench DecryptItems -benchmem
+
+import (
+	"Modsec/clientside/CipherAlgo/keymaster"
+	"Modsec/clientside/CipherAlgo/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// testVault unlocks a throwaway vault and returns n encrypted login items
+func testVault(tb testing.TB, n int) []Item {
+	tb.Helper()
+
+	vaultKey := make([]byte, 32)
+	for i := range vaultKey {
+		vaultKey[i] = byte(i)
+	}
+	keymaster.Keys.Store("bench@example.com", vaultKey, vaultKey, nil)
+	tb.Cleanup(keymaster.Keys.Clear)
+
+	items := make([]Item, n)
+	for i := range items {
+		title, err := utils.EncryptAES256GCM([]byte(fmt.Sprintf("Item %d", i)), vaultKey)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		data, _ := json.Marshal(map[string]interface{}{
+			"username": fmt.Sprintf("user%d@example.com", i),
+			"password": "correct horse battery staple",
+			"url":      "https://example.com/login",
+			"notes":    "Lorem ipsum dolor sit amet, consectetur adipiscing elit.",
+		})
+		encryptedData, err := utils.EncryptAES256GCM(data, vaultKey)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		items[i] = Item{
+			ItemID:   uint(i + 1),
+			Title:    utils.BytToBa64(title),
+			TypeName: "login",
+			Data:     utils.BytToBa64(encryptedData),
+		}
+	}
+	return items
+}
+
+func TestDecryptItemsKeepsOrder(t *testing.T) {
+	items := testVault(t, 1000)
+
+	result, err := DecryptItems(context.Background(), items, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, item := range result {
+		if want := fmt.Sprintf("Item %d", i); item.Title != want || item.ItemID != uint(i+1) {
+			t.Fatalf("result[%d] = %d %q, want %d %q", i, item.ItemID, item.Title, i+1, want)
+		}
+		if item.Data == nil || item.Data.Website == nil || item.Data.Website.Username != fmt.Sprintf("user%d@example.com", i) {
+			t.Fatalf("result[%d] has wrong data: %v", i, item.Data)
+		}
+	}
+}
+
+func TestDecryptItemsTitlesOnly(t *testing.T) {
+	items := testVault(t, 10)
+
+	result, err := DecryptItems(context.Background(), items, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result[3].Title != "Item 3" || result[3].Data != nil {
+		t.Fatalf("got %q with data %v, want title only", result[3].Title, result[3].Data)
+	}
+}
+
+func TestDecryptItemsCancelled(t *testing.T) {
+	items := testVault(t, 1000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := DecryptItems(ctx, items, true); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
+
+func benchmarkDecryptItems(b *testing.B, n, workers int, withData bool) {
+	items := testVault(b, n)
+
+	defer func(old int) { decryptWorkers = old }(decryptWorkers)
+	decryptWorkers = workers
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecryptItems(context.Background(), items, withData); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecryptItems(b *testing.B) {
+	for _, n := range []int{100, 1000, 5000} {
+		for _, workers := range []int{1, 4, 8} {
+			b.Run(fmt.Sprintf("items=%d/workers=%d/titles", n, workers), func(b *testing.B) {
+				benchmarkDecryptItems(b, n, workers, false)
+			})
+			b.Run(fmt.Sprintf("items=%d/workers=%d/full", n, workers), func(b *testing.B) {
+				benchmarkDecryptItems(b, n, workers, true)
+			})
+		}
+	}
+}
@@ -3,23 +3,22 @@ package service
 import (
 	"Modsec/clientside/CipherAlgo/keymaster"
 	"Modsec/clientside/CipherAlgo/utils"
-	"Modsec/clientside/client"
-	"encoding/json"
+	"Modsec/clientside/schema"
+	"context"
 	"fmt"
 	"log"
-	"net/http"
 	"time"
 )
 
 type AfterItem struct {
-	ItemID     uint                   `json:"ItemID"`
-	CategoryID *uint                  `json:"CategoryID,omitempty"`
-	Title      string                 `json:"Title"`
-	TypeName   string                 `json:"TypeName"`
-	DateCreate time.Time              `json:"DateCreate"`
-	DateModify time.Time              `json:"DateModify"`
-	Data       map[string]interface{} `json:"Data"`
-	IsBookmark bool                   `json:"IsBookmark"`
+	ItemID     uint           `json:"ItemID"`
+	CategoryID *uint          `json:"CategoryID,omitempty"`
+	Title      string         `json:"Title"`
+	TypeName   string         `json:"TypeName"`
+	DateCreate time.Time      `json:"DateCreate"`
+	DateModify time.Time      `json:"DateModify"`
+	Data       *schema.Fields `json:"Data"`
+	IsBookmark bool           `json:"IsBookmark"`
 }
 
 type Item struct {
@@ -46,149 +45,39 @@ type Category struct {
 type GetListItemResponse struct {
 	Items     []Item     `json:"Items"`
 	Categorys []Category `json:"categorys"`
+
+	// Only sent by /syncItems
+	Revision         uint64 `json:"revision,omitempty"`
+	Full             bool   `json:"full,omitempty"`
+	DeletedItems     []uint `json:"deleted_items,omitempty"`
+	DeletedCategorys []uint `json:"deleted_categorys,omitempty"`
 }
 
+// ProcessGetListItem decrypts every item in resp, including Data
 func ProcessGetListItem(resp *GetListItemResponse) (*[]AfterItem, error) {
-	var result []AfterItem
-
-	for _, item := range resp.Items {
-		// Add some debugging to check the raw title value
-		log.Printf("Processing item with ID %d, title: %s, type: %s", item.ItemID, item.Title, item.TypeName)
-
-		// Check if title is empty or contains raw non-base64 data
-		if item.Title == "" || !isBase64(item.Title) {
-			log.Printf("Item %d has non-base64 or empty title, using as-is", item.ItemID)
-
-			// Use the title as-is or a placeholder if empty
-			titleValue := item.Title
-			if titleValue == "" {
-				titleValue = fmt.Sprintf("[Item %d]", item.ItemID)
-			}
-
-			var dataMap map[string]interface{}
-
-			// Try to process data if available
-			if item.Data != "" && isBase64(item.Data) {
-				dataBytes, err := utils.Ba64ToByt(item.Data)
-				if err == nil {
-					decryptedData, err := utils.DecryptAES256GCM(dataBytes, keymaster.Vaultkey)
-					if err == nil {
-						// Try to parse the JSON data
-						if err := json.Unmarshal(decryptedData, &dataMap); err != nil {
-							dataMap = make(map[string]interface{})
-						}
-					} else {
-						dataMap = make(map[string]interface{})
-					}
-				} else {
-					dataMap = make(map[string]interface{})
-				}
-			} else {
-				dataMap = make(map[string]interface{})
-			}
-
-			eachitem := AfterItem{
-				ItemID:     item.ItemID,
-				CategoryID: item.CategoryID,
-				Title:      titleValue,
-				TypeName:   mapTypeNameToFrontend(item.TypeName), // Map the type name
-				Data:       dataMap,
-				DateCreate: item.DateCreate,
-				DateModify: item.DateModify,
-				IsBookmark: item.IsBookmark,
-			}
-
-			result = append(result, eachitem)
-			continue
-		}
-
-		// Title is base64-encoded, try to decode it
-		bytetitle, err := utils.Ba64ToByt(item.Title)
-		if err != nil {
-			log.Printf("Error decoding title for item ID %d: %v", item.ItemID, err)
-
-			// Create entry with placeholder title
-			eachitem := AfterItem{
-				ItemID:     item.ItemID,
-				CategoryID: item.CategoryID,
-				Title:      fmt.Sprintf("[Item %d]", item.ItemID),
-				TypeName:   mapTypeNameToFrontend(item.TypeName), // Map the type name
-				Data:       make(map[string]interface{}),
-				DateCreate: item.DateCreate,
-				DateModify: item.DateModify,
-				IsBookmark: item.IsBookmark,
-			}
-			result = append(result, eachitem)
-			continue
-		}
-
-		// Try to decrypt the title
-		decryptedTitle, err := utils.DecryptAES256GCM(bytetitle, keymaster.Vaultkey)
-		if err != nil {
-			log.Printf("Error decrypting title for item ID %d: %v", item.ItemID, err)
-
-			// Create entry with placeholder title
-			eachitem := AfterItem{
-				ItemID:     item.ItemID,
-				CategoryID: item.CategoryID,
-				Title:      fmt.Sprintf("[Item %d]", item.ItemID),
-				TypeName:   mapTypeNameToFrontend(item.TypeName), // Map the type name
-				Data:       make(map[string]interface{}),
-				DateCreate: item.DateCreate,
-				DateModify: item.DateModify,
-				IsBookmark: item.IsBookmark,
-			}
-			result = append(result, eachitem)
-			continue
-		}
-
-		// Process the data field with similar error handling
-		var dataMap map[string]interface{} = make(map[string]interface{})
-
-		if item.Data != "" && isBase64(item.Data) {
-			dataBytes, err := utils.Ba64ToByt(item.Data)
-			if err == nil {
-				decryptedData, err := utils.DecryptAES256GCM(dataBytes, keymaster.Vaultkey)
-				if err == nil {
-					// Try to parse the JSON data
-					if err := json.Unmarshal(decryptedData, &dataMap); err != nil {
-						log.Printf("Error unmarshaling JSON for item ID %d: %v", item.ItemID, err)
-					}
-				} else {
-					log.Printf("Error decrypting data for item ID %d: %v", item.ItemID, err)
-				}
-			} else {
-				log.Printf("Error decoding data for item ID %d: %v", item.ItemID, err)
-			}
-		}
-
-		// Create the item with all processed data
-		eachitem := AfterItem{
-			ItemID:     item.ItemID,
-			CategoryID: item.CategoryID,
-			Title:      string(decryptedTitle),
-			TypeName:   mapTypeNameToFrontend(item.TypeName), // Map the type name
-			Data:       dataMap,
-			DateCreate: item.DateCreate,
-			DateModify: item.DateModify,
-			IsBookmark: item.IsBookmark,
-		}
-		result = append(result, eachitem)
+	result, err := DecryptItems(context.Background(), resp.Items, true)
+	if err != nil {
+		return nil, err
 	}
-
 	return &result, nil
 }
 
 func ProcessGetListCategory(resp *GetListItemResponse) (*[]AfterCategory, error) {
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return nil, err
+	}
+	defer keymaster.Wipe(vaultKey)
+
 	var result []AfterCategory
 
 	for _, category := range resp.Categorys {
 		// Add some debugging to check the raw title value
 		log.Printf("Processing categroy with ID %d, name: %s", category.CategoryID, category.CategoryName)
 
-		// Check if title is empty or contains raw non-base64 data
-		if category.CategoryName == "" || !isBase64(category.CategoryName) {
-			log.Printf("Category %d has non-base64 or empty category, using as-is", category.CategoryID)
+		// Check if the name is empty or was never encrypted
+		if !isSealedField(category.CategoryName) {
+			log.Printf("Category %d has an unencrypted or empty name, using as-is", category.CategoryID)
 
 			// Use the title as-is or a placeholder if empty
 			categoryname := category.CategoryName
@@ -221,7 +110,7 @@ func ProcessGetListCategory(resp *GetListItemResponse) (*[]AfterCategory, error)
 		}
 
 		// Try to decrypt the title
-		decryptedCategoryName, err := utils.DecryptAES256GCM(bytecategory, keymaster.Vaultkey)
+		decryptedCategoryName, _, err := openField(bytecategory, vaultKey, categoryBinding(category.CategoryID))
 		if err != nil {
 			log.Printf("Error decrypting title for category ID %d: %v", category.CategoryID, err)
 
@@ -275,82 +164,14 @@ func isBase64(s string) bool {
 	return err == nil
 }
 
-// Add this function to map backend types to frontend types
-func mapTypeNameToFrontend(backendType string) string {
-	switch backendType {
-	case "login":
-		return "website"
-	case "cryptowallet":
-		return "crypto"
-	case "identity":
-		return "identity"
-	case "note":
-		return "memo"
-	case "card":
-		return "card"
-	default:
-		log.Printf("Unknown type name: %s, using as-is", backendType)
-		return backendType
-	}
-}
-
-// SendLoginToBackend sends login data to the backend server
-func SendGetListItemToBackend(backendURL string) (*GetListItemResponse, error) {
-
-	// Send data to backend server
-	req, err := http.NewRequest("GET", backendURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Access-Control-Allow-Credentials", "true")
-
-	resp, err := client.HMClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GetListItem failed with status: %d", resp.StatusCode)
-	}
-
-	// Parse response
-	var result GetListItemResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-
-	return &result, nil
-}
-
-// LoginUser combines processing and backend communication
+// GetListItemClient syncs the vault and returns every decrypted item and category
 func GetListItemClient() (*[]AfterItem, *[]AfterCategory, error) {
-
-	// Send to backend server
-	backendURL := "http://localhost:8080/getItemList"
-	response, err := SendGetListItemToBackend(backendURL)
-	if err != nil {
-		log.Printf("GetListItem communication failed: %v", err)
+	if _, err := SyncVault(context.Background()); err != nil {
+		log.Printf("GetListItem sync failed: %v", err)
 		return nil, nil, err
 	}
 
-	resptofront, err := ProcessGetListItem(response)
-	if err != nil {
-		log.Printf("GetListItem communication failed: %v", err)
-		return nil, nil, err
-	}
-
-	respcategoryfront, err := ProcessGetListCategory(response)
-	if err != nil {
-		log.Printf("GetListCategory communication failed: %v", err)
-		return resptofront, nil, err
-	}
-
-	// Log success and return result
-	log.Printf("GetListItem result: All good")
-	return resptofront, respcategoryfront, nil
+	items, categories := store.snapshot()
+	log.Printf("GetListItem result: %d items, %d categories", len(items), len(categories))
+	return &items, &categories, nil
 }
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"Modsec/clientside/schema"
+	"Modsec/clientside/strength"
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HealthOptions are the limits the vault health report checks against
+type HealthOptions struct {
+	MaxPasswordAgeDays int `json:"maxPasswordAgeDays"` // Passwords unchanged for longer are reported as old
+	CardExpiryDays     int `json:"cardExpiryDays"`     // Cards expiring within this many days are reported
+	MinPasswordScore   int `json:"minPasswordScore"`   // Passwords scoring lower are reported as weak
+}
+
+// DefaultHealthOptions returns the limits used by GetVaultHealthReport
+func DefaultHealthOptions() HealthOptions {
+	return HealthOptions{
+		MaxPasswordAgeDays: 365,
+		CardExpiryDays:     60,
+		MinPasswordScore:   3,
+	}
+}
+
+// HealthItem identifies an item in the health report
+type HealthItem struct {
+	ItemID   uint   `json:"itemId"`
+	Title    string `json:"title"`
+	TypeName string `json:"typeName"`
+}
+
+// ReusedPassword is a password shared by more than one website item
+type ReusedPassword struct {
+	Items []HealthItem `json:"items"`
+}
+
+// WeakPassword is a website password that is too easy to guess
+type WeakPassword struct {
+	Item    HealthItem `json:"item"`
+	Score   int        `json:"score"`
+	Warning string     `json:"warning"`
+}
+
+// OldPassword is a website password that has not changed for a long time
+type OldPassword struct {
+	Item    HealthItem `json:"item"`
+	Changed time.Time  `json:"changed"` // Last password change, or the last edit of items without a history
+	AgeDays int        `json:"ageDays"`
+}
+
+// InsecureURL is a link that is not served over https
+type InsecureURL struct {
+	Item HealthItem `json:"item"`
+	URL  string     `json:"url"`
+}
+
+// ExpiringCard is a card that has expired or expires soon
+type ExpiringCard struct {
+	Item     HealthItem `json:"item"`
+	Expires  string     `json:"expires"`  // Last valid month as YYYY-MM
+	DaysLeft int        `json:"daysLeft"` // Negative once expired
+	Expired  bool       `json:"expired"`
+}
+
+// HealthReport lists the items that need attention
+type HealthReport struct {
+	GeneratedAt  time.Time        `json:"generatedAt"`
+	Options      HealthOptions    `json:"options"`
+	TotalItems   int              `json:"totalItems"`
+	Passwords    int              `json:"passwords"` // Website items with a password
+	Score        int              `json:"score"`     // Share of audited items without any issue, 0 to 100
+	Reused       []ReusedPassword `json:"reused"`
+	Weak         []WeakPassword   `json:"weak"`
+	Old          []OldPassword    `json:"old"`
+	InsecureURLs []InsecureURL    `json:"insecureUrls"`
+	Cards        []ExpiringCard   `json:"cards"`
+}
+
+// GetVaultHealthReport audits every item of the vault for reused, weak and old passwords, plain http
+// links and cards near expiry
+func GetVaultHealthReport(ctx context.Context, opts HealthOptions) (*HealthReport, error) {
+	if err := requireUnlocked(); err != nil {
+		return nil, err
+	}
+	if !store.isLoaded() {
+		if _, err := SyncVault(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	items, err := ProcessGetListItem(store.rawResponse())
+	if err != nil {
+		return nil, err
+	}
+	report := auditItems(*items, opts, time.Now())
+	return &report, nil
+}
+
+// auditItems builds the health report of items as of now
+func auditItems(items []AfterItem, opts HealthOptions, now time.Time) HealthReport {
+	report := HealthReport{
+		GeneratedAt:  now,
+		Options:      opts,
+		TotalItems:   len(items),
+		Reused:       []ReusedPassword{},
+		Weak:         []WeakPassword{},
+		Old:          []OldPassword{},
+		InsecureURLs: []InsecureURL{},
+		Cards:        []ExpiringCard{},
+	}
+	flagged := map[uint]bool{}
+	audited := 0
+	byPassword := map[string][]HealthItem{}
+
+	for _, item := range items {
+		if item.Data == nil {
+			continue
+		}
+		ref := HealthItem{ItemID: item.ItemID, Title: item.Title, TypeName: item.TypeName}
+		audited++
+
+		if website := item.Data.Website; website != nil {
+			if website.Password != "" {
+				report.Passwords++
+				byPassword[website.Password] = append(byPassword[website.Password], ref)
+
+				result := strength.Estimate(website.Password, website.Username, item.Title)
+				if result.Score < opts.MinPasswordScore {
+					report.Weak = append(report.Weak, WeakPassword{Item: ref, Score: result.Score, Warning: result.Feedback.Warning})
+					flagged[ref.ItemID] = true
+				}
+
+				changed := item.DateModify
+				if len(website.PasswordHistory) > 0 {
+					changed = website.PasswordHistory[0].Replaced
+				}
+				if age := int(now.Sub(changed).Hours() / 24); !changed.IsZero() && age > opts.MaxPasswordAgeDays {
+					report.Old = append(report.Old, OldPassword{Item: ref, Changed: changed, AgeDays: age})
+					flagged[ref.ItemID] = true
+				}
+			}
+			if insecureURL(website.URL) {
+				report.InsecureURLs = append(report.InsecureURLs, InsecureURL{Item: ref, URL: website.URL})
+				flagged[ref.ItemID] = true
+			}
+		}
+
+		if data, err := item.Data.Data(); err == nil {
+			if custom := schema.CustomFieldsOf(data); custom != nil {
+				for _, field := range *custom {
+					if field.Kind == schema.FieldURL && insecureURL(field.Value) {
+						report.InsecureURLs = append(report.InsecureURLs, InsecureURL{Item: ref, URL: field.Value})
+						flagged[ref.ItemID] = true
+					}
+				}
+			}
+		}
+
+		if card := item.Data.Card; card != nil {
+			if expiring, ok := cardExpiry(card, now, opts.CardExpiryDays); ok {
+				expiring.Item = ref
+				report.Cards = append(report.Cards, expiring)
+				flagged[ref.ItemID] = true
+			}
+		}
+	}
+
+	for _, refs := range byPassword {
+		if len(refs) < 2 {
+			continue
+		}
+		sort.Slice(refs, func(a, b int) bool { return refs[a].ItemID < refs[b].ItemID })
+		report.Reused = append(report.Reused, ReusedPassword{Items: refs})
+		for _, ref := range refs {
+			flagged[ref.ItemID] = true
+		}
+	}
+	sort.Slice(report.Reused, func(a, b int) bool {
+		if len(report.Reused[a].Items) != len(report.Reused[b].Items) {
+			return len(report.Reused[a].Items) > len(report.Reused[b].Items)
+		}
+		return report.Reused[a].Items[0].ItemID < report.Reused[b].Items[0].ItemID
+	})
+	sort.Slice(report.Weak, func(a, b int) bool { return report.Weak[a].Score < report.Weak[b].Score })
+	sort.Slice(report.Old, func(a, b int) bool { return report.Old[a].AgeDays > report.Old[b].AgeDays })
+	sort.Slice(report.Cards, func(a, b int) bool { return report.Cards[a].DaysLeft < report.Cards[b].DaysLeft })
+
+	report.Score = 100
+	if audited > 0 {
+		report.Score = 100 * (audited - len(flagged)) / audited
+	}
+	return report
+}
+
+// insecureURL reports whether link is sent in the clear
+func insecureURL(link string) bool {
+	u, err := url.Parse(strings.TrimSpace(link))
+	return err == nil && strings.EqualFold(u.Scheme, "http")
+}
+
+// cardExpiry reports a card that expired or expires within days of now. A card is valid until the end
+// of its expiration month.
+func cardExpiry(card *schema.Card, now time.Time, days int) (ExpiringCard, bool) {
+	month, err := strconv.Atoi(strings.TrimSpace(card.ExpirationMonth))
+	if err != nil || month < 1 || month > 12 {
+		return ExpiringCard{}, false
+	}
+	year, err := strconv.Atoi(strings.TrimSpace(card.ExpirationYear))
+	if err != nil {
+		return ExpiringCard{}, false
+	}
+	if year < 100 {
+		year += 2000
+	}
+
+	end := time.Date(year, time.Month(month)+1, 1, 0, 0, 0, 0, now.Location())
+	left := int(end.Sub(now).Hours() / 24)
+	if end.After(now) && left > days {
+		return ExpiringCard{}, false
+	}
+	return ExpiringCard{
+		Expires:  end.AddDate(0, -1, 0).Format("2006-01"),
+		DaysLeft: left,
+		Expired:  !end.After(now),
+	}, true
+}
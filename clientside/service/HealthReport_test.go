@@ -0,0 +1,90 @@
+package service
+
+import (
+	"Modsec/clientside/schema"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAuditItems(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	recent := now.AddDate(0, -1, 0)
+	const strong = "walrus-pickle-nebula-drizzle-fox"
+
+	website := func(id uint, password, link string, modified time.Time) AfterItem {
+		return AfterItem{ItemID: id, Title: "Site", TypeName: string(schema.TypeWebsite), DateModify: modified,
+			Data: &schema.Fields{Website: &schema.Website{Username: "alice", Password: password, URL: link}}}
+	}
+	card := func(id uint, month, year string) AfterItem {
+		return AfterItem{ItemID: id, Title: "Card", TypeName: string(schema.TypeCard), DateModify: recent,
+			Data: &schema.Fields{Card: &schema.Card{ExpirationMonth: month, ExpirationYear: year}}}
+	}
+
+	items := []AfterItem{
+		website(1, strong, "https://a.example", recent),
+		website(2, strong, "https://b.example", recent),
+		website(3, "password1", "https://c.example", recent),
+		website(4, "Xq7!mZ2#rT9@wLp4", "http://d.example", recent),
+		website(5, "Vn3$kB8^pH1&yQz6", "https://e.example", now.AddDate(-2, 0, 0)),
+		website(6, "Gc5*tJ4%sW2!eRm8", "https://f.example", recent),
+		website(11, "Hd6&uK5^tX3@fSn9", "https://g.example", recent),
+		card(7, "02", "26"),
+		card(8, "4", "2026"),
+		card(9, "12", "2030"),
+		{ItemID: 10, Title: "Locked"},
+	}
+	// Edited recently, but the password itself last changed two years ago
+	items[6].Data.Website.PasswordHistory = schema.PasswordHistory{{Password: "old", Replaced: now.AddDate(-2, 0, 0)}}
+	report := auditItems(items, DefaultHealthOptions(), now)
+
+	if report.TotalItems != 11 || report.Passwords != 7 {
+		t.Fatalf("totals = %d items, %d passwords", report.TotalItems, report.Passwords)
+	}
+	if len(report.Reused) != 1 || len(report.Reused[0].Items) != 2 ||
+		report.Reused[0].Items[0].ItemID != 1 || report.Reused[0].Items[1].ItemID != 2 {
+		t.Errorf("reused = %+v", report.Reused)
+	}
+	if len(report.Weak) != 1 || report.Weak[0].Item.ItemID != 3 {
+		t.Errorf("weak = %+v", report.Weak)
+	}
+	if len(report.InsecureURLs) != 1 || report.InsecureURLs[0].Item.ItemID != 4 {
+		t.Errorf("insecure = %+v", report.InsecureURLs)
+	}
+	if len(report.Old) != 2 || report.Old[0].Item.ItemID != 5 || report.Old[1].Item.ItemID != 11 {
+		t.Errorf("old = %+v", report.Old)
+	}
+	if len(report.Cards) != 2 || report.Cards[0].Item.ItemID != 7 || !report.Cards[0].Expired ||
+		report.Cards[1].Item.ItemID != 8 || report.Cards[1].Expired || report.Cards[1].Expires != "2026-04" {
+		t.Errorf("cards = %+v", report.Cards)
+	}
+	// Items 6 and 9 are healthy, item 10 has no data and is not audited
+	if report.Score != 2*100/10 {
+		t.Errorf("score = %d", report.Score)
+	}
+}
+
+func TestFindBreached(t *testing.T) {
+	website := func(id uint, password string) AfterItem {
+		return AfterItem{ItemID: id, Title: "Site", Data: &schema.Fields{Website: &schema.Website{Password: password}}}
+	}
+	items := []AfterItem{website(1, "password"), website(2, "unique"), website(3, "password"), website(4, "hunter2"), {ItemID: 5}}
+
+	lookups := 0
+	count := func(_ context.Context, password string) (int, error) {
+		lookups++
+		return map[string]int{"password": 1000, "hunter2": 17}[password], nil
+	}
+
+	breached, err := findBreached(context.Background(), items, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lookups != 3 {
+		t.Errorf("%d lookups, want one per distinct password", lookups)
+	}
+	if len(breached) != 3 || breached[0].Item.ItemID != 1 || breached[1].Item.ItemID != 3 ||
+		breached[2].Item.ItemID != 4 || breached[2].Count != 17 {
+		t.Errorf("breached = %+v", breached)
+	}
+}
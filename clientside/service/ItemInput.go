@@ -0,0 +1,20 @@
+package service
+
+import (
+	"Modsec/clientside/schema"
+)
+
+// ItemInput is what the frontend sends to create or update an item
+type ItemInput struct {
+	Title  string        `json:"title"`
+	Fields schema.Fields `json:"fields"`
+}
+
+// data validates the input and returns the item data it holds
+func (in ItemInput) data() (schema.Data, error) {
+	d, err := in.Fields.Data()
+	if err != nil {
+		return nil, err
+	}
+	return d, d.Validate()
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"Modsec/clientside/CipherAlgo/keymaster"
+	"Modsec/clientside/client"
+	"Modsec/clientside/schema"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrVersionNotFound is returned when an item has no stored version with the requested number
+	ErrVersionNotFound = errors.New("item version not found")
+	// ErrVersionsUnsupported is returned when the backend does not keep item versions
+	ErrVersionsUnsupported = errors.New("the server does not keep item versions")
+)
+
+// CurrentVersion stands for the current state of an item when comparing versions
+const CurrentVersion uint = 0
+
+// ItemVersion is an earlier state of an item as kept by the backend, still encrypted. The backend stores
+// one on every /updateItem and numbers them from 1 per item.
+type ItemVersion struct {
+	Version    uint      `json:"version"`
+	ItemID     uint      `json:"item_id"`
+	CategoryID *uint     `json:"category_id"`
+	Title      string    `json:"title"`
+	Data       string    `json:"data"`
+	DateModify time.Time `json:"date_modify"` // When this version was saved
+}
+
+type GetItemVersionsResponse struct {
+	Versions []ItemVersion `json:"versions"`
+}
+
+// AfterItemVersion is a decrypted earlier state of an item
+type AfterItemVersion struct {
+	Version    uint           `json:"version"`
+	CategoryID *uint          `json:"categoryId,omitempty"`
+	Title      string         `json:"title"`
+	DateModify time.Time      `json:"dateModify"`
+	Data       *schema.Fields `json:"data"`
+}
+
+// FieldChange is one field that differs between two versions of an item
+type FieldChange struct {
+	Field     string `json:"field"`  // JSON path of the field, custom fields as customFields.<label>
+	Before    string `json:"before"` // Empty when the field was added
+	After     string `json:"after"`  // Empty when the field was removed
+	Sensitive bool   `json:"sensitive"`
+}
+
+// ItemDiff lists the changes from one version of an item to another
+type ItemDiff struct {
+	ItemID  uint          `json:"itemId"`
+	From    uint          `json:"from"`
+	To      uint          `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// fetchItemVersions downloads the stored versions of an item
+func fetchItemVersions(ctx context.Context, itemID uint) ([]ItemVersion, error) {
+	if err := requireUnlocked(); err != nil {
+		return nil, err
+	}
+	// Items still waiting to be created have no history on the server yet
+	if isTempID(itemID) {
+		return nil, nil
+	}
+	if err := requireOnline(); err != nil {
+		return nil, err
+	}
+
+	response := &GetItemVersionsResponse{}
+	err := client.Backend.Do(ctx, http.MethodGet, "/getItemVersions?item_id="+strconv.FormatUint(uint64(itemID), 10), nil, response)
+
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound && apiErr.Code == "" {
+		log.Printf("Backend has no /getItemVersions")
+		return nil, ErrVersionsUnsupported
+	}
+	if err != nil {
+		log.Printf("GetItemVersions communication failed: %v", err)
+		return nil, err
+	}
+	return response.Versions, nil
+}
+
+// GetItemVersions returns the earlier versions of an item, newest first
+func GetItemVersions(ctx context.Context, itemID uint) ([]AfterItemVersion, error) {
+	current, err := GetItemDetail(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := fetchItemVersions(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return nil, err
+	}
+	defer keymaster.Wipe(vaultKey)
+
+	typeName := schema.BackendName(schema.Type(current.TypeName))
+	result := make([]AfterItemVersion, 0, len(versions))
+	for _, v := range versions {
+		opened := openItem(vaultKey, Item{ItemID: itemID, Title: v.Title, TypeName: typeName, Data: v.Data}, true, openArchivedField)
+		result = append(result, AfterItemVersion{
+			Version:    v.Version,
+			CategoryID: v.CategoryID,
+			Title:      opened.Title,
+			DateModify: v.DateModify,
+			Data:       opened.Data,
+		})
+	}
+	sort.Slice(result, func(a, b int) bool { return result[a].Version > result[b].Version })
+	return result, nil
+}
+
+// DiffItemVersions compares two versions of an item, CurrentVersion being the item as it is now.
+// The server only has ciphertext, so the comparison runs here after decryption.
+func DiffItemVersions(ctx context.Context, itemID uint, from, to uint) (*ItemDiff, error) {
+	current, err := GetItemDetail(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := GetItemVersions(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	pick := func(version uint) (AfterItemVersion, error) {
+		if version == CurrentVersion {
+			return AfterItemVersion{CategoryID: current.CategoryID, Title: current.Title,
+				DateModify: current.DateModify, Data: current.Data}, nil
+		}
+		for _, v := range versions {
+			if v.Version == version {
+				return v, nil
+			}
+		}
+		return AfterItemVersion{}, fmt.Errorf("%w: item %d has no version %d", ErrVersionNotFound, itemID, version)
+	}
+	before, err := pick(from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := pick(to)
+	if err != nil {
+		return nil, err
+	}
+
+	_, categories := store.snapshot()
+	names := make(map[uint]string, len(categories))
+	for _, c := range categories {
+		names[c.CategoryID] = c.CategoryName
+	}
+
+	return &ItemDiff{
+		ItemID:  itemID,
+		From:    from,
+		To:      to,
+		Changes: diffVersions(before, after, schema.Type(current.TypeName), names),
+	}, nil
+}
+
+// RestoreItemVersion saves an earlier version of an item as its current state. The state it replaces is
+// kept by the backend as a new version, so a restore can be undone the same way.
+func RestoreItemVersion(ctx context.Context, itemID uint, version uint) (*UpdateItemResponse, error) {
+	versions, err := GetItemVersions(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v.Version != version {
+			continue
+		}
+		if v.Data == nil {
+			return nil, fmt.Errorf("%w: version %d of item %d cannot be read", schema.ErrInvalidItem, version, itemID)
+		}
+		log.Printf("Restoring item %d to version %d", itemID, version)
+		return UpdateItemClient(itemID, v.CategoryID, ItemInput{Title: v.Title, Fields: *v.Data})
+	}
+	return nil, fmt.Errorf("%w: item %d has no version %d", ErrVersionNotFound, itemID, version)
+}
+
+// diffVersions lists the fields that differ between before and after, in a stable order. The password
+// history is left out, it follows from the password changes that are listed.
+func diffVersions(before, after AfterItemVersion, t schema.Type, categories map[uint]string) []FieldChange {
+	sensitive := map[string]bool{}
+	if info, ok := schema.Lookup(t); ok {
+		for _, name := range info.Sensitive {
+			sensitive[name] = true
+		}
+	}
+
+	changes := []FieldChange{}
+	add := func(field, a, b string, hidden bool) {
+		if a != b {
+			changes = append(changes, FieldChange{Field: field, Before: a, After: b, Sensitive: hidden})
+		}
+	}
+
+	add("title", before.Title, after.Title, false)
+	add("category", categoryLabel(before.CategoryID, categories), categoryLabel(after.CategoryID, categories), false)
+
+	a, hiddenA := flattenData(before.Data)
+	b, hiddenB := flattenData(after.Data)
+	var fields []string
+	for field := range a {
+		fields = append(fields, field)
+	}
+	for field := range b {
+		if _, ok := a[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		root, _, _ := strings.Cut(field, ".")
+		root, _, _ = strings.Cut(root, "[")
+		add(field, a[field], b[field], sensitive[root] || hiddenA[field] || hiddenB[field])
+	}
+	return changes
+}
+
+// categoryLabel names a category for the diff
+func categoryLabel(id *uint, names map[uint]string) string {
+	if id == nil {
+		return ""
+	}
+	if name, ok := names[*id]; ok {
+		return name
+	}
+	return fmt.Sprintf("[Category %d]", *id)
+}
+
+// flattenData turns item data into field paths and their values, plus the custom fields that are hidden
+func flattenData(fields *schema.Fields) (map[string]string, map[string]bool) {
+	values := map[string]string{}
+	hidden := map[string]bool{}
+	if fields == nil {
+		return values, hidden
+	}
+	data, err := fields.Data()
+	if err != nil {
+		return values, hidden
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return values, hidden
+	}
+	var tree map[string]interface{}
+	if err := json.Unmarshal(encoded, &tree); err != nil {
+		return values, hidden
+	}
+	delete(tree, "passwordHistory")
+
+	// Custom fields are matched by label so reordering them is not reported as a change
+	if custom := schema.CustomFieldsOf(data); custom != nil {
+		delete(tree, "customFields")
+		seen := map[string]int{}
+		for _, f := range *custom {
+			path := "customFields." + f.Label
+			if seen[f.Label]++; seen[f.Label] > 1 {
+				path = fmt.Sprintf("%s (%d)", path, seen[f.Label])
+			}
+			values[path] = f.Value
+			hidden[path] = f.Kind == schema.FieldHidden || f.Kind == schema.FieldTOTP
+		}
+	}
+
+	flatten("", tree, values)
+	return values, hidden
+}
+
+// flatten adds every leaf of v under prefix to values
+func flatten(prefix string, v interface{}, values map[string]string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flatten(path, child, values)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flatten(fmt.Sprintf("%s[%d]", prefix, i), child, values)
+		}
+	case nil:
+	case string:
+		if v != "" {
+			values[prefix] = v
+		}
+	default:
+		values[prefix] = fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"Modsec/clientside/schema"
+	"reflect"
+	"testing"
+)
+
+func TestDiffVersions(t *testing.T) {
+	work, home := uint(1), uint(2)
+	categories := map[uint]string{work: "Work", home: "Home"}
+
+	before := AfterItemVersion{Title: "Mail", CategoryID: &work, Data: schema.FieldsOf(&schema.Website{
+		Username: "alice", Password: "old secret", URL: "https://mail.example.com",
+		CustomFields: schema.CustomFields{
+			{Label: "PIN", Kind: schema.FieldHidden, Value: "1234"},
+			{Label: "Plan", Kind: schema.FieldText, Value: "basic"},
+		},
+	})}
+	after := AfterItemVersion{Title: "Mail", CategoryID: &home, Data: schema.FieldsOf(&schema.Website{
+		Username: "alice", Password: "new secret", URL: "https://mail.example.com",
+		PasswordHistory: schema.PasswordHistory{{Password: "old secret"}},
+		CustomFields: schema.CustomFields{
+			{Label: "Plan", Kind: schema.FieldText, Value: "pro"},
+			{Label: "PIN", Kind: schema.FieldHidden, Value: "1234"},
+		},
+	})}
+
+	got := diffVersions(before, after, schema.TypeWebsite, categories)
+	want := []FieldChange{
+		{Field: "category", Before: "Work", After: "Home"},
+		{Field: "customFields.Plan", Before: "basic", After: "pro"},
+		{Field: "password", Before: "old secret", After: "new secret", Sensitive: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("diff = %+v, want %+v", got, want)
+	}
+
+	if changes := diffVersions(after, after, schema.TypeWebsite, categories); len(changes) != 0 {
+		t.Fatalf("identical versions differ: %+v", changes)
+	}
+}
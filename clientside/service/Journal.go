@@ -0,0 +1,1137 @@
+package service
+
+// Writes made while the backend is unreachable are kept in an encrypted journal and
+// replayed once it answers again. Item edits are checked against the server copy with
+// DateModify so a change made elsewhere in the meantime is never silently overwritten.
+
+import (
+	"Modsec/clientside/CipherAlgo/envelope"
+	"Modsec/clientside/CipherAlgo/keymaster"
+	"Modsec/clientside/CipherAlgo/utils"
+	"Modsec/clientside/cache"
+	"Modsec/clientside/client"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// OpKind is the write a journal entry stands for
+type OpKind string
+
+const (
+	OpCreateItem     OpKind = "create_item"
+	OpUpdateItem     OpKind = "update_item"
+	OpDeleteItem     OpKind = "delete_item"
+	OpCreateCategory OpKind = "create_category"
+	OpUpdateCategory OpKind = "update_category"
+	OpDeleteCategory OpKind = "delete_category"
+)
+
+// ConflictReason says why a journaled write was held back instead of replayed
+type ConflictReason string
+
+const (
+	ReasonModified ConflictReason = "modified" // Item changed on the server after it was edited offline
+	ReasonDeleted  ConflictReason = "deleted"  // Item was deleted on the server after it was edited offline
+	ReasonRejected ConflictReason = "rejected" // Backend refused the write
+)
+
+// Resolution is the user's answer to a sync conflict
+type Resolution string
+
+const (
+	KeepMine   Resolution = "mine"
+	KeepTheirs Resolution = "theirs"
+	KeepBoth   Resolution = "both"
+)
+
+// AllResolutions is bound to the frontend so it gets a matching TypeScript enum
+var AllResolutions = []struct {
+	Value  Resolution
+	TSName string
+}{
+	{KeepMine, "MINE"},
+	{KeepTheirs, "THEIRS"},
+	{KeepBoth, "BOTH"},
+}
+
+var (
+	// ErrJournalUnreadable is returned when the offline changes saved on this device cannot be decrypted
+	ErrJournalUnreadable = errors.New("offline changes saved on this device cannot be read")
+	ErrConflictNotFound  = errors.New("sync conflict not found")
+	ErrCannotKeepBoth    = errors.New("keep both is only possible for item edits")
+)
+
+// isItemOp reports whether kind writes an item rather than a category
+func isItemOp(kind OpKind) bool {
+	return kind == OpCreateItem || kind == OpUpdateItem || kind == OpDeleteItem
+}
+
+// Items and categories created offline get IDs from tempIDBase up until the server assigns real ones
+const tempIDBase uint = 1 << 30
+
+func isTempID(id uint) bool {
+	return id >= tempIDBase
+}
+
+// PendingOp is one write waiting in the journal
+type PendingOp struct {
+	ID             string          `json:"id"`
+	Kind           OpKind          `json:"kind"`
+	ItemID         uint            `json:"item_id,omitempty"`
+	CategoryID     uint            `json:"category_id,omitempty"`
+	TypeName       string          `json:"type_name,omitempty"`   // Item type, needed to recreate an item deleted on the server
+	BaseModify     time.Time       `json:"base_modify,omitempty"` // DateModify of the item when it was first edited offline
+	Payload        json.RawMessage `json:"payload,omitempty"`     // Request body, already encrypted with the vault key
+	QueuedAt       time.Time       `json:"queued_at"`
+	Created        bool            `json:"created,omitempty"` // The item was created from this write, only its content is left to send
+	Conflict       ConflictReason  `json:"conflict,omitempty"`
+	Theirs         *Item           `json:"theirs,omitempty"`          // Server copy when the conflict was found
+	TheirsCategory *Category       `json:"theirs_category,omitempty"` // Server copy of the category for a rejected category write
+}
+
+// SyncConflict is a held back write shown to the user with both versions. Item writes fill in Mine
+// and Theirs, category writes MineName and TheirsName.
+type SyncConflict struct {
+	ID         string         `json:"ID"`
+	Kind       OpKind         `json:"Kind"`
+	Reason     ConflictReason `json:"Reason"`
+	ItemID     uint           `json:"ItemID"`
+	CategoryID uint           `json:"CategoryID"`
+	Mine       *AfterItem     `json:"Mine,omitempty"`       // nil when the local change is a deletion
+	Theirs     *AfterItem     `json:"Theirs,omitempty"`     // nil when the item no longer exists on the server
+	MineName   string         `json:"MineName,omitempty"`   // Empty when the local change is a deletion
+	TheirsName string         `json:"TheirsName,omitempty"` // Empty when the category no longer exists on the server
+	QueuedAt   time.Time      `json:"QueuedAt"`
+}
+
+// journal holds the pending writes of the logged-in user, loaded from the cache on first use
+var journal struct {
+	mu     sync.Mutex
+	email  string
+	loaded bool
+	ops    []PendingOp
+}
+
+// loadJournalLocked reads the journal for the current user if it is not in memory yet
+func loadJournalLocked() error {
+	email := keymaster.Keys.Email()
+	if journal.loaded && journal.email == email {
+		return nil
+	}
+
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return err
+	}
+	defer keymaster.Wipe(vaultKey)
+
+	data, err := cache.LoadJournal(email, vaultKey)
+	var previousKey []byte
+	if errors.Is(err, envelope.ErrWrongKey) {
+		// Another device rotated the vault key while these writes waited, they are sealed with the old one
+		if previousKey, err = previousVaultKey(email); err == nil {
+			defer keymaster.Wipe(previousKey)
+			data, err = cache.LoadJournal(email, previousKey)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrJournalUnreadable, err)
+	}
+
+	var ops []PendingOp
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &ops); err != nil {
+			return fmt.Errorf("failed to decode journal: %w", err)
+		}
+	}
+	if previousKey != nil {
+		for i := range ops {
+			if err := resealOp(&ops[i], previousKey, vaultKey); err != nil {
+				return fmt.Errorf("%w: %w", ErrJournalUnreadable, err)
+			}
+		}
+	}
+
+	journal.email = email
+	journal.loaded = true
+	journal.ops = ops
+	if previousKey != nil {
+		log.Printf("Resealed %d offline changes with the rotated vault key", len(ops))
+		return saveJournalLocked()
+	}
+	return nil
+}
+
+// previousVaultKey opens the vault key kept in the cache from before a rotation
+func previousVaultKey(email string) ([]byte, error) {
+	protected, err := cache.LoadPreviousKey(email)
+	if err != nil {
+		return nil, err
+	}
+	masterKey, err := keymaster.Keys.Masterkey()
+	if err != nil {
+		return nil, err
+	}
+	defer keymaster.Wipe(masterKey)
+	return keymaster.Unprotect(protected, masterKey)
+}
+
+// resealOp reseals the content of a journaled write and of the server copy it holds with newKey
+func resealOp(op *PendingOp, oldKey, newKey []byte) error {
+	switch op.Kind {
+	case OpCreateItem:
+		var payload CreateItemPayload
+		if err := json.Unmarshal(op.Payload, &payload); err != nil {
+			return err
+		}
+		title, data, err := openItemPayload(oldKey, op.ItemID, op.TypeName, payload.Title, payload.Data)
+		if err != nil {
+			return err
+		}
+		if payload.Title, payload.Data, err = sealItem(newKey, op.ItemID, op.TypeName, title, data); err != nil {
+			return err
+		}
+		op.Payload, err = json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+	case OpUpdateItem:
+		var payload UpdateItemPayload
+		if err := json.Unmarshal(op.Payload, &payload); err != nil {
+			return err
+		}
+		title, data, err := openItemPayload(oldKey, op.ItemID, op.TypeName, payload.Title, payload.Data)
+		if err != nil {
+			return err
+		}
+		if payload.Title, payload.Data, err = sealItem(newKey, op.ItemID, op.TypeName, title, data); err != nil {
+			return err
+		}
+		op.Payload, err = json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+	case OpCreateCategory:
+		var payload CreateCategoryPayload
+		if err := json.Unmarshal(op.Payload, &payload); err != nil {
+			return err
+		}
+		name, err := resealBase64(payload.Category, oldKey, newKey, categoryBinding(0))
+		if err != nil {
+			return err
+		}
+		payload.Category = name
+		op.Payload, err = json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+	case OpUpdateCategory:
+		var payload UpdateCategoryPayload
+		if err := json.Unmarshal(op.Payload, &payload); err != nil {
+			return err
+		}
+		name, err := resealBase64(payload.CategoryName, oldKey, newKey, tempBinding(categoryBinding(op.CategoryID)))
+		if err != nil {
+			return err
+		}
+		payload.CategoryName = name
+		op.Payload, err = json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+	}
+
+	if op.Theirs != nil {
+		theirs, err := resealItem(oldKey, newKey, *op.Theirs)
+		if err != nil {
+			return err
+		}
+		op.Theirs.Title = theirs.Title
+		op.Theirs.Data = utils.BytToBa64(theirs.Data)
+	}
+	return nil
+}
+
+// resealBase64 opens a base64 ciphertext sealed for b with oldKey and seals it for b with newKey
+func resealBase64(encoded string, oldKey, newKey []byte, b envelope.Binding) (string, error) {
+	plain, _, err := openBase64Field(encoded, oldKey, b)
+	if err != nil {
+		return "", err
+	}
+	defer keymaster.Wipe(plain)
+	sealed, err := envelope.Seal(plain, newKey, b)
+	if err != nil {
+		return "", err
+	}
+	return utils.BytToBa64(sealed), nil
+}
+
+// saveJournalLocked writes the journal back to the cache
+func saveJournalLocked() error {
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return err
+	}
+	defer keymaster.Wipe(vaultKey)
+
+	var data []byte
+	if len(journal.ops) > 0 {
+		data, err = json.Marshal(journal.ops)
+		if err != nil {
+			return fmt.Errorf("failed to encode journal: %w", err)
+		}
+	}
+	return cache.SaveJournal(journal.email, vaultKey, data)
+}
+
+func newOpID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate journal ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// nextTempIDLocked returns a temporary ID not used by any pending op
+func nextTempIDLocked() uint {
+	next := tempIDBase
+	for _, op := range journal.ops {
+		if op.ItemID >= next {
+			next = op.ItemID + 1
+		}
+		if op.CategoryID >= next {
+			next = op.CategoryID + 1
+		}
+	}
+	return next
+}
+
+// enqueue adds op to the journal, merging it with earlier writes to the same item or category.
+// Creates are given a temporary ID, returned in the queued op.
+func enqueue(op PendingOp) (PendingOp, error) {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	if err := loadJournalLocked(); err != nil {
+		return op, err
+	}
+
+	id, err := newOpID()
+	if err != nil {
+		return op, err
+	}
+	op.ID = id
+	op.QueuedAt = time.Now().UTC()
+
+	switch op.Kind {
+	case OpCreateItem:
+		op.ItemID = nextTempIDLocked()
+
+	case OpCreateCategory:
+		op.CategoryID = nextTempIDLocked()
+
+	case OpUpdateItem, OpUpdateCategory:
+		// A second edit replaces the first but keeps the version it was based on. An edit that ran into a
+		// conflict stays one, so keeping the local version sends the newest edit.
+		for i := range journal.ops {
+			prev := &journal.ops[i]
+			if prev.Kind == op.Kind && prev.ItemID == op.ItemID && prev.CategoryID == op.CategoryID {
+				prev.Payload = op.Payload
+				prev.QueuedAt = op.QueuedAt
+				return op, saveJournalLocked()
+			}
+		}
+
+	case OpDeleteItem:
+		base := op.BaseModify
+		kept := journal.ops[:0]
+		for _, prev := range journal.ops {
+			if prev.ItemID == op.ItemID && prev.Conflict == "" && (prev.Kind == OpCreateItem || prev.Kind == OpUpdateItem) {
+				if prev.Kind == OpUpdateItem {
+					base = prev.BaseModify
+				}
+				continue
+			}
+			kept = append(kept, prev)
+		}
+		journal.ops = kept
+		// Deleting something that never reached the server leaves nothing to send
+		if isTempID(op.ItemID) {
+			return op, saveJournalLocked()
+		}
+		op.BaseModify = base
+
+	case OpDeleteCategory:
+		if isTempID(op.CategoryID) {
+			kept := journal.ops[:0]
+			for _, prev := range journal.ops {
+				if prev.CategoryID == op.CategoryID && (prev.Kind == OpCreateCategory || prev.Kind == OpUpdateCategory) {
+					continue
+				}
+				kept = append(kept, prev)
+			}
+			journal.ops = kept
+			return op, saveJournalLocked()
+		}
+	}
+
+	journal.ops = append(journal.ops, op)
+	return op, saveJournalLocked()
+}
+
+// conflictPending reports whether an edit of the item or category is waiting for the user to resolve a
+// conflict. Later edits are queued behind it, even while online, so they are merged into it.
+func conflictPending(kind OpKind, itemID, categoryID uint) bool {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	if err := loadJournalLocked(); err != nil {
+		return false
+	}
+	for _, op := range journal.ops {
+		if op.Conflict != "" && op.Kind == kind && op.ItemID == itemID && op.CategoryID == categoryID {
+			return true
+		}
+	}
+	return false
+}
+
+// cachedItem looks up an item as the server last sent it
+func cachedItem(itemID uint) *Item {
+	if item, ok := store.rawItem(itemID); ok && !store.isFromCache() {
+		return &item
+	}
+
+	resp, _, err := loadListCache()
+	if err != nil {
+		return nil
+	}
+	for _, item := range resp.Items {
+		if item.ItemID == itemID {
+			return &item
+		}
+	}
+	return nil
+}
+
+func queueCreateItem(payload *CreateItemPayload, title string) (*CreateItemResponse, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	op, err := enqueue(PendingOp{Kind: OpCreateItem, TypeName: payload.Type, Payload: body})
+	if err != nil {
+		log.Printf("Failed to queue CreateItem: %v", err)
+		return nil, err
+	}
+
+	log.Printf("CreateItem queued until the server is reachable: ItemID:%d", op.ItemID)
+	return &CreateItemResponse{
+		ItemID:   op.ItemID,
+		Title:    title,
+		CreateAt: time.Now().UTC(),
+		Message:  "Saved offline, will sync when the server is reachable",
+	}, nil
+}
+
+func queueUpdateItem(payload *UpdateItemPayload) (*UpdateItemResponse, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	op := PendingOp{Kind: OpUpdateItem, ItemID: payload.Item_id, Payload: body}
+	if item := cachedItem(payload.Item_id); item != nil {
+		op.BaseModify = item.DateModify
+		op.TypeName = item.TypeName
+	}
+	if _, err := enqueue(op); err != nil {
+		log.Printf("Failed to queue UpdateItem: %v", err)
+		return nil, err
+	}
+
+	log.Printf("UpdateItem queued until the server is reachable: ItemID:%d", payload.Item_id)
+	return &UpdateItemResponse{
+		ItemID:  payload.Item_id,
+		Message: "Saved offline, will sync when the server is reachable",
+	}, nil
+}
+
+func queueDeleteItem(itemID uint) (*DeleteItemResponse, error) {
+	op := PendingOp{Kind: OpDeleteItem, ItemID: itemID}
+	if item := cachedItem(itemID); item != nil {
+		op.BaseModify = item.DateModify
+		op.TypeName = item.TypeName
+	}
+	if _, err := enqueue(op); err != nil {
+		log.Printf("Failed to queue DeleteItem: %v", err)
+		return nil, err
+	}
+
+	log.Printf("DeleteItem queued until the server is reachable: ItemID:%d", itemID)
+	return &DeleteItemResponse{ItemID: itemID, Status: "queued"}, nil
+}
+
+func queueCreateCategory(payload *CreateCategoryPayload, categoryname string) (*CreateCategoryResponse, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := enqueue(PendingOp{Kind: OpCreateCategory, Payload: body}); err != nil {
+		log.Printf("Failed to queue CreateCategory: %v", err)
+		return nil, err
+	}
+
+	log.Printf("CreateCategory queued until the server is reachable")
+	return &CreateCategoryResponse{Category: categoryname, Status: "queued"}, nil
+}
+
+func queueUpdateCategory(payload *UpdateCategoryPayload) (*UpdateCategoryResponse, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	op := PendingOp{Kind: OpUpdateCategory, CategoryID: payload.Category_id, Payload: body}
+	if _, err := enqueue(op); err != nil {
+		log.Printf("Failed to queue UpdateCategory: %v", err)
+		return nil, err
+	}
+
+	log.Printf("UpdateCategory queued until the server is reachable: CategoryID:%d", payload.Category_id)
+	return &UpdateCategoryResponse{CategoryID: payload.Category_id, Status: "queued"}, nil
+}
+
+func queueDeleteCategory(categoryID uint) (*DeleteCategoryResponse, error) {
+	op := PendingOp{Kind: OpDeleteCategory, CategoryID: categoryID}
+	if _, err := enqueue(op); err != nil {
+		log.Printf("Failed to queue DeleteCategory: %v", err)
+		return nil, err
+	}
+
+	log.Printf("DeleteCategory queued until the server is reachable: CategoryID:%d", categoryID)
+	return &DeleteCategoryResponse{CategoryID: categoryID, Status: "queued"}, nil
+}
+
+// PendingCount returns how many writes are waiting to be synced, including conflicts
+func PendingCount() (int, error) {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	if err := loadJournalLocked(); err != nil {
+		return 0, err
+	}
+	return len(journal.ops), nil
+}
+
+// ConflictCount returns how many writes are held back waiting for the user to resolve them
+func ConflictCount() (int, error) {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	if err := loadJournalLocked(); err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, op := range journal.ops {
+		if op.Conflict != "" {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// applyJournal overlays the pending writes onto a cached list so offline edits show up
+func applyJournal(resp *GetListItemResponse) {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	if err := loadJournalLocked(); err != nil {
+		log.Printf("Failed to load journal: %v", err)
+		return
+	}
+
+	for _, op := range journal.ops {
+		if op.Conflict != "" {
+			continue
+		}
+
+		switch op.Kind {
+		case OpCreateItem:
+			var payload CreateItemPayload
+			if json.Unmarshal(op.Payload, &payload) != nil {
+				continue
+			}
+			resp.Items = append(resp.Items, Item{
+				ItemID:     op.ItemID,
+				Title:      payload.Title,
+				TypeName:   payload.Type,
+				Data:       utils.BytToBa64(payload.Data),
+				DateCreate: op.QueuedAt,
+				DateModify: op.QueuedAt,
+			})
+
+		case OpUpdateItem:
+			var payload UpdateItemPayload
+			if json.Unmarshal(op.Payload, &payload) != nil {
+				continue
+			}
+			for i := range resp.Items {
+				if resp.Items[i].ItemID == op.ItemID {
+					resp.Items[i].Title = payload.Title
+					resp.Items[i].Data = utils.BytToBa64(payload.Data)
+					resp.Items[i].CategoryID = payload.Category_id
+					resp.Items[i].DateModify = op.QueuedAt
+				}
+			}
+
+		case OpDeleteItem:
+			for i := range resp.Items {
+				if resp.Items[i].ItemID == op.ItemID {
+					resp.Items = append(resp.Items[:i], resp.Items[i+1:]...)
+					break
+				}
+			}
+
+		case OpCreateCategory:
+			var payload CreateCategoryPayload
+			if json.Unmarshal(op.Payload, &payload) != nil {
+				continue
+			}
+			resp.Categorys = append(resp.Categorys, Category{CategoryID: op.CategoryID, CategoryName: payload.Category})
+
+		case OpUpdateCategory:
+			var payload UpdateCategoryPayload
+			if json.Unmarshal(op.Payload, &payload) != nil {
+				continue
+			}
+			for i := range resp.Categorys {
+				if resp.Categorys[i].CategoryID == op.CategoryID {
+					resp.Categorys[i].CategoryName = payload.CategoryName
+				}
+			}
+
+		case OpDeleteCategory:
+			for i := range resp.Categorys {
+				if resp.Categorys[i].CategoryID == op.CategoryID {
+					resp.Categorys = append(resp.Categorys[:i], resp.Categorys[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+}
+
+// replayJournal sends the pending writes to the backend, holding back the ones that conflict
+// with the server copy in resp. It returns true if anything reached the server.
+func replayJournal(resp *GetListItemResponse) (bool, error) {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	if err := loadJournalLocked(); err != nil {
+		log.Printf("Failed to load journal: %v", err)
+		return false, err
+	}
+	if len(journal.ops) == 0 {
+		return false, nil
+	}
+
+	serverItems := make(map[uint]Item, len(resp.Items))
+	for _, item := range resp.Items {
+		serverItems[item.ItemID] = item
+	}
+	serverCategories := make(map[uint]Category, len(resp.Categorys))
+	for _, category := range resp.Categorys {
+		serverCategories[category.CategoryID] = category
+	}
+
+	// Sent ops leave the journal one by one, so it can be saved at any point in between
+	sent := false
+	for i := 0; i < len(journal.ops); {
+		op := &journal.ops[i]
+
+		// Writes that depend on an item or category that has not been created yet wait for it
+		if op.Conflict != "" || (isTempID(op.ItemID) && op.Kind != OpCreateItem) ||
+			(isTempID(op.CategoryID) && op.Kind != OpCreateCategory) {
+			i++
+			continue
+		}
+
+		if reason, theirs := checkConflict(*op, serverItems); reason != "" {
+			log.Printf("Sync conflict on item %d: %s", op.ItemID, reason)
+			op.Conflict = reason
+			op.Theirs = theirs
+			i++
+			continue
+		}
+
+		created := op.Kind == OpCreateItem
+		err := sendOp(op)
+		if errors.Is(err, client.ErrNetwork) {
+			// Lost the server again, keep this and everything after it for next time
+			log.Printf("Replay stopped, server unreachable: %v", err)
+			break
+		}
+		if err != nil {
+			log.Printf("Backend rejected queued %s: %v", op.Kind, err)
+			if op.Kind == OpCreateCategory {
+				// The category will not exist, so nothing may keep waiting for it
+				tempID := op.CategoryID
+				journal.ops = append(journal.ops[:i], journal.ops[i+1:]...)
+				dropCategoryLocked(tempID, i)
+				continue
+			}
+			op.Conflict = ReasonRejected
+			if theirs, ok := serverItems[op.ItemID]; ok && isItemOp(op.Kind) {
+				op.Theirs = &theirs
+			}
+			if theirs, ok := serverCategories[op.CategoryID]; ok && !isItemOp(op.Kind) {
+				op.TheirsCategory = &theirs
+			}
+			i++
+			continue
+		}
+
+		if created {
+			// Later edits of this item are checked against the version just created
+			serverItems[op.ItemID] = Item{ItemID: op.ItemID}
+		}
+		journal.ops = append(journal.ops[:i], journal.ops[i+1:]...)
+		sent = true
+	}
+
+	if err := saveJournalLocked(); err != nil {
+		log.Printf("Failed to save journal: %v", err)
+	}
+	return sent, nil
+}
+
+// checkConflict compares an item write with the server copy it was based on
+func checkConflict(op PendingOp, serverItems map[uint]Item) (ConflictReason, *Item) {
+	if op.Kind != OpUpdateItem && op.Kind != OpDeleteItem {
+		return "", nil
+	}
+
+	server, ok := serverItems[op.ItemID]
+	if !ok {
+		if op.Kind == OpDeleteItem {
+			return "", nil
+		}
+		return ReasonDeleted, nil
+	}
+	// Without a base version, as for items created offline, there is nothing to compare
+	if !op.BaseModify.IsZero() && !server.DateModify.Equal(op.BaseModify) {
+		return ReasonModified, &server
+	}
+	return "", nil
+}
+
+// sendOp sends one journaled write, op points into the journal. Once a create succeeds the
+// temporary ID is replaced with the real one in op and every later op, and op becomes the
+// update that saves the content. The journal is saved before that update is sent, so if it
+// fails the item is not created a second time.
+func sendOp(op *PendingOp) error {
+	ctx := context.Background()
+
+	switch op.Kind {
+	case OpCreateItem:
+		var payload CreateItemPayload
+		if err := json.Unmarshal(op.Payload, &payload); err != nil {
+			return err
+		}
+		title, data, err := openJournaledItem(op.ItemID, op.TypeName, payload.Title, payload.Data)
+		if err != nil {
+			return err
+		}
+		response, update, err := createPlaceholder(ctx, op.TypeName, title, data, nil)
+		if response == nil {
+			return err
+		}
+		remapItemIDLocked(op.ItemID, response.ItemID, op.TypeName)
+		op.ItemID = response.ItemID
+		log.Printf("Replayed CreateItem: ItemID:%d", response.ItemID)
+		if err != nil {
+			return err
+		}
+		op.Kind = OpUpdateItem
+		op.Created = true
+		op.Payload, _ = json.Marshal(update)
+		if err := saveJournalLocked(); err != nil {
+			log.Printf("Failed to save journal: %v", err)
+		}
+		if err := client.Backend.Do(ctx, http.MethodPost, "/updateItem", op.Payload, &UpdateItemResponse{}); err != nil {
+			return err
+		}
+
+	case OpUpdateItem:
+		if err := client.Backend.Do(ctx, http.MethodPost, "/updateItem", op.Payload, &UpdateItemResponse{}); err != nil {
+			return err
+		}
+		log.Printf("Replayed UpdateItem: ItemID:%d", op.ItemID)
+
+	case OpDeleteItem:
+		payload := &DeleteItemPayload{ItemID: op.ItemID}
+		if err := client.Backend.Do(ctx, http.MethodDelete, "/deleteItem", payload, &DeleteItemResponse{}); err != nil {
+			return err
+		}
+		log.Printf("Replayed DeleteItem: ItemID:%d", op.ItemID)
+
+	case OpCreateCategory:
+		var payload CreateCategoryPayload
+		if err := json.Unmarshal(op.Payload, &payload); err != nil {
+			return err
+		}
+		name, err := openJournaledCategory(payload.Category)
+		if err != nil {
+			return err
+		}
+		response, update, err := createCategoryPlaceholder(ctx, name)
+		if response == nil {
+			return err
+		}
+		remapCategoryIDLocked(op.CategoryID, response.CategoryID)
+		op.CategoryID = response.CategoryID
+		log.Printf("Replayed CreateCategory: CategoryID:%d", response.CategoryID)
+		if err != nil {
+			return err
+		}
+		op.Kind = OpUpdateCategory
+		op.Payload, _ = json.Marshal(update)
+		if err := saveJournalLocked(); err != nil {
+			log.Printf("Failed to save journal: %v", err)
+		}
+		if err := client.Backend.Do(ctx, http.MethodPost, "/updateCategory", op.Payload, &UpdateCategoryResponse{}); err != nil {
+			return err
+		}
+
+	case OpUpdateCategory:
+		if err := client.Backend.Do(ctx, http.MethodPost, "/updateCategory", op.Payload, &UpdateCategoryResponse{}); err != nil {
+			return err
+		}
+		log.Printf("Replayed UpdateCategory: CategoryID:%d", op.CategoryID)
+
+	case OpDeleteCategory:
+		payload := &DeleteCategoryPayload{CategoryID: op.CategoryID}
+		if err := client.Backend.Do(ctx, http.MethodDelete, "/deleteCategory", payload, &DeleteCategoryResponse{}); err != nil {
+			return err
+		}
+		log.Printf("Replayed DeleteCategory: CategoryID:%d", op.CategoryID)
+	}
+	return nil
+}
+
+// remapItemIDLocked points later ops at the real ID of an item created offline and
+// rebinds their content to it
+func remapItemIDLocked(tempID, realID uint, typeName string) {
+	for i := range journal.ops {
+		op := &journal.ops[i]
+		if op.ItemID != tempID || op.Kind == OpCreateItem {
+			continue
+		}
+		op.ItemID = realID
+		if op.Kind == OpUpdateItem {
+			var payload UpdateItemPayload
+			if json.Unmarshal(op.Payload, &payload) == nil {
+				payload.Item_id = realID
+				if err := rebindItemPayload(&payload, tempID, typeName); err != nil {
+					log.Printf("Failed to rebind queued UpdateItem: ItemID:%d: %v", realID, err)
+				}
+				op.Payload, _ = json.Marshal(payload)
+			}
+		}
+	}
+}
+
+// remapCategoryIDLocked points later ops at the real ID of a category created offline and rebinds
+// queued renames to it
+func remapCategoryIDLocked(tempID, realID uint) {
+	for i := range journal.ops {
+		op := &journal.ops[i]
+		switch {
+		case op.Kind == OpUpdateCategory && op.CategoryID == tempID:
+			op.CategoryID = realID
+			var payload UpdateCategoryPayload
+			if json.Unmarshal(op.Payload, &payload) == nil {
+				payload.Category_id = realID
+				name, err := rebindCategoryName(payload.CategoryName, tempID, realID)
+				if err != nil {
+					log.Printf("Failed to rebind queued UpdateCategory: CategoryID:%d: %v", realID, err)
+				} else {
+					payload.CategoryName = name
+				}
+				op.Payload, _ = json.Marshal(payload)
+			}
+		case op.Kind == OpDeleteCategory && op.CategoryID == tempID:
+			op.CategoryID = realID
+		case op.Kind == OpUpdateItem:
+			var payload UpdateItemPayload
+			if json.Unmarshal(op.Payload, &payload) == nil && payload.Category_id != nil && *payload.Category_id == tempID {
+				payload.Category_id = &realID
+				op.Payload, _ = json.Marshal(payload)
+			}
+		}
+	}
+}
+
+// dropCategoryLocked removes the ops from index from on that wait for a category created offline that could
+// not be created. Items queued to move into it stay where they are.
+func dropCategoryLocked(tempID uint, from int) {
+	kept := journal.ops[:from]
+	for _, op := range journal.ops[from:] {
+		if (op.Kind == OpUpdateCategory || op.Kind == OpDeleteCategory) && op.CategoryID == tempID {
+			log.Printf("Dropping queued %s of category that was not created", op.Kind)
+			continue
+		}
+		if op.Kind == OpUpdateItem {
+			var payload UpdateItemPayload
+			if json.Unmarshal(op.Payload, &payload) == nil && payload.Category_id != nil && *payload.Category_id == tempID {
+				payload.Category_id = nil
+				if cached := cachedItem(op.ItemID); cached != nil {
+					payload.Category_id = cached.CategoryID
+				}
+				op.Payload, _ = json.Marshal(payload)
+			}
+		}
+		kept = append(kept, op)
+	}
+	journal.ops = kept
+}
+
+// GetConflicts returns the writes held back by a conflict, decrypted for display
+func GetConflicts() ([]SyncConflict, error) {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	if err := loadJournalLocked(); err != nil {
+		return nil, err
+	}
+
+	conflicts := []SyncConflict{}
+	for _, op := range journal.ops {
+		if op.Conflict == "" {
+			continue
+		}
+
+		conflict := SyncConflict{
+			ID:         op.ID,
+			Kind:       op.Kind,
+			Reason:     op.Conflict,
+			ItemID:     op.ItemID,
+			CategoryID: op.CategoryID,
+			QueuedAt:   op.QueuedAt,
+		}
+		if mine := mineItem(op); mine != nil {
+			conflict.Mine = decryptForDisplay(*mine)
+		}
+		if op.Theirs != nil {
+			conflict.Theirs = decryptForDisplay(*op.Theirs)
+		}
+		if op.Kind == OpUpdateCategory {
+			var payload UpdateCategoryPayload
+			if json.Unmarshal(op.Payload, &payload) == nil {
+				conflict.MineName = categoryNameForDisplay(payload.CategoryName, tempBinding(categoryBinding(op.CategoryID)))
+			}
+		}
+		if op.TheirsCategory != nil {
+			conflict.TheirsName = categoryNameForDisplay(op.TheirsCategory.CategoryName, categoryBinding(op.CategoryID))
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts, nil
+}
+
+// mineItem rebuilds the local version of the item from a journaled write
+func mineItem(op PendingOp) *Item {
+	switch op.Kind {
+	case OpCreateItem:
+		var payload CreateItemPayload
+		if json.Unmarshal(op.Payload, &payload) != nil {
+			return nil
+		}
+		return &Item{ItemID: op.ItemID, Title: payload.Title, TypeName: payload.Type,
+			Data: utils.BytToBa64(payload.Data), DateCreate: op.QueuedAt, DateModify: op.QueuedAt}
+	case OpUpdateItem:
+		var payload UpdateItemPayload
+		if json.Unmarshal(op.Payload, &payload) != nil {
+			return nil
+		}
+		return &Item{ItemID: op.ItemID, CategoryID: payload.Category_id, Title: payload.Title, TypeName: op.TypeName,
+			Data: utils.BytToBa64(payload.Data), DateModify: op.QueuedAt}
+	}
+	return nil
+}
+
+func decryptForDisplay(item Item) *AfterItem {
+	items, err := DecryptItems(context.Background(), []Item{item}, true)
+	if err != nil {
+		return nil
+	}
+	return &items[0]
+}
+
+// categoryNameForDisplay opens a category name sealed for b, empty if it cannot be opened
+func categoryNameForDisplay(encoded string, b envelope.Binding) string {
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return ""
+	}
+	defer keymaster.Wipe(vaultKey)
+	name, _, err := openBase64Field(encoded, vaultKey, b)
+	if err != nil {
+		return ""
+	}
+	return string(name)
+}
+
+// ResolveConflict applies the user's choice to a held back write and removes it from the journal
+func ResolveConflict(id string, resolution Resolution) error {
+	if err := requireUnlocked(); err != nil {
+		return err
+	}
+
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+
+	if err := loadJournalLocked(); err != nil {
+		return err
+	}
+
+	index := -1
+	for i, op := range journal.ops {
+		if op.ID == id && op.Conflict != "" {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return ErrConflictNotFound
+	}
+	op := &journal.ops[index]
+
+	var err error
+	switch resolution {
+	case KeepTheirs:
+		// Nothing to send, the server copy stays as it is
+	case KeepMine:
+		err = keepMine(op)
+	case KeepBoth:
+		err = keepBoth(op)
+	default:
+		return fmt.Errorf("unknown resolution: %s", resolution)
+	}
+	if err != nil {
+		log.Printf("Failed to resolve conflict %s: %v", id, err)
+		// An item may have been created before the failure, op now saves its content
+		if saveErr := saveJournalLocked(); saveErr != nil {
+			log.Printf("Failed to save journal: %v", saveErr)
+		}
+		return err
+	}
+
+	resolved := *op
+	journal.ops = append(journal.ops[:index], journal.ops[index+1:]...)
+	// Dropping a create also drops the edits that were waiting for it
+	if resolved.Kind == OpCreateItem && resolution == KeepTheirs {
+		kept := journal.ops[:0]
+		for _, other := range journal.ops {
+			if other.ItemID != resolved.ItemID {
+				kept = append(kept, other)
+			}
+		}
+		journal.ops = kept
+	}
+
+	log.Printf("Resolved conflict %s: %s", id, resolution)
+	return saveJournalLocked()
+}
+
+// keepMine overwrites the server with the local version. op points into the journal.
+func keepMine(op *PendingOp) error {
+	if op.Kind == OpUpdateItem && op.Conflict == ReasonDeleted {
+		return recreateItem(op, "")
+	}
+	return sendOp(op)
+}
+
+// keepBoth leaves the server copy alone and saves the local version as a new item. op points into the journal.
+func keepBoth(op *PendingOp) error {
+	switch {
+	case op.Kind == OpDeleteItem || op.Kind == OpUpdateCategory || op.Kind == OpDeleteCategory:
+		return ErrCannotKeepBoth
+	case op.Kind == OpCreateItem || op.Created:
+		return sendOp(op)
+	default:
+		return recreateItem(op, " (conflicted copy)")
+	}
+}
+
+// recreateItem creates a new item from an offline edit, adding suffix to its title. Once the item exists
+// op becomes the update that saves its content, and the journal is saved before that update is sent, so a
+// failure leaves the new item to be filled in instead of creating another one.
+func recreateItem(op *PendingOp, suffix string) error {
+	var update UpdateItemPayload
+	if err := json.Unmarshal(op.Payload, &update); err != nil {
+		return err
+	}
+
+	title, data, err := openJournaledItem(op.ItemID, op.TypeName, update.Title, update.Data)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	response, pending, err := createPlaceholder(ctx, op.TypeName, append(title, suffix...), data, update.Category_id)
+	if err != nil {
+		if response != nil {
+			// Do not leave an empty placeholder behind
+			if deleteErr := client.Backend.Do(ctx, http.MethodDelete, "/deleteItem", &DeleteItemPayload{ItemID: response.ItemID}, nil); deleteErr != nil {
+				log.Printf("Failed to remove placeholder item %d: %v", response.ItemID, deleteErr)
+			}
+		}
+		return err
+	}
+
+	op.ItemID = response.ItemID
+	op.Created = true
+	op.BaseModify = time.Time{}
+	op.Theirs = nil
+	op.Conflict = ReasonRejected
+	op.Payload, _ = json.Marshal(pending)
+	if err := saveJournalLocked(); err != nil {
+		log.Printf("Failed to save journal: %v", err)
+	}
+	return client.Backend.Do(ctx, http.MethodPost, "/updateItem", op.Payload, &UpdateItemResponse{})
+}
+
+// openJournaledCategory opens the name of a category created offline
+func openJournaledCategory(encoded string) ([]byte, error) {
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return nil, err
+	}
+	defer keymaster.Wipe(vaultKey)
+	name, _, err := openBase64Field(encoded, vaultKey, categoryBinding(0))
+	return name, err
+}
+
+// openJournaledItem opens the title and data of a journaled item write sealed for id
+func openJournaledItem(id uint, typeName string, title string, data []byte) ([]byte, []byte, error) {
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer keymaster.Wipe(vaultKey)
+	return openItemPayload(vaultKey, id, typeName, title, data)
+}
@@ -0,0 +1,471 @@
+package service
+
+import (
+	"Modsec/clientside/CipherAlgo/envelope"
+	"Modsec/clientside/CipherAlgo/utils"
+	"Modsec/clientside/client"
+	"Modsec/clientside/config"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// journalBackend records the writes a replay sends. New items and categories get IDs from 50 and 40.
+type journalBackend struct {
+	mu             sync.Mutex
+	updates        []UpdateItemPayload
+	renames        []UpdateCategoryPayload
+	deletes        []uint
+	nextItem       uint
+	categoryID     uint // ID returned by /createCategory, 0 for a backend that does not say
+	updateStatus   int  // Status /updateItem refuses with, 0 to accept
+	categoryStatus int  // Status /updateCategory and /deleteCategory refuse with, 0 to accept
+}
+
+func newJournalBackend(t *testing.T) *journalBackend {
+	t.Helper()
+	backend := &journalBackend{nextItem: 50, categoryID: 40}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/createItem", func(w http.ResponseWriter, r *http.Request) {
+		backend.mu.Lock()
+		defer backend.mu.Unlock()
+		json.NewEncoder(w).Encode(CreateItemResponse{ItemID: backend.nextItem})
+		backend.nextItem++
+	})
+	mux.HandleFunc("/updateItem", func(w http.ResponseWriter, r *http.Request) {
+		var payload UpdateItemPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		backend.mu.Lock()
+		defer backend.mu.Unlock()
+		if backend.updateStatus != 0 {
+			http.Error(w, `{"message":"rejected"}`, backend.updateStatus)
+			return
+		}
+		backend.updates = append(backend.updates, payload)
+		json.NewEncoder(w).Encode(UpdateItemResponse{ItemID: payload.Item_id})
+	})
+	mux.HandleFunc("/deleteItem", func(w http.ResponseWriter, r *http.Request) {
+		var payload DeleteItemPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		backend.mu.Lock()
+		backend.deletes = append(backend.deletes, payload.ItemID)
+		backend.mu.Unlock()
+		json.NewEncoder(w).Encode(DeleteItemResponse{ItemID: payload.ItemID})
+	})
+	mux.HandleFunc("/createCategory", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(CreateCategoryResponse{CategoryID: backend.categoryID})
+	})
+	mux.HandleFunc("/updateCategory", func(w http.ResponseWriter, r *http.Request) {
+		var payload UpdateCategoryPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		backend.mu.Lock()
+		defer backend.mu.Unlock()
+		if backend.categoryStatus != 0 {
+			http.Error(w, `{"message":"rejected"}`, backend.categoryStatus)
+			return
+		}
+		backend.renames = append(backend.renames, payload)
+		json.NewEncoder(w).Encode(UpdateCategoryResponse{CategoryID: payload.Category_id})
+	})
+	mux.HandleFunc("/deleteCategory", func(w http.ResponseWriter, r *http.Request) {
+		var payload DeleteCategoryPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		backend.mu.Lock()
+		defer backend.mu.Unlock()
+		if backend.categoryStatus != 0 {
+			http.Error(w, `{"message":"rejected"}`, backend.categoryStatus)
+			return
+		}
+		json.NewEncoder(w).Encode(DeleteCategoryResponse{CategoryID: payload.CategoryID})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := config.Default()
+	cfg.BackendURL = srv.URL
+	if err := client.InitClient(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.InitClient(config.Default()) })
+	ClearStore()
+	t.Cleanup(ClearStore)
+	return backend
+}
+
+// queueItemUpdate journals an edit of item id sealed the way UpdateItemClient seals it
+func queueItemUpdate(t *testing.T, vaultKey []byte, id uint, categoryID *uint, title string, base time.Time) PendingOp {
+	t.Helper()
+	sealedTitle, data, err := sealItem(vaultKey, id, "login", []byte(title), []byte(`{"password":"pw"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	op, err := enqueue(PendingOp{Kind: OpUpdateItem, ItemID: id, TypeName: "login", BaseModify: base,
+		Payload: mustJSON(t, UpdateItemPayload{Item_id: id, Category_id: categoryID, Title: sealedTitle, Data: data})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return op
+}
+
+func journalOps() []PendingOp {
+	journal.mu.Lock()
+	defer journal.mu.Unlock()
+	return append([]PendingOp(nil), journal.ops...)
+}
+
+func TestEnqueueMerges(t *testing.T) {
+	vaultKey := bindingVault(t)
+	resetJournal(t)
+	first := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// A second edit replaces the first but keeps the version it was based on
+	queueItemUpdate(t, vaultKey, 7, nil, "One", first)
+	queueItemUpdate(t, vaultKey, 7, nil, "Two", first.Add(time.Hour))
+	ops := journalOps()
+	if len(ops) != 1 || !ops[0].BaseModify.Equal(first) {
+		t.Fatalf("ops after two edits = %+v", ops)
+	}
+	var payload UpdateItemPayload
+	json.Unmarshal(ops[0].Payload, &payload)
+	if title, _, _ := openItemPayload(vaultKey, 7, "login", payload.Title, payload.Data); string(title) != "Two" {
+		t.Fatalf("merged title = %q", title)
+	}
+
+	// Deleting drops the edit and is checked against the version the edit was based on
+	if _, err := enqueue(PendingOp{Kind: OpDeleteItem, ItemID: 7}); err != nil {
+		t.Fatal(err)
+	}
+	ops = journalOps()
+	if len(ops) != 1 || ops[0].Kind != OpDeleteItem || !ops[0].BaseModify.Equal(first) {
+		t.Fatalf("ops after delete = %+v", ops)
+	}
+
+	// An item or category created and deleted offline leaves nothing behind
+	created, err := enqueue(PendingOp{Kind: OpCreateItem, TypeName: "login"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	category, err := enqueue(PendingOp{Kind: OpCreateCategory})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isTempID(created.ItemID) || !isTempID(category.CategoryID) || created.ItemID == category.CategoryID {
+		t.Fatalf("temporary IDs %d and %d", created.ItemID, category.CategoryID)
+	}
+	queueItemUpdate(t, vaultKey, created.ItemID, nil, "Draft", time.Time{})
+	enqueue(PendingOp{Kind: OpDeleteItem, ItemID: created.ItemID})
+	enqueue(PendingOp{Kind: OpDeleteCategory, CategoryID: category.CategoryID})
+	if ops := journalOps(); len(ops) != 1 || ops[0].ItemID != 7 {
+		t.Fatalf("ops after offline create and delete = %+v", ops)
+	}
+}
+
+func TestReplayDetectsConflicts(t *testing.T) {
+	vaultKey := bindingVault(t)
+	resetJournal(t)
+	backend := newJournalBackend(t)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	queueItemUpdate(t, vaultKey, 1, nil, "Changed elsewhere", base)
+	queueItemUpdate(t, vaultKey, 2, nil, "Unchanged elsewhere", base)
+	queueItemUpdate(t, vaultKey, 3, nil, "Deleted elsewhere", base)
+	enqueue(PendingOp{Kind: OpDeleteItem, ItemID: 4, BaseModify: base})
+
+	server := &GetListItemResponse{Items: []Item{
+		{ItemID: 1, TypeName: "login", DateModify: base.Add(time.Hour)},
+		{ItemID: 2, TypeName: "login", DateModify: base},
+	}}
+	sent, err := replayJournal(server)
+	if err != nil || !sent {
+		t.Fatalf("replayJournal = %v, %v", sent, err)
+	}
+
+	if len(backend.updates) != 1 || backend.updates[0].Item_id != 2 {
+		t.Fatalf("updates sent = %+v", backend.updates)
+	}
+	// Deleting something already gone on the server is not a conflict
+	if len(backend.deletes) != 1 || backend.deletes[0] != 4 {
+		t.Fatalf("deletes sent = %v", backend.deletes)
+	}
+	reasons := map[uint]ConflictReason{}
+	for _, op := range journalOps() {
+		reasons[op.ItemID] = op.Conflict
+		if op.ItemID == 1 && (op.Theirs == nil || !op.Theirs.DateModify.Equal(base.Add(time.Hour))) {
+			t.Errorf("conflict on item 1 does not keep the server copy: %+v", op.Theirs)
+		}
+	}
+	if len(reasons) != 2 || reasons[1] != ReasonModified || reasons[3] != ReasonDeleted {
+		t.Fatalf("conflicts = %v", reasons)
+	}
+	if count, err := ConflictCount(); err != nil || count != 2 {
+		t.Fatalf("ConflictCount = %d, %v", count, err)
+	}
+}
+
+func TestReplayRemapsTempIDs(t *testing.T) {
+	vaultKey := bindingVault(t)
+	resetJournal(t)
+	backend := newJournalBackend(t)
+
+	name, _ := envelope.Seal([]byte("Travel"), vaultKey, categoryBinding(0))
+	category, _ := enqueue(PendingOp{Kind: OpCreateCategory,
+		Payload: mustJSON(t, CreateCategoryPayload{Category: utils.BytToBa64(name)})})
+	title, data, _ := sealItem(vaultKey, 0, "login", []byte("Airline"), []byte(`{"username":"me"}`))
+	item, _ := enqueue(PendingOp{Kind: OpCreateItem, TypeName: "login",
+		Payload: mustJSON(t, CreateItemPayload{Title: title, Type: "login", Data: data})})
+	queueItemUpdate(t, vaultKey, item.ItemID, &category.CategoryID, "Airline miles", time.Time{})
+	rename, _ := envelope.Seal([]byte("Trips"), vaultKey, categoryBinding(0))
+	enqueue(PendingOp{Kind: OpUpdateCategory, CategoryID: category.CategoryID,
+		Payload: mustJSON(t, UpdateCategoryPayload{Category_id: category.CategoryID, CategoryName: utils.BytToBa64(rename)})})
+
+	if sent, err := replayJournal(&GetListItemResponse{}); err != nil || !sent {
+		t.Fatalf("replayJournal = %v, %v", sent, err)
+	}
+	if ops := journalOps(); len(ops) != 0 {
+		t.Fatalf("ops left = %+v", ops)
+	}
+
+	// The created item's content, then the queued edit, both on the real IDs
+	if len(backend.updates) != 2 {
+		t.Fatalf("updates sent = %+v", backend.updates)
+	}
+	edit := backend.updates[1]
+	if edit.Item_id != 50 || edit.Category_id == nil || *edit.Category_id != 40 {
+		t.Fatalf("queued edit sent as %+v", edit)
+	}
+	if got, state, err := openBase64Field(edit.Title, vaultKey, itemBinding(50, "login", fieldTitle)); err != nil ||
+		state != sealBound || string(got) != "Airline miles" {
+		t.Fatalf("queued edit title = %q, %d, %v", got, state, err)
+	}
+	// The created category's name, then the queued rename, both bound to the real ID
+	if len(backend.renames) != 2 {
+		t.Fatalf("renames sent = %+v", backend.renames)
+	}
+	for i, want := range []string{"Travel", "Trips"} {
+		got, state, err := openBase64Field(backend.renames[i].CategoryName, vaultKey, categoryBinding(40))
+		if err != nil || state != sealBound || string(got) != want {
+			t.Fatalf("category name %d = %q, %d, %v", i, got, state, err)
+		}
+	}
+}
+
+func TestReplayDropsOpsOfFailedCategory(t *testing.T) {
+	vaultKey := bindingVault(t)
+	resetJournal(t)
+	backend := newJournalBackend(t)
+	backend.categoryID = 0
+
+	name, _ := envelope.Seal([]byte("Travel"), vaultKey, categoryBinding(0))
+	category, _ := enqueue(PendingOp{Kind: OpCreateCategory,
+		Payload: mustJSON(t, CreateCategoryPayload{Category: utils.BytToBa64(name)})})
+	enqueue(PendingOp{Kind: OpUpdateCategory, CategoryID: category.CategoryID,
+		Payload: mustJSON(t, UpdateCategoryPayload{Category_id: category.CategoryID})})
+	queueItemUpdate(t, vaultKey, 5, &category.CategoryID, "Airline", time.Time{})
+
+	if _, err := replayJournal(&GetListItemResponse{Items: []Item{{ItemID: 5, TypeName: "login"}}}); err != nil {
+		t.Fatal(err)
+	}
+	// Without an ID the category is given up, the rename goes with it and the item keeps its category
+	if ops := journalOps(); len(ops) != 0 {
+		t.Fatalf("ops left = %+v", ops)
+	}
+	if len(backend.renames) != 0 {
+		t.Fatalf("renames sent = %+v", backend.renames)
+	}
+	if len(backend.updates) != 1 || backend.updates[0].Category_id != nil {
+		t.Fatalf("updates sent = %+v", backend.updates)
+	}
+}
+
+func TestResolveConflict(t *testing.T) {
+	vaultKey := bindingVault(t)
+	resetJournal(t)
+	backend := newJournalBackend(t)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	queueItemUpdate(t, vaultKey, 1, nil, "Mine", base)
+	queueItemUpdate(t, vaultKey, 2, nil, "Mine too", base)
+	queueItemUpdate(t, vaultKey, 3, nil, "Copy", base)
+	enqueue(PendingOp{Kind: OpDeleteItem, ItemID: 4, BaseModify: base})
+	later := base.Add(time.Hour)
+	server := &GetListItemResponse{}
+	for id := uint(1); id <= 4; id++ {
+		server.Items = append(server.Items, Item{ItemID: id, TypeName: "login", DateModify: later})
+	}
+	replayJournal(server)
+
+	conflicts := map[uint]string{}
+	for _, op := range journalOps() {
+		conflicts[op.ItemID] = op.ID
+	}
+	if len(conflicts) != 4 {
+		t.Fatalf("conflicts = %v", conflicts)
+	}
+
+	if err := ResolveConflict("missing", KeepMine); !errors.Is(err, ErrConflictNotFound) {
+		t.Errorf("unknown conflict: %v", err)
+	}
+	if err := ResolveConflict(conflicts[4], KeepBoth); !errors.Is(err, ErrCannotKeepBoth) {
+		t.Errorf("keep both of a deletion: %v", err)
+	}
+
+	if err := ResolveConflict(conflicts[1], KeepTheirs); err != nil || len(backend.updates) != 0 {
+		t.Fatalf("keep theirs = %v, sent %+v", err, backend.updates)
+	}
+	if err := ResolveConflict(conflicts[2], KeepMine); err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.updates) != 1 || backend.updates[0].Item_id != 2 {
+		t.Fatalf("keep mine sent %+v", backend.updates)
+	}
+
+	// Keeping both saves the local version as a new item next to the server copy
+	if err := ResolveConflict(conflicts[3], KeepBoth); err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.updates) != 2 || backend.updates[1].Item_id != 50 {
+		t.Fatalf("keep both sent %+v", backend.updates)
+	}
+	title, _, err := openBase64Field(backend.updates[1].Title, vaultKey, itemBinding(50, "login", fieldTitle))
+	if err != nil || string(title) != "Copy (conflicted copy)" {
+		t.Fatalf("copy title = %q, %v", title, err)
+	}
+
+	if ops := journalOps(); len(ops) != 1 || ops[0].ItemID != 4 {
+		t.Fatalf("ops left = %+v", ops)
+	}
+}
+
+func TestReplayKeepsRejectedCategoryOps(t *testing.T) {
+	vaultKey := bindingVault(t)
+	resetJournal(t)
+	backend := newJournalBackend(t)
+	backend.categoryStatus = http.StatusBadRequest
+
+	rename, _ := envelope.Seal([]byte("Trips"), vaultKey, categoryBinding(8))
+	enqueue(PendingOp{Kind: OpUpdateCategory, CategoryID: 8,
+		Payload: mustJSON(t, UpdateCategoryPayload{Category_id: 8, CategoryName: utils.BytToBa64(rename)})})
+	enqueue(PendingOp{Kind: OpDeleteCategory, CategoryID: 9})
+	current, _ := envelope.Seal([]byte("Travel"), vaultKey, categoryBinding(8))
+	server := &GetListItemResponse{Categorys: []Category{{CategoryID: 8, CategoryName: utils.BytToBa64(current)}}}
+	if _, err := replayJournal(server); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both stay in the journal for the user to decide instead of disappearing
+	if count, err := ConflictCount(); err != nil || count != 2 {
+		t.Fatalf("ConflictCount = %d, %v", count, err)
+	}
+	conflicts, err := GetConflicts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byCategory := map[uint]SyncConflict{}
+	for _, c := range conflicts {
+		byCategory[c.CategoryID] = c
+	}
+	if c := byCategory[8]; c.Reason != ReasonRejected || c.MineName != "Trips" || c.TheirsName != "Travel" {
+		t.Fatalf("rename conflict = %+v", c)
+	}
+	if c := byCategory[9]; c.Kind != OpDeleteCategory || c.Reason != ReasonRejected {
+		t.Fatalf("delete conflict = %+v", c)
+	}
+	if err := ResolveConflict(byCategory[8].ID, KeepBoth); !errors.Is(err, ErrCannotKeepBoth) {
+		t.Errorf("keep both of a rename: %v", err)
+	}
+
+	backend.categoryStatus = 0
+	if err := ResolveConflict(byCategory[8].ID, KeepMine); err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.renames) != 1 || backend.renames[0].Category_id != 8 {
+		t.Fatalf("keep mine sent %+v", backend.renames)
+	}
+	if err := ResolveConflict(byCategory[9].ID, KeepTheirs); err != nil {
+		t.Fatal(err)
+	}
+	if ops := journalOps(); len(ops) != 0 {
+		t.Fatalf("ops left = %+v", ops)
+	}
+}
+
+func TestResolveCreatesItemOnce(t *testing.T) {
+	vaultKey := bindingVault(t)
+	resetJournal(t)
+	backend := newJournalBackend(t)
+	backend.updateStatus = http.StatusBadRequest
+
+	title, data, _ := sealItem(vaultKey, 0, "login", []byte("Airline"), []byte(`{"username":"me"}`))
+	enqueue(PendingOp{Kind: OpCreateItem, TypeName: "login",
+		Payload: mustJSON(t, CreateItemPayload{Title: title, Type: "login", Data: data})})
+	if _, err := replayJournal(&GetListItemResponse{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The item exists but its content was refused, the journal on disk already knows the real ID
+	journal.mu.Lock()
+	journal.loaded = false
+	err := loadJournalLocked()
+	journal.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := journalOps()
+	if len(ops) != 1 || ops[0].ItemID != 50 || ops[0].Kind != OpUpdateItem || ops[0].Conflict != ReasonRejected {
+		t.Fatalf("journal after refused content = %+v", ops)
+	}
+
+	// Failing again keeps the conflict on the same item, succeeding fills it in
+	if err := ResolveConflict(ops[0].ID, KeepBoth); err == nil {
+		t.Fatal("refused content resolved")
+	}
+	backend.updateStatus = 0
+	if err := ResolveConflict(ops[0].ID, KeepBoth); err != nil {
+		t.Fatal(err)
+	}
+	if backend.nextItem != 51 {
+		t.Fatalf("%d items created", backend.nextItem-50)
+	}
+	if len(backend.updates) != 1 || backend.updates[0].Item_id != 50 {
+		t.Fatalf("updates sent = %+v", backend.updates)
+	}
+	got, _, err := openBase64Field(backend.updates[0].Title, vaultKey, itemBinding(50, "login", fieldTitle))
+	if err != nil || string(got) != "Airline" {
+		t.Fatalf("title = %q, %v", got, err)
+	}
+}
+
+func TestEditMergesIntoConflict(t *testing.T) {
+	vaultKey := bindingVault(t)
+	resetJournal(t)
+	backend := newJournalBackend(t)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	queueItemUpdate(t, vaultKey, 1, nil, "Older", base)
+	replayJournal(&GetListItemResponse{Items: []Item{{ItemID: 1, TypeName: "login", DateModify: base.Add(time.Hour)}}})
+	if !conflictPending(OpUpdateItem, 1, 0) {
+		t.Fatal("conflict not found")
+	}
+
+	// The newer edit is what keeping the local version sends
+	queueItemUpdate(t, vaultKey, 1, nil, "Newer", base.Add(2*time.Hour))
+	ops := journalOps()
+	if len(ops) != 1 || ops[0].Conflict != ReasonModified || !ops[0].BaseModify.Equal(base) || ops[0].Theirs == nil {
+		t.Fatalf("ops after edit = %+v", ops)
+	}
+	if err := ResolveConflict(ops[0].ID, KeepMine); err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.updates) != 1 {
+		t.Fatalf("updates sent = %+v", backend.updates)
+	}
+	got, _, err := openBase64Field(backend.updates[0].Title, vaultKey, itemBinding(1, "login", fieldTitle))
+	if err != nil || string(got) != "Newer" {
+		t.Fatalf("title = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"Modsec/clientside/CipherAlgo/keymaster"
+	"Modsec/clientside/cache"
+	"Modsec/clientside/client"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// offlineState tracks whether the vault is being served from the local cache
+var offlineState struct {
+	mu      sync.RWMutex
+	offline bool
+}
+
+// IsOffline reports whether the last item list came from the local cache. Writes are journaled while it is true.
+func IsOffline() bool {
+	offlineState.mu.RLock()
+	defer offlineState.mu.RUnlock()
+	return offlineState.offline
+}
+
+func setOffline(offline bool) {
+	offlineState.mu.Lock()
+	defer offlineState.mu.Unlock()
+	if offline != offlineState.offline {
+		log.Printf("Offline mode: %v", offline)
+	}
+	offlineState.offline = offline
+}
+
+// requireOnline blocks actions that cannot be queued while the vault is served from the cache
+func requireOnline() error {
+	if IsOffline() {
+		log.Printf("Rejected request: vault is offline")
+		return client.ErrOffline
+	}
+	return nil
+}
+
+// saveListCache stores the still-encrypted item list for offline use
+func saveListCache(resp *GetListItemResponse) {
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return
+	}
+	defer keymaster.Wipe(vaultKey)
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("Failed to encode vault cache: %v", err)
+		return
+	}
+	if err := cache.SaveVault(keymaster.Keys.Email(), vaultKey, data); err != nil {
+		log.Printf("Failed to save vault cache: %v", err)
+	}
+}
+
+// loadListCache returns the item list saved by saveListCache and when it was saved
+func loadListCache() (*GetListItemResponse, time.Time, error) {
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer keymaster.Wipe(vaultKey)
+
+	data, savedAt, err := cache.LoadVault(keymaster.Keys.Email(), vaultKey)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var resp GetListItemResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, time.Time{}, err
+	}
+	return &resp, savedAt, nil
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"Modsec/clientside/CipherAlgo/keymaster"
+	"Modsec/clientside/config"
+	"Modsec/clientside/schema"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoPasswordHistory is returned for items that cannot keep a password history or an entry that does not exist
+var ErrNoPasswordHistory = errors.New("no such previous password")
+
+// GetPasswordHistory returns the previous passwords of a website item, newest first
+func GetPasswordHistory(ctx context.Context, itemID uint) (schema.PasswordHistory, error) {
+	item, err := GetItemDetail(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.Data == nil || item.Data.Website == nil {
+		return nil, fmt.Errorf("%w: item %d is not a website", ErrNoPasswordHistory, itemID)
+	}
+	if item.Data.Website.PasswordHistory == nil {
+		return schema.PasswordHistory{}, nil
+	}
+	return item.Data.Website.PasswordHistory, nil
+}
+
+// RestorePassword makes entry index of the password history the current password again.
+// The password it replaces goes into the history like any other change.
+func RestorePassword(ctx context.Context, itemID uint, index int) (*UpdateItemResponse, error) {
+	item, err := GetItemDetail(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.Data == nil || item.Data.Website == nil {
+		return nil, fmt.Errorf("%w: item %d is not a website", ErrNoPasswordHistory, itemID)
+	}
+	website := item.Data.Website
+	if index < 0 || index >= len(website.PasswordHistory) {
+		return nil, fmt.Errorf("%w: item %d has no entry %d", ErrNoPasswordHistory, itemID, index)
+	}
+
+	website.Password = website.PasswordHistory[index].Password
+	return UpdateItemClient(itemID, item.CategoryID, ItemInput{Title: item.Title, Fields: *item.Data})
+}
+
+// carryPasswordHistory replaces the history in an updated website item with the stored one, adding the
+// stored password if the update changes it. The client never edits the history directly.
+func carryPasswordHistory(itemID uint, data schema.Data, now time.Time) error {
+	website, ok := data.(*schema.Website)
+	if !ok {
+		return nil
+	}
+
+	var previous *schema.Website
+	if raw, ok := store.rawItem(itemID); ok {
+		vaultKey, err := keymaster.Keys.Vaultkey()
+		if err != nil {
+			return err
+		}
+		fields := openItemData(vaultKey, raw, openField)
+		keymaster.Wipe(vaultKey)
+		if fields != nil {
+			previous = fields.Website
+		}
+	}
+	if previous == nil {
+		// Nothing stored to compare with, keep whatever history came with the item
+		return nil
+	}
+
+	limit := config.GetPreferences().PasswordHistoryLimit
+	website.PasswordHistory = previous.PasswordHistory.Record(previous.Password, website.Password, now, limit)
+	return nil
+}
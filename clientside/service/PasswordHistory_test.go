@@ -0,0 +1,43 @@
+package service
+
+import (
+	"Modsec/clientside/schema"
+	"testing"
+	"time"
+)
+
+func TestCarryPasswordHistory(t *testing.T) {
+	items := testVault(t, 1)
+	store.mu.Lock()
+	store.resetLocked("bench@example.com")
+	store.raw[items[0].ItemID] = items[0]
+	store.mu.Unlock()
+	t.Cleanup(ClearStore)
+
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	// The client cannot rewrite the history, and a new password records the stored one
+	updated := &schema.Website{Password: "new password", URL: "https://example.com",
+		PasswordHistory: schema.PasswordHistory{{Password: "forged", Replaced: now}}}
+	if err := carryPasswordHistory(items[0].ItemID, updated, now); err != nil {
+		t.Fatal(err)
+	}
+	if len(updated.PasswordHistory) != 1 || updated.PasswordHistory[0].Password != "correct horse battery staple" ||
+		!updated.PasswordHistory[0].Replaced.Equal(now) {
+		t.Fatalf("history = %+v", updated.PasswordHistory)
+	}
+
+	// Saving the same password leaves the history alone
+	same := &schema.Website{Password: "correct horse battery staple", URL: "https://example.com"}
+	if err := carryPasswordHistory(items[0].ItemID, same, now); err != nil {
+		t.Fatal(err)
+	}
+	if len(same.PasswordHistory) != 0 {
+		t.Fatalf("unchanged password recorded %+v", same.PasswordHistory)
+	}
+
+	// Items of other types are not touched
+	if err := carryPasswordHistory(items[0].ItemID, &schema.Memo{Content: "x"}, now); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,628 @@
+package service
+
+// Vault key rotation. A new vault key is generated and every item, category name and attachment record is
+// resealed with it on the client. Attachment files are encrypted again with new file keys, so the old wrapped
+// keys do not open them anymore. The resealed records and files are staged on the server and swapped in
+// together with the new wrapped keys by one commit, so the vault is never half rotated. What was staged is
+// remembered in the cache file, so a rotation that failed halfway resumes where it stopped.
+
+import (
+	"Modsec/clientside/CipherAlgo/envelope"
+	"Modsec/clientside/CipherAlgo/keymaster"
+	"Modsec/clientside/CipherAlgo/utils"
+	"Modsec/clientside/attachment"
+	"Modsec/clientside/auth"
+	"Modsec/clientside/cache"
+	"Modsec/clientside/client"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrRotationPending is returned when offline writes or sync conflicts would be lost by a rotation
+	ErrRotationPending = errors.New("sync pending offline changes before rotating the vault key")
+	// ErrRotationStale is returned when the vault kept changing while a rotation tried to commit
+	ErrRotationStale = errors.New("the vault changed during the key rotation, try again")
+	// ErrRotationUnsupported is returned when the backend cannot rotate the vault key
+	ErrRotationUnsupported = errors.New("the server does not support vault key rotation")
+)
+
+// rotationBatchSize is how many records are staged per request
+const rotationBatchSize = 100
+
+// rotationCommitAttempts is how often a commit is retried after the vault changed underneath it
+const rotationCommitAttempts = 3
+
+type RotationBeginResponse struct {
+	RotationID string `json:"rotation_id"`
+}
+
+type RotationItem struct {
+	ItemID     uint      `json:"item_id"`
+	Title      string    `json:"title"`
+	Data       []byte    `json:"data"`
+	DateModify time.Time `json:"date_modify"`
+}
+
+type RotationCategory struct {
+	CategoryID   uint   `json:"category_id"`
+	CategoryName string `json:"category_name"`
+}
+
+type RotationAttachment struct {
+	AttachmentID uint   `json:"attachment_id"`
+	Name         string `json:"name"`
+	Key          string `json:"key"`
+}
+
+type RotationBatchPayload struct {
+	RotationID  string               `json:"rotation_id"`
+	Items       []RotationItem       `json:"items"`
+	Categories  []RotationCategory   `json:"categories"`
+	Attachments []RotationAttachment `json:"attachments"`
+}
+
+type RotationCommitPayload struct {
+	RotationID           string `json:"rotation_id"`
+	ProtectedVaultKey    string `json:"protected_vault_key"`
+	EncryptedRecoveryKey string `json:"encrypted_recoverykey"`
+}
+
+// RotationProgress is reported while records are resealed and staged
+type RotationProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// rotationState is what has been staged so far. Items are staged as of their DateModify, categories and
+// attachments as of the ciphertext they had, so records that changed since are staged again. An attachment
+// counts as staged once its file and its record are.
+type rotationState struct {
+	RotationID  string             `json:"rotation_id"`
+	Key         []byte             `json:"key"`
+	Items       map[uint]time.Time `json:"items"`
+	Categories  map[uint]string    `json:"categories"`
+	Attachments map[uint]string    `json:"attachments"`
+}
+
+// rotationMu keeps two rotations from running at once
+var rotationMu sync.Mutex
+
+// RotationInProgress reports whether a rotation of the logged-in user stopped before it was committed
+func RotationInProgress() bool {
+	state, err := loadRotation()
+	if state != nil {
+		keymaster.Wipe(state.Key)
+	}
+	return err == nil && state != nil
+}
+
+// RotateVaultKey replaces the vault key and reseals the vault with it, resuming a rotation that stopped
+// halfway. The recovery key is wrapped again under a new seed phrase, which is returned; the old seed
+// phrase no longer recovers the vault. progress may be nil.
+func RotateVaultKey(ctx context.Context, progress func(RotationProgress)) (string, error) {
+	if err := requireUnlocked(); err != nil {
+		return "", err
+	}
+	if err := requireOnline(); err != nil {
+		return "", err
+	}
+	pending, err := PendingCount()
+	if err != nil {
+		return "", err
+	}
+	if pending > 0 {
+		return "", ErrRotationPending
+	}
+
+	rotationMu.Lock()
+	defer rotationMu.Unlock()
+
+	state, err := loadRotation()
+	if err != nil {
+		log.Printf("Failed to load rotation, starting over: %v", err)
+	}
+	if state == nil {
+		if state, err = beginRotation(ctx); err != nil {
+			return "", err
+		}
+	} else {
+		log.Printf("Resuming vault key rotation %s", state.RotationID)
+	}
+	defer func() {
+		if state != nil {
+			keymaster.Wipe(state.Key)
+		}
+	}()
+
+	for attempt := 1; ; attempt++ {
+		if _, err := SyncVault(ctx); err != nil {
+			return "", err
+		}
+		if rotationCommitted(state.Key) {
+			// An earlier commit went through but its answer never arrived
+			log.Printf("Rotation %s was already committed", state.RotationID)
+			return finishCommitted(ctx, state)
+		}
+		if err := stageRotation(ctx, state, progress); err != nil {
+			return "", err
+		}
+		seedPhrase, err := commitRotation(ctx, state)
+		if err == nil {
+			return seedPhrase, nil
+		}
+
+		var apiErr *client.APIError
+		switch {
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+			// The server dropped the staged records, start again with a new key
+			log.Printf("Rotation %s is unknown to the server, starting over", state.RotationID)
+			keymaster.Wipe(state.Key)
+			if state, err = beginRotation(ctx); err != nil {
+				return "", err
+			}
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict:
+			log.Printf("Vault changed during rotation %s, staging the changes", state.RotationID)
+			if attempt >= rotationCommitAttempts {
+				return "", ErrRotationStale
+			}
+		default:
+			log.Printf("Vault key rotation failed: %v", err)
+			return "", err
+		}
+	}
+}
+
+// beginRotation generates the new vault key and opens a rotation on the server
+func beginRotation(ctx context.Context) (*rotationState, error) {
+	key, err := utils.GenerateRandomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &RotationBeginResponse{}
+	err = client.Backend.Do(ctx, http.MethodPost, "/rotation/begin", nil, response)
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound && apiErr.Code == "" {
+		keymaster.Wipe(key)
+		log.Printf("Backend has no /rotation/begin")
+		return nil, ErrRotationUnsupported
+	}
+	if err != nil {
+		keymaster.Wipe(key)
+		log.Printf("Rotation begin failed: %v", err)
+		return nil, err
+	}
+
+	state := &rotationState{
+		RotationID:  response.RotationID,
+		Key:         key,
+		Items:       map[uint]time.Time{},
+		Categories:  map[uint]string{},
+		Attachments: map[uint]string{},
+	}
+	if err := saveRotation(state); err != nil {
+		keymaster.Wipe(key)
+		return nil, err
+	}
+	log.Printf("Started vault key rotation %s", state.RotationID)
+	return state, nil
+}
+
+// stageRotation reseals every record that is not staged as it is now and sends it in batches. The state is
+// saved after every batch. The store has to be synced first.
+func stageRotation(ctx context.Context, state *rotationState, progress func(RotationProgress)) error {
+	if IsOffline() {
+		return client.ErrOffline
+	}
+	attachments, err := fetchAllAttachments(ctx)
+	if err != nil {
+		return err
+	}
+
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return err
+	}
+	defer keymaster.Wipe(vaultKey)
+
+	raw := store.rawResponse()
+	var items []Item
+	for _, item := range raw.Items {
+		if staged, ok := state.Items[item.ItemID]; !ok || !staged.Equal(item.DateModify) {
+			items = append(items, item)
+		}
+	}
+	var categories []Category
+	for _, category := range raw.Categorys {
+		if staged, ok := state.Categories[category.CategoryID]; !ok || staged != category.CategoryName {
+			categories = append(categories, category)
+		}
+	}
+	var records []AttachmentRecord
+	for _, record := range attachments {
+		if staged, ok := state.Attachments[record.AttachmentID]; !ok || staged != record.Key {
+			records = append(records, record)
+		}
+	}
+
+	report := RotationProgress{Total: len(raw.Items) + len(raw.Categorys) + len(attachments)}
+	report.Done = report.Total - len(items) - len(categories) - len(records)
+	if progress != nil {
+		progress(report)
+	}
+
+	batch := &RotationBatchPayload{RotationID: state.RotationID}
+	flush := func() error {
+		size := len(batch.Items) + len(batch.Categories) + len(batch.Attachments)
+		if size == 0 {
+			return nil
+		}
+		if err := client.Backend.Do(ctx, http.MethodPost, "/rotation/batch", batch, nil); err != nil {
+			log.Printf("Rotation batch failed: %v", err)
+			return err
+		}
+		for _, item := range batch.Items {
+			state.Items[item.ItemID] = item.DateModify
+		}
+		for _, category := range batch.Categories {
+			state.Categories[category.CategoryID] = raw.categoryName(category.CategoryID)
+		}
+		for _, record := range batch.Attachments {
+			state.Attachments[record.AttachmentID] = attachmentKey(attachments, record.AttachmentID)
+		}
+		if err := saveRotation(state); err != nil {
+			return err
+		}
+		batch = &RotationBatchPayload{RotationID: state.RotationID}
+		report.Done += size
+		if progress != nil {
+			progress(report)
+		}
+		return nil
+	}
+	add := func() error {
+		if len(batch.Items)+len(batch.Categories)+len(batch.Attachments) >= rotationBatchSize {
+			return flush()
+		}
+		return nil
+	}
+
+	for _, item := range items {
+		resealed, err := resealItem(vaultKey, state.Key, item)
+		if err != nil {
+			return err
+		}
+		batch.Items = append(batch.Items, *resealed)
+		if err := add(); err != nil {
+			return err
+		}
+	}
+	for _, category := range categories {
+		resealed, err := resealCategory(vaultKey, state.Key, category)
+		if err != nil {
+			return err
+		}
+		batch.Categories = append(batch.Categories, *resealed)
+		if err := add(); err != nil {
+			return err
+		}
+	}
+	for _, record := range records {
+		resealed, err := resealAttachment(ctx, state.RotationID, vaultKey, state.Key, record)
+		if err != nil {
+			return err
+		}
+		batch.Attachments = append(batch.Attachments, *resealed)
+		if err := add(); err != nil {
+			return err
+		}
+	}
+	return flush()
+}
+
+// commitRotation swaps in the staged records and the new wrapped keys, then switches to the new key
+func commitRotation(ctx context.Context, state *rotationState) (string, error) {
+	masterKey, err := keymaster.Keys.Masterkey()
+	if err != nil {
+		return "", err
+	}
+	defer keymaster.Wipe(masterKey)
+
+	// Everything staged is bound to its ID, so the new key is wrapped as a migrated vault
+	protected, err := keymaster.Protect(state.Key, masterKey, true)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt vault key: %v", err)
+	}
+	seedPhrase := utils.RandomSeedPhrase()
+	recoveryKey, err := auth.WrapRecoveryKey(state.Key, seedPhrase)
+	if err != nil {
+		return "", err
+	}
+
+	payload := &RotationCommitPayload{
+		RotationID:           state.RotationID,
+		ProtectedVaultKey:    utils.BytToBa64(protected),
+		EncryptedRecoveryKey: recoveryKey,
+	}
+	if err := client.Backend.Do(ctx, http.MethodPost, "/rotation/commit", payload, nil); err != nil {
+		return "", err
+	}
+	adoptRotation(ctx, state, masterKey, protected)
+	return seedPhrase, nil
+}
+
+// rotationCommitted reports whether the server already holds the vault sealed with key
+func rotationCommitted(key []byte) bool {
+	id := envelope.KeyID(key)
+	for _, item := range store.rawResponse().Items {
+		ciphertext, err := utils.Ba64ToByt(item.Title)
+		if err != nil {
+			continue
+		}
+		if header, err := envelope.ParseHeader(ciphertext); err == nil {
+			return header.KeyID == id
+		}
+	}
+	return false
+}
+
+// finishCommitted switches to the key of a rotation the server committed without this client hearing back.
+// The seed phrase of that commit was never shown, so recovery is set up again with a new one.
+func finishCommitted(ctx context.Context, state *rotationState) (string, error) {
+	masterKey, err := keymaster.Keys.Masterkey()
+	if err != nil {
+		return "", err
+	}
+	defer keymaster.Wipe(masterKey)
+
+	protected, err := keymaster.Protect(state.Key, masterKey, true)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt vault key: %v", err)
+	}
+	adoptRotation(ctx, state, masterKey, protected)
+	return auth.RecoverySetup(keymaster.Keys.Email())
+}
+
+// adoptRotation makes the key of a committed rotation the vault key of this session and of the offline copy
+func adoptRotation(ctx context.Context, state *rotationState, masterKey, protected []byte) {
+	email := keymaster.Keys.Email()
+	keymaster.Keys.Rekey(masterKey, state.Key, protected)
+	if err := cache.SaveProtectedKey(email, protected); err != nil {
+		log.Printf("Failed to cache the new vault key: %v", err)
+	}
+	if err := cache.ClearRotation(email); err != nil {
+		log.Printf("Failed to clear rotation: %v", err)
+	}
+	// Everything was resealed bound to its ID
+	if err := setStrictBinding(); err != nil {
+		log.Printf("Failed to record binding migration: %v", err)
+	}
+	log.Printf("Vault key rotated, rotation %s committed", state.RotationID)
+
+	// The store and the offline copy still hold ciphertext of the old key
+	ClearStore()
+	if _, err := SyncVault(ctx); err != nil {
+		log.Printf("Sync after rotation failed: %v", err)
+	}
+}
+
+// resealItem opens an item with the current vault key and seals it with the new one
+func resealItem(vaultKey, newKey []byte, item Item) (*RotationItem, error) {
+	title, _, err := openBase64With(openForRebind, item.Title, vaultKey, itemBinding(item.ItemID, item.TypeName, fieldTitle))
+	if err != nil {
+		return nil, fmt.Errorf("cannot rotate item %d, title does not open: %w", item.ItemID, err)
+	}
+	var data []byte
+	if item.Data != "" {
+		if data, _, err = openBase64With(openForRebind, item.Data, vaultKey, itemBinding(item.ItemID, item.TypeName, fieldData)); err != nil {
+			return nil, fmt.Errorf("cannot rotate item %d, data does not open: %w", item.ItemID, err)
+		}
+	}
+
+	resealed := &RotationItem{ItemID: item.ItemID, DateModify: item.DateModify}
+	resealed.Title, resealed.Data, err = sealItem(newKey, item.ItemID, item.TypeName, title, data)
+	return resealed, err
+}
+
+// resealCategory reseals a category name with the new vault key. Names that were never encrypted are
+// encrypted now.
+func resealCategory(vaultKey, newKey []byte, category Category) (*RotationCategory, error) {
+	name := []byte(category.CategoryName)
+	if isSealedField(category.CategoryName) {
+		opened, _, err := openBase64With(openForRebind, category.CategoryName, vaultKey, categoryBinding(category.CategoryID))
+		if err != nil {
+			return nil, fmt.Errorf("cannot rotate category %d: %w", category.CategoryID, err)
+		}
+		name = opened
+	}
+	sealed, err := envelope.Seal(name, newKey, categoryBinding(category.CategoryID))
+	if err != nil {
+		return nil, err
+	}
+	return &RotationCategory{CategoryID: category.CategoryID, CategoryName: utils.BytToBa64(sealed)}, nil
+}
+
+// resealAttachment encrypts the file of an attachment again with a new file key and stages it, then seals
+// the name and the new file key with the new vault key
+func resealAttachment(ctx context.Context, rotationID string, vaultKey, newKey []byte, record AttachmentRecord) (*RotationAttachment, error) {
+	name, err := openAttachmentRecord(vaultKey, record, record.Name, fieldAttachmentName)
+	if err != nil {
+		return nil, err
+	}
+	defer keymaster.Wipe(name)
+	oldFileKey, err := openAttachmentRecord(vaultKey, record, record.Key, fieldAttachmentKey)
+	if err != nil {
+		return nil, err
+	}
+	defer keymaster.Wipe(oldFileKey)
+	if len(oldFileKey) != attachment.KeySize {
+		return nil, fmt.Errorf("cannot rotate attachment %d: %w", record.AttachmentID, attachment.ErrCorrupt)
+	}
+
+	fileKey, err := attachment.NewKey()
+	if err != nil {
+		return nil, err
+	}
+	defer keymaster.Wipe(fileKey)
+	if err := stageAttachmentFile(ctx, rotationID, record, oldFileKey, fileKey); err != nil {
+		return nil, fmt.Errorf("cannot rotate attachment %d: %w", record.AttachmentID, err)
+	}
+
+	resealed := &RotationAttachment{AttachmentID: record.AttachmentID}
+	for _, field := range []struct {
+		name  string
+		plain []byte
+		out   *string
+	}{
+		{fieldAttachmentName, name, &resealed.Name},
+		{fieldAttachmentKey, fileKey, &resealed.Key},
+	} {
+		sealed, err := envelope.Seal(field.plain, newKey, attachmentBinding(record.ItemID, record.AttachmentID, field.name))
+		if err != nil {
+			return nil, err
+		}
+		*field.out = utils.BytToBa64(sealed)
+	}
+	return resealed, nil
+}
+
+// openAttachmentRecord opens field of an attachment record with the current vault key
+func openAttachmentRecord(vaultKey []byte, record AttachmentRecord, encoded string, field string) ([]byte, error) {
+	ciphertext, err := utils.Ba64ToByt(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("cannot rotate attachment %d: %w", record.AttachmentID, err)
+	}
+	plain, err := openAttachmentField(ciphertext, vaultKey, record.ItemID, record.AttachmentID, field)
+	if err != nil {
+		return nil, fmt.Errorf("cannot rotate attachment %d: %w", record.AttachmentID, err)
+	}
+	return plain, nil
+}
+
+// stageAttachmentFile downloads the file of an attachment, decrypts it with oldKey and uploads it to the
+// rotation encrypted with fileKey. Each chunk is passed on as soon as it is verified, so the file never sits
+// in memory as a whole, and a chunk that does not verify stops the upload.
+func stageAttachmentFile(ctx context.Context, rotationID string, record AttachmentRecord, oldKey, fileKey []byte) error {
+	id := strconv.FormatUint(uint64(record.AttachmentID), 10)
+	body, err := client.Backend.Download(ctx, "/downloadAttachment?attachment_id="+id)
+	if err != nil {
+		log.Printf("DownloadAttachment communication failed: %v", err)
+		return err
+	}
+	defer body.Close()
+
+	plainReader, plainWriter := io.Pipe()
+	sealedReader, sealedWriter := io.Pipe()
+	var wg sync.WaitGroup
+	var decryptErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, decryptErr = attachment.Decrypt(plainWriter, body, oldKey)
+		plainWriter.CloseWithError(decryptErr)
+	}()
+	go func() {
+		defer wg.Done()
+		_, err := attachment.Encrypt(sealedWriter, plainReader, fileKey)
+		plainReader.CloseWithError(err)
+		sealedWriter.CloseWithError(err)
+	}()
+
+	path := "/rotation/attachment?rotation_id=" + url.QueryEscape(rotationID) + "&attachment_id=" + id
+	err = client.Backend.Upload(ctx, path, sealedReader, attachment.EncryptedSize(record.Size), nil)
+
+	// The keys are wiped by the caller, so both streams have to stop first. Closing the upload side stops
+	// them, so an error left in decryptErr is the reason the upload failed.
+	sealedReader.Close()
+	wg.Wait()
+
+	var apiErr *client.APIError
+	switch {
+	case decryptErr != nil && !errors.Is(decryptErr, io.ErrClosedPipe):
+		return decryptErr
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound && apiErr.Code == "":
+		log.Printf("Backend has no /rotation/attachment")
+		return ErrRotationUnsupported
+	case err != nil:
+		log.Printf("Staging attachment %d failed: %v", record.AttachmentID, err)
+		return err
+	}
+	return nil
+}
+
+// fetchAllAttachments lists the attachment records of every item
+func fetchAllAttachments(ctx context.Context) ([]AttachmentRecord, error) {
+	response := &GetAttachmentsResponse{}
+	if err := client.Backend.Do(ctx, http.MethodGet, "/getAttachments", nil, response); err != nil {
+		log.Printf("GetAttachments communication failed: %v", err)
+		return nil, err
+	}
+	return response.Attachments, nil
+}
+
+// categoryName returns the name of a category as the server sent it
+func (resp *GetListItemResponse) categoryName(id uint) string {
+	for _, category := range resp.Categorys {
+		if category.CategoryID == id {
+			return category.CategoryName
+		}
+	}
+	return ""
+}
+
+// attachmentKey returns the wrapped key of an attachment as the server sent it
+func attachmentKey(records []AttachmentRecord, id uint) string {
+	for _, record := range records {
+		if record.AttachmentID == id {
+			return record.Key
+		}
+	}
+	return ""
+}
+
+// loadRotation returns the rotation in progress for the logged-in user, or nil
+func loadRotation() (*rotationState, error) {
+	masterKey, err := keymaster.Keys.Masterkey()
+	if err != nil {
+		return nil, err
+	}
+	defer keymaster.Wipe(masterKey)
+
+	data, err := cache.LoadRotation(keymaster.Keys.Email(), masterKey)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	defer keymaster.Wipe(data)
+
+	state := &rotationState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// saveRotation stores what has been staged so far, encrypted with the master key
+func saveRotation(state *rotationState) error {
+	masterKey, err := keymaster.Keys.Masterkey()
+	if err != nil {
+		return err
+	}
+	defer keymaster.Wipe(masterKey)
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	defer keymaster.Wipe(data)
+	return cache.SaveRotation(keymaster.Keys.Email(), masterKey, data)
+}
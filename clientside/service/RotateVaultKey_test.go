@@ -0,0 +1,323 @@
+package service
+
+import (
+	"Modsec/clientside/CipherAlgo/envelope"
+	"Modsec/clientside/CipherAlgo/keymaster"
+	"Modsec/clientside/CipherAlgo/utils"
+	"Modsec/clientside/attachment"
+	"Modsec/clientside/cache"
+	"Modsec/clientside/client"
+	"Modsec/clientside/config"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// rotationServer stands in for the backend item list and rotation endpoints. The second batch request
+// fails once, to interrupt the first rotation.
+type rotationServer struct {
+	items        []Item
+	categories   []Category
+	attachments  []AttachmentRecord
+	files        map[uint][]byte
+	staged       map[uint]RotationItem
+	stagedCats   map[uint]RotationCategory
+	stagedAtts   map[uint]RotationAttachment
+	stagedFiles  map[uint][]byte
+	batches      int
+	received     int
+	protected    string
+	noFileStages bool // Answer /rotation/attachment like a backend without it
+}
+
+func newRotationServer(t *testing.T, vaultKey []byte, n int) *rotationServer {
+	t.Helper()
+	srv := &rotationServer{staged: map[uint]RotationItem{}, stagedCats: map[uint]RotationCategory{},
+		files: map[uint][]byte{}, stagedAtts: map[uint]RotationAttachment{}, stagedFiles: map[uint][]byte{}}
+	modified := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= n; i++ {
+		title, data, err := sealItem(vaultKey, uint(i), "login", []byte(fmt.Sprintf("Item %d", i)), []byte(`{"password":"pw"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv.items = append(srv.items, Item{ItemID: uint(i), Title: title, TypeName: "login",
+			Data: utils.BytToBa64(data), DateModify: modified})
+	}
+	legacy, _ := utils.EncryptAES256GCM([]byte("Work"), vaultKey)
+	srv.categories = []Category{{CategoryID: 1, CategoryName: utils.BytToBa64(legacy)}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/syncItems", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(GetListItemResponse{Items: srv.items, Categorys: srv.categories, Revision: 1, Full: true})
+	})
+	mux.HandleFunc("/getAttachments", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(GetAttachmentsResponse{Attachments: srv.attachments})
+	})
+	mux.HandleFunc("/downloadAttachment", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.URL.Query().Get("attachment_id"))
+		w.Write(srv.files[uint(id)])
+	})
+	mux.HandleFunc("/rotation/attachment", func(w http.ResponseWriter, r *http.Request) {
+		if srv.noFileStages {
+			http.NotFound(w, r)
+			return
+		}
+		id, _ := strconv.Atoi(r.URL.Query().Get("attachment_id"))
+		srv.stagedFiles[uint(id)], _ = io.ReadAll(r.Body)
+	})
+	mux.HandleFunc("/rotation/begin", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(RotationBeginResponse{RotationID: "r1"})
+	})
+	mux.HandleFunc("/rotation/batch", func(w http.ResponseWriter, r *http.Request) {
+		srv.batches++
+		if srv.batches == 2 {
+			http.Error(w, `{"error":"unavailable"}`, http.StatusServiceUnavailable)
+			return
+		}
+		var batch RotationBatchPayload
+		json.NewDecoder(r.Body).Decode(&batch)
+		for _, item := range batch.Items {
+			srv.staged[item.ItemID] = item
+		}
+		srv.received += len(batch.Items) + len(batch.Categories)
+		for _, category := range batch.Categories {
+			srv.stagedCats[category.CategoryID] = category
+		}
+		for _, record := range batch.Attachments {
+			srv.stagedAtts[record.AttachmentID] = record
+		}
+	})
+	mux.HandleFunc("/rotation/commit", func(w http.ResponseWriter, r *http.Request) {
+		var payload RotationCommitPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		if len(srv.staged) != len(srv.items) || len(srv.stagedAtts) != len(srv.attachments) ||
+			len(srv.stagedFiles) != len(srv.attachments) {
+			http.Error(w, `{"error":"incomplete","code":"rotation_stale"}`, http.StatusConflict)
+			return
+		}
+		for i, item := range srv.items {
+			staged := srv.staged[item.ItemID]
+			srv.items[i].Title = staged.Title
+			srv.items[i].Data = utils.BytToBa64(staged.Data)
+		}
+		for i, category := range srv.categories {
+			srv.categories[i].CategoryName = srv.stagedCats[category.CategoryID].CategoryName
+		}
+		for i, record := range srv.attachments {
+			staged := srv.stagedAtts[record.AttachmentID]
+			srv.attachments[i].Name, srv.attachments[i].Key = staged.Name, staged.Key
+			srv.files[record.AttachmentID] = srv.stagedFiles[record.AttachmentID]
+		}
+		srv.protected = payload.ProtectedVaultKey
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	cfg := config.Default()
+	cfg.BackendURL = ts.URL
+	if err := client.InitClient(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.InitClient(config.Default()) })
+	ClearStore()
+	t.Cleanup(ClearStore)
+	return srv
+}
+
+// attach adds an attachment of item 1 holding content, sealed the way UploadAttachment seals it
+func (srv *rotationServer) attach(t *testing.T, vaultKey []byte, content []byte) AttachmentRecord {
+	t.Helper()
+	id := uint(len(srv.attachments) + 1)
+	fileKey, _ := attachment.NewKey()
+	var file bytes.Buffer
+	if _, err := attachment.Encrypt(&file, bytes.NewReader(content), fileKey); err != nil {
+		t.Fatal(err)
+	}
+	wrapped, _ := envelope.Seal(fileKey, vaultKey, attachmentBinding(1, id, fieldAttachmentKey))
+	name, _ := envelope.Seal([]byte("scan.pdf"), vaultKey, attachmentBinding(1, id, fieldAttachmentName))
+	record := AttachmentRecord{AttachmentID: id, ItemID: 1, Size: int64(len(content)),
+		Name: utils.BytToBa64(name), Key: utils.BytToBa64(wrapped)}
+	srv.attachments = append(srv.attachments, record)
+	srv.files[id] = file.Bytes()
+	return record
+}
+
+func TestRotateVaultKeyResumes(t *testing.T) {
+	oldKey := bindingVault(t)
+	srv := newRotationServer(t, oldKey, rotationBatchSize+20)
+	ctx := context.Background()
+
+	if _, err := RotateVaultKey(ctx, nil); err == nil {
+		t.Fatal("rotation succeeded although a batch failed")
+	}
+	if !RotationInProgress() {
+		t.Fatal("interrupted rotation was not kept")
+	}
+
+	var last RotationProgress
+	seedPhrase, err := RotateVaultKey(ctx, func(p RotationProgress) { last = p })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seedPhrase == "" || RotationInProgress() {
+		t.Fatalf("seed phrase %q, still in progress: %v", seedPhrase, RotationInProgress())
+	}
+	// The resumed run only sent what the first run had not staged
+	if srv.received != rotationBatchSize+20+1 || last.Done != last.Total {
+		t.Fatalf("received %d records, progress %+v", srv.received, last)
+	}
+
+	newKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer keymaster.Wipe(newKey)
+	if bytes.Equal(newKey, oldKey) || srv.protected == "" {
+		t.Fatal("vault key was not replaced")
+	}
+	for _, item := range srv.items {
+		title, state, err := openBase64Field(item.Title, newKey, itemBinding(item.ItemID, "login", fieldTitle))
+		if err != nil || state != sealBound || string(title) != fmt.Sprintf("Item %d", item.ItemID) {
+			t.Fatalf("item %d: %q, %d, %v", item.ItemID, title, state, err)
+		}
+		if _, _, err := openBase64Field(item.Title, oldKey, itemBinding(item.ItemID, "login", fieldTitle)); err == nil {
+			t.Fatalf("item %d still opens with the old key", item.ItemID)
+		}
+	}
+	if name, _, err := openBase64Field(srv.categories[0].CategoryName, newKey, categoryBinding(1)); err != nil || string(name) != "Work" {
+		t.Fatalf("category = %q, %v", name, err)
+	}
+}
+
+func TestRotateVaultKeyReencryptsAttachments(t *testing.T) {
+	oldKey := bindingVault(t)
+	srv := newRotationServer(t, oldKey, 1)
+	srv.batches = 2 // No interrupted batch
+	content := bytes.Repeat([]byte("attachment "), 20000)
+	old := srv.attach(t, oldKey, content)
+	oldFile := srv.files[old.AttachmentID]
+
+	if _, err := RotateVaultKey(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	newKey, _ := keymaster.Keys.Vaultkey()
+	defer keymaster.Wipe(newKey)
+
+	record := srv.attachments[0]
+	wrapped, _ := utils.Ba64ToByt(record.Key)
+	fileKey, err := openAttachmentField(wrapped, newKey, 1, record.AttachmentID, fieldAttachmentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var plain bytes.Buffer
+	if _, err := attachment.Decrypt(&plain, bytes.NewReader(srv.files[record.AttachmentID]), fileKey); err != nil ||
+		!bytes.Equal(plain.Bytes(), content) {
+		t.Fatalf("rotated file does not open with its new key: %v", err)
+	}
+
+	// Whoever kept the old wrapped key can no longer read the file
+	oldWrapped, _ := utils.Ba64ToByt(old.Key)
+	oldFileKey, _ := openAttachmentField(oldWrapped, oldKey, 1, old.AttachmentID, fieldAttachmentKey)
+	if bytes.Equal(oldFileKey, fileKey) || bytes.Equal(oldFile, srv.files[record.AttachmentID]) {
+		t.Fatal("attachment kept its file key")
+	}
+	if _, err := attachment.Decrypt(io.Discard, bytes.NewReader(srv.files[record.AttachmentID]), oldFileKey); err == nil {
+		t.Fatal("rotated file opens with the old file key")
+	}
+}
+
+func TestRotateVaultKeyWithoutAttachmentStaging(t *testing.T) {
+	oldKey := bindingVault(t)
+	srv := newRotationServer(t, oldKey, 1)
+	srv.batches = 2
+	srv.noFileStages = true
+	srv.attach(t, oldKey, []byte("contract"))
+
+	// The rotation stops instead of leaving the files under their old keys
+	if _, err := RotateVaultKey(context.Background(), nil); !errors.Is(err, ErrRotationUnsupported) {
+		t.Fatalf("RotateVaultKey = %v", err)
+	}
+	if srv.protected != "" {
+		t.Fatal("rotation committed")
+	}
+	if key, _ := keymaster.Keys.Vaultkey(); !bytes.Equal(key, oldKey) {
+		t.Fatal("vault key replaced")
+	}
+}
+
+// resetJournal makes the next journal access read it from the cache again
+func resetJournal(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		journal.mu.Lock()
+		journal.loaded = false
+		journal.ops = nil
+		journal.mu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestJournalAfterRotationElsewhere(t *testing.T) {
+	oldKey := bindingVault(t)
+	resetJournal(t)
+	email := keymaster.Keys.Email()
+	masterKey, _ := keymaster.Keys.Masterkey()
+	defer keymaster.Wipe(masterKey)
+
+	protected, _ := keymaster.Protect(oldKey, masterKey, true)
+	if err := cache.SaveProtectedKey(email, protected); err != nil {
+		t.Fatal(err)
+	}
+	title, data, _ := sealItem(oldKey, 7, "login", []byte("Mail"), []byte(`{"password":"pw"}`))
+	if _, err := enqueue(PendingOp{Kind: OpUpdateItem, ItemID: 7, TypeName: "login",
+		Payload: mustJSON(t, UpdateItemPayload{Item_id: 7, Title: title, Data: data})}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another device rotated the key, this one logs in and gets it
+	newKey, _ := utils.GenerateRandomBytes(32)
+	newProtected, _ := keymaster.Protect(newKey, masterKey, true)
+	if err := cache.SaveProtectedKey(email, newProtected); err != nil {
+		t.Fatal(err)
+	}
+	keymaster.Keys.Rekey(masterKey, newKey, newProtected)
+	resetJournal(t)
+
+	if pending, err := PendingCount(); err != nil || pending != 1 {
+		t.Fatalf("PendingCount = %d, %v", pending, err)
+	}
+	var payload UpdateItemPayload
+	json.Unmarshal(journal.ops[0].Payload, &payload)
+	if got, _, err := openItemPayload(newKey, 7, "login", payload.Title, payload.Data); err != nil || string(got) != "Mail" {
+		t.Fatalf("queued title = %q, %v", got, err)
+	}
+	if _, err := cache.LoadPreviousKey(email); !errors.Is(err, cache.ErrNoCache) {
+		t.Fatalf("previous key kept after the journal was resealed: %v", err)
+	}
+
+	// Without the previous key the journal is reported as unreadable, not as empty
+	otherKey, _ := utils.GenerateRandomBytes(32)
+	keymaster.Keys.Rekey(masterKey, otherKey, nil)
+	resetJournal(t)
+	if _, err := PendingCount(); !errors.Is(err, ErrJournalUnreadable) {
+		t.Fatalf("PendingCount error = %v", err)
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
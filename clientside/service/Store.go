@@ -0,0 +1,427 @@
+package service
+
+// The decrypted vault is kept in memory and brought up to date with the backend by asking only
+// for what changed since the last revision. Every change applied is reported to the listeners
+// registered with OnVaultChange so the frontend can patch its list instead of reloading it.
+
+import (
+	"Modsec/clientside/CipherAlgo/keymaster"
+	"Modsec/clientside/client"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ChangeKind says what happened to an item or category
+type ChangeKind string
+
+const (
+	ItemChanged     ChangeKind = "item_changed"
+	ItemDeleted     ChangeKind = "item_deleted"
+	CategoryChanged ChangeKind = "category_changed"
+	CategoryDeleted ChangeKind = "category_deleted"
+)
+
+// Change is one entry of the change feed
+type Change struct {
+	Kind       ChangeKind     `json:"Kind"`
+	ItemID     uint           `json:"ItemID,omitempty"`
+	CategoryID uint           `json:"CategoryID,omitempty"`
+	Item       *AfterItem     `json:"Item,omitempty"`
+	Category   *AfterCategory `json:"Category,omitempty"`
+}
+
+// ChangeSet is the list of changes applied by one sync
+type ChangeSet struct {
+	Revision uint64   `json:"Revision"`
+	Changes  []Change `json:"Changes"`
+}
+
+// vaultStore holds the vault both as the backend sent it and decrypted
+type vaultStore struct {
+	mu sync.RWMutex
+
+	email     string
+	loaded    bool
+	revision  uint64 // Revision of the last delta applied, 0 forces a full download
+	fromCache bool   // Contents came from the offline copy and may include journaled writes
+	noDelta   bool   // Backend has no /syncItems endpoint
+	cancel    context.CancelFunc
+
+	raw           map[uint]Item
+	rawCategories map[uint]Category
+	items         map[uint]AfterItem
+	categories    map[uint]AfterCategory
+
+	listeners []func(ChangeSet)
+}
+
+var store = &vaultStore{}
+
+// syncMu makes sure only one sync talks to the backend at a time
+var syncMu sync.Mutex
+
+// OnVaultChange registers fn to be called with every non-empty set of changes
+func OnVaultChange(fn func(ChangeSet)) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.listeners = append(store.listeners, fn)
+}
+
+// ClearStore drops the decrypted vault from memory, used when the vault locks or the user logs out
+func ClearStore() {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	// Stop a sync that is still decrypting
+	if store.cancel != nil {
+		store.cancel()
+		store.cancel = nil
+	}
+	store.resetLocked("")
+	resetBindingMigration()
+}
+
+func (s *vaultStore) resetLocked(email string) {
+	s.email = email
+	s.loaded = false
+	s.revision = 0
+	s.fromCache = false
+	s.noDelta = false
+	s.raw = make(map[uint]Item)
+	s.rawCategories = make(map[uint]Category)
+	s.items = make(map[uint]AfterItem)
+	s.categories = make(map[uint]AfterCategory)
+}
+
+// snapshot returns the decrypted items and categories ordered by ID
+func (s *vaultStore) snapshot() ([]AfterItem, []AfterCategory) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make([]AfterItem, 0, len(s.items))
+	for _, item := range s.items {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ItemID < items[j].ItemID })
+
+	categories := make([]AfterCategory, 0, len(s.categories))
+	for _, category := range s.categories {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].CategoryID < categories[j].CategoryID })
+
+	return items, categories
+}
+
+// rawResponse rebuilds the backend response the store was filled from, for the offline copy and the journal
+func (s *vaultStore) rawResponse() *GetListItemResponse {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resp := &GetListItemResponse{Revision: s.revision}
+	for _, item := range s.raw {
+		resp.Items = append(resp.Items, item)
+	}
+	for _, category := range s.rawCategories {
+		resp.Categorys = append(resp.Categorys, category)
+	}
+	sort.Slice(resp.Items, func(i, j int) bool { return resp.Items[i].ItemID < resp.Items[j].ItemID })
+	sort.Slice(resp.Categorys, func(i, j int) bool { return resp.Categorys[i].CategoryID < resp.Categorys[j].CategoryID })
+	return resp
+}
+
+func (s *vaultStore) isLoaded() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loaded
+}
+
+// isFromCache reports whether the contents came from the offline copy
+func (s *vaultStore) isFromCache() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.fromCache
+}
+
+// syncFrom returns the revision to ask for changes since, 0 when everything has to be downloaded
+func (s *vaultStore) syncFrom() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.fromCache {
+		return 0
+	}
+	return s.revision
+}
+
+// setFromCache records where the contents came from, unless the store was cleared since ctx started
+func (s *vaultStore) setFromCache(ctx context.Context, fromCache bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ctx.Err() == nil {
+		s.fromCache = fromCache
+	}
+}
+
+// hasDelta reports whether the backend may have /syncItems
+func (s *vaultStore) hasDelta() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return !s.noDelta
+}
+
+func (s *vaultStore) setNoDelta() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.noDelta = true
+}
+
+// rawItem returns the item as the backend last sent it
+func (s *vaultStore) rawItem(itemID uint) (Item, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	item, ok := s.raw[itemID]
+	return item, ok
+}
+
+func sameItem(a, b Item) bool {
+	sameCategory := (a.CategoryID == nil && b.CategoryID == nil) ||
+		(a.CategoryID != nil && b.CategoryID != nil && *a.CategoryID == *b.CategoryID)
+	return sameCategory && a.Title == b.Title && a.TypeName == b.TypeName && a.Data == b.Data &&
+		a.IsBookmark == b.IsBookmark && a.DateModify.Equal(b.DateModify)
+}
+
+// merge decrypts the titles of what changed in resp and applies it. A full response replaces the store,
+// anything missing from it counts as deleted.
+func (s *vaultStore) merge(ctx context.Context, resp *GetListItemResponse, full bool) (ChangeSet, error) {
+	s.mu.RLock()
+	var changedItems []Item
+	for _, item := range resp.Items {
+		if old, ok := s.raw[item.ItemID]; !ok || !sameItem(old, item) {
+			changedItems = append(changedItems, item)
+		}
+	}
+	var changedCategories []Category
+	for _, category := range resp.Categorys {
+		if old, ok := s.rawCategories[category.CategoryID]; !ok || old != category {
+			changedCategories = append(changedCategories, category)
+		}
+	}
+
+	deletedItems := resp.DeletedItems
+	deletedCategories := resp.DeletedCategorys
+	if full {
+		deletedItems, deletedCategories = nil, nil
+		present := make(map[uint]bool, len(resp.Items))
+		for _, item := range resp.Items {
+			present[item.ItemID] = true
+		}
+		for id := range s.raw {
+			if !present[id] {
+				deletedItems = append(deletedItems, id)
+			}
+		}
+		presentCategories := make(map[uint]bool, len(resp.Categorys))
+		for _, category := range resp.Categorys {
+			presentCategories[category.CategoryID] = true
+		}
+		for id := range s.rawCategories {
+			if !presentCategories[id] {
+				deletedCategories = append(deletedCategories, id)
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	// Only what changed is decrypted, outside the lock so readers are not held up
+	decryptedItems, err := DecryptItems(ctx, changedItems, false)
+	if err != nil {
+		return ChangeSet{}, err
+	}
+	decryptedCategories, err := ProcessGetListCategory(&GetListItemResponse{Categorys: changedCategories})
+	if err != nil {
+		return ChangeSet{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The store may have been cleared while decrypting
+	if err := ctx.Err(); err != nil {
+		return ChangeSet{}, err
+	}
+
+	changes := ChangeSet{Revision: resp.Revision, Changes: []Change{}}
+	for i, item := range changedItems {
+		after := decryptedItems[i]
+		s.raw[item.ItemID] = item
+		s.items[item.ItemID] = after
+		changes.Changes = append(changes.Changes, Change{Kind: ItemChanged, ItemID: item.ItemID, Item: &after})
+	}
+	for _, id := range deletedItems {
+		if _, ok := s.raw[id]; !ok {
+			continue
+		}
+		delete(s.raw, id)
+		delete(s.items, id)
+		changes.Changes = append(changes.Changes, Change{Kind: ItemDeleted, ItemID: id})
+	}
+	for i, category := range changedCategories {
+		after := (*decryptedCategories)[i]
+		s.rawCategories[category.CategoryID] = category
+		s.categories[category.CategoryID] = after
+		changes.Changes = append(changes.Changes, Change{Kind: CategoryChanged, CategoryID: category.CategoryID, Category: &after})
+	}
+	for _, id := range deletedCategories {
+		if _, ok := s.rawCategories[id]; !ok {
+			continue
+		}
+		delete(s.rawCategories, id)
+		delete(s.categories, id)
+		changes.Changes = append(changes.Changes, Change{Kind: CategoryDeleted, CategoryID: id})
+	}
+
+	s.loaded = true
+	s.revision = resp.Revision
+	return changes, nil
+}
+
+// notify hands a change set to the listeners
+func (s *vaultStore) notify(changes ChangeSet) {
+	if len(changes.Changes) == 0 {
+		return
+	}
+
+	s.mu.RLock()
+	listeners := append([]func(ChangeSet){}, s.listeners...)
+	s.mu.RUnlock()
+
+	for _, fn := range listeners {
+		fn(changes)
+	}
+}
+
+// fetchChanges asks the backend for what changed since revision, or for everything if it cannot do deltas.
+// It also reports whether the response is a full list.
+func fetchChanges(ctx context.Context, since uint64) (*GetListItemResponse, bool, error) {
+	if store.hasDelta() {
+		response := &GetListItemResponse{}
+		err := client.Backend.Do(ctx, http.MethodGet, "/syncItems?since="+strconv.FormatUint(since, 10), nil, response)
+
+		var apiErr *client.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+			return response, since == 0 || response.Full, err
+		}
+		log.Printf("Backend has no /syncItems, falling back to full downloads")
+		store.setNoDelta()
+	}
+
+	response := &GetListItemResponse{}
+	err := client.Backend.Do(ctx, http.MethodGet, "/getItemList", nil, response)
+	// A full list has no revision, so the next sync is full again
+	response.Revision = 0
+	return response, true, err
+}
+
+// SyncVault brings the in-memory vault up to date with the backend and returns the changes applied.
+// When the backend is unreachable the offline copy plus any journaled writes is used instead.
+// Item Data is not decrypted here, see GetItemDetail.
+func SyncVault(ctx context.Context) (ChangeSet, error) {
+	if err := requireUnlocked(); err != nil {
+		return ChangeSet{}, err
+	}
+
+	syncMu.Lock()
+	defer syncMu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	store.mu.Lock()
+	if email := keymaster.Keys.Email(); store.email != email || store.raw == nil {
+		store.resetLocked(email)
+	}
+	store.cancel = cancel
+	store.mu.Unlock()
+
+	// Start from the offline copy so only what changed since it was saved is downloaded
+	if !store.isLoaded() {
+		if cached, _, err := loadListCache(); err == nil && cached.Revision > 0 {
+			if _, err := store.merge(ctx, cached, true); err != nil {
+				log.Printf("Failed to load offline copy: %v", err)
+			}
+		}
+	}
+
+	response, full, err := fetchChanges(ctx, store.syncFrom())
+	// Cleared while waiting for the backend, the answer belongs to a session that is gone
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ChangeSet{}, ctxErr
+	}
+	if errors.Is(err, client.ErrNetwork) {
+		// Backend unreachable, fall back to the local copy plus any writes made offline
+		cached, savedAt, cacheErr := loadListCache()
+		if cacheErr != nil {
+			log.Printf("Sync failed and no offline copy: %v", cacheErr)
+			return ChangeSet{}, err
+		}
+		log.Printf("Sync using offline copy from %s", savedAt.Format(time.RFC3339))
+		setOffline(true)
+		applyJournal(cached)
+
+		changes, mergeErr := store.merge(ctx, cached, true)
+		if mergeErr != nil {
+			return ChangeSet{}, mergeErr
+		}
+		store.setFromCache(ctx, true)
+		store.notify(changes)
+		return changes, nil
+	}
+	if err != nil {
+		log.Printf("Sync communication failed: %v", err)
+		return ChangeSet{}, err
+	}
+	setOffline(false)
+
+	changes, err := store.merge(ctx, response, full)
+	if err != nil {
+		return ChangeSet{}, err
+	}
+	store.setFromCache(ctx, false)
+	if err := ctx.Err(); err != nil {
+		return ChangeSet{}, err
+	}
+
+	sent, replayErr := replayJournal(store.rawResponse())
+	if sent {
+		// Fetch again so the store includes the writes that were just replayed
+		response, full, err = fetchChanges(ctx, store.syncFrom())
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ChangeSet{}, ctxErr
+		}
+		if err != nil {
+			log.Printf("Sync communication failed after replay: %v", err)
+			return ChangeSet{}, err
+		}
+		more, err := store.merge(ctx, response, full)
+		if err != nil {
+			return ChangeSet{}, err
+		}
+		changes.Revision = more.Revision
+		changes.Changes = append(changes.Changes, more.Changes...)
+	}
+
+	if len(changes.Changes) > 0 {
+		saveListCache(store.rawResponse())
+	}
+
+	log.Printf("Sync applied %d changes, revision %d", len(changes.Changes), changes.Revision)
+	store.notify(changes)
+	// The vault is up to date, but the offline changes could not be sent
+	return changes, replayErr
+}
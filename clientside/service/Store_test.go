@@ -0,0 +1,107 @@
+package service
+
+import (
+	"Modsec/clientside/client"
+	"Modsec/clientside/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestClearStoreDuringSync locks the vault while syncs run, run it with -race
+func TestClearStoreDuringSync(t *testing.T) {
+	items := testVault(t, 20)
+	t.Setenv(config.EnvConfigFile, t.TempDir()+"/config.json")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/syncItems", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(GetListItemResponse{Items: items, Revision: 1, Full: true})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	cfg := config.Default()
+	cfg.BackendURL = srv.URL
+	if err := client.InitClient(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.InitClient(config.Default()) })
+	ClearStore()
+	t.Cleanup(ClearStore)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SyncVault(context.Background())
+		}()
+		go func() {
+			defer wg.Done()
+			ClearStore()
+		}()
+	}
+	wg.Wait()
+
+	// A sync after the clears starts from scratch and loads everything
+	if _, err := SyncVault(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.snapshot(); len(got) != len(items) {
+		t.Fatalf("store has %d items, want %d", len(got), len(items))
+	}
+}
+
+// TestClearStoreCancelsFetch locks the vault while the backend is still answering a sync
+func TestClearStoreCancelsFetch(t *testing.T) {
+	items := testVault(t, 3)
+	t.Setenv(config.EnvConfigFile, t.TempDir()+"/config.json")
+
+	arrived := make(chan struct{})
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/syncItems", func(w http.ResponseWriter, r *http.Request) {
+		close(arrived)
+		<-release
+		json.NewEncoder(w).Encode(GetListItemResponse{Items: items, Revision: 1, Full: true})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	cfg := config.Default()
+	cfg.BackendURL = srv.URL
+	if err := client.InitClient(cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.InitClient(config.Default()) })
+	ClearStore()
+	t.Cleanup(ClearStore)
+	setOffline(false)
+
+	done := make(chan error)
+	go func() {
+		_, err := SyncVault(context.Background())
+		done <- err
+	}()
+	<-arrived
+	ClearStore()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("SyncVault = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ClearStore did not cancel the request")
+	}
+	if store.isLoaded() {
+		t.Fatal("cleared store was filled")
+	}
+	if IsOffline() {
+		t.Fatal("a cancelled request was taken for the backend being unreachable")
+	}
+}
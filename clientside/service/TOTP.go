@@ -0,0 +1,83 @@
+package service
+
+import (
+	"Modsec/clientside/otp"
+	"Modsec/clientside/schema"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrNoTOTP is returned by GetTOTPCode for an item without a one-time password key
+var ErrNoTOTP = errors.New("item has no one-time password")
+
+// TOTPCode is a one-time code for an item
+type TOTPCode struct {
+	Code      string `json:"code"`
+	Remaining int    `json:"remaining"` // Seconds the code stays valid, 0 for HOTP codes which last until used
+	Period    int    `json:"period"`    // Seconds per TOTP code, 0 for HOTP
+	Issuer    string `json:"issuer"`
+	Account   string `json:"account"`
+}
+
+// GetTOTPCode returns the current code of an item. The key comes from the website TOTP field, or else
+// the first custom field of kind totp. HOTP keys move on to the next counter, which is saved with the item.
+func GetTOTPCode(ctx context.Context, itemID uint) (*TOTPCode, error) {
+	item, err := GetItemDetail(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.Data == nil {
+		return nil, ErrNoTOTP
+	}
+	data, err := item.Data.Data()
+	if err != nil {
+		return nil, err
+	}
+
+	// source points at the stored key so an HOTP counter can be written back
+	var source *string
+	if website, ok := data.(*schema.Website); ok && website.TOTP != "" {
+		source = &website.TOTP
+	} else if custom := schema.CustomFieldsOf(data); custom != nil {
+		for i := range *custom {
+			if field := &(*custom)[i]; field.Kind == schema.FieldTOTP && field.Value != "" {
+				source = &field.Value
+				break
+			}
+		}
+	}
+	if source == nil {
+		return nil, ErrNoTOTP
+	}
+
+	key, err := otp.Parse(*source)
+	if err != nil {
+		log.Printf("GetTOTPCode item %d: %v", itemID, err)
+		return nil, err
+	}
+	result := &TOTPCode{Issuer: key.Issuer, Account: key.Account}
+
+	if key.Kind == otp.TOTP {
+		code, remaining, err := key.At(time.Now())
+		if err != nil {
+			return nil, err
+		}
+		result.Code = code
+		result.Remaining = int(remaining / time.Second)
+		result.Period = int(key.Period / time.Second)
+		return result, nil
+	}
+
+	if result.Code, err = key.Generate(key.Counter); err != nil {
+		return nil, err
+	}
+	key.Counter++
+	*source = key.URI()
+	if _, err := UpdateItemClient(itemID, item.CategoryID, ItemInput{Title: item.Title, Fields: *schema.FieldsOf(data)}); err != nil {
+		return nil, fmt.Errorf("failed to save the next HOTP counter: %w", err)
+	}
+	return result, nil
+}
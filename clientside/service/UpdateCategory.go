@@ -1,11 +1,12 @@
 package service
 
 import (
+	"Modsec/clientside/CipherAlgo/envelope"
 	"Modsec/clientside/CipherAlgo/keymaster"
 	"Modsec/clientside/CipherAlgo/utils"
 	"Modsec/clientside/client"
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -14,6 +15,7 @@ import (
 type UpdateCategoryPayload struct {
 	Category_id  uint   `json:"category_id"`
 	CategoryName string `json:"categoryname"`
+	BaseName     string `json:"base_name,omitempty"` // Refused with 409 if the stored name is no longer this
 }
 
 type UpdateCategoryResponse struct {
@@ -22,8 +24,13 @@ type UpdateCategoryResponse struct {
 }
 
 func ProcessUpdateCategory(category_id uint, categoryname string) (*UpdateCategoryPayload, error) {
+	vaultKey, err := keymaster.Keys.Vaultkey()
+	if err != nil {
+		return nil, err
+	}
+	defer keymaster.Wipe(vaultKey)
 
-	encryptedCategory, err := utils.EncryptAES256GCM([]byte(categoryname), keymaster.Vaultkey)
+	encryptedCategory, err := envelope.Seal([]byte(categoryname), vaultKey, tempBinding(categoryBinding(category_id)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to encrypt categoryname: %v", err)
 	}
@@ -38,47 +45,6 @@ func ProcessUpdateCategory(category_id uint, categoryname string) (*UpdateCatego
 	return payload, nil
 }
 
-// SendLoginToBackend sends login data to the backend server
-func SendUpdateCategoryToBackend(payload *UpdateCategoryPayload, backendURL string) (*UpdateCategoryResponse, error) {
-	// Convert payload to JSON
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode JSON: %v", err)
-	}
-
-	// Send data to backend server
-	req, err := http.NewRequest("POST", backendURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Access-Control-Allow-Credentials", "true")
-
-	//Delete after test
-	fmt.Println("Category data:", req)
-
-	resp, err := client.HMClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("UpdateCategory failed with status: %d", resp.StatusCode)
-	}
-
-	// Parse response
-	var result UpdateCategoryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
-	}
-
-	return &result, nil
-}
-
 func UpdateCategoryClient(category_id uint, categoryname string) (*UpdateCategoryResponse, error) {
 	//Update a category payload
 	payload, err := ProcessUpdateCategory(category_id, categoryname)
@@ -87,9 +53,19 @@ func UpdateCategoryClient(category_id uint, categoryname string) (*UpdateCategor
 		return nil, err
 	}
 
+	// Keep the write for later while the server is unreachable, the category is still waiting to be created
+	// or an earlier rename of it is held back by a sync conflict
+	if IsOffline() || isTempID(category_id) || conflictPending(OpUpdateCategory, 0, category_id) {
+		return queueUpdateCategory(payload)
+	}
+
 	// Send to backend server
-	backendURL := "http://localhost:8080/updateCategory" // Change as needed
-	response, err := SendUpdateCategoryToBackend(payload, backendURL)
+	response := &UpdateCategoryResponse{}
+	err = client.Backend.Do(context.Background(), http.MethodPost, "/updateCategory", payload, response)
+	if errors.Is(err, client.ErrNetwork) {
+		log.Printf("UpdateCategory could not reach the server, queueing: %v", err)
+		return queueUpdateCategory(payload)
+	}
 	if err != nil {
 		log.Printf("UpdateCategory communication failed: %v", err)
 		return nil, err
@@ -2,20 +2,23 @@ package service
 
 import (
 	"Modsec/clientside/CipherAlgo/keymaster"
-	"Modsec/clientside/CipherAlgo/utils"
 	"Modsec/clientside/client"
-	"bytes"
-	"encoding/json"
+	"Modsec/clientside/schema"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 )
 
 type UpdateItemPayload struct {
-	Item_id     uint   `json:"item_id"`
-	Title       string `json:"title"`
-	Category_id *uint  `json:"category_id"`
-	Data        []byte `json:"data"`
+	Item_id     uint       `json:"item_id"`
+	Title       string     `json:"title"`
+	Category_id *uint      `json:"category_id"`
+	Data        []byte     `json:"data"`
+	BaseModify  *time.Time `json:"base_modify,omitempty"` // Refused with 409 if the item was modified after this
+	Rebind      bool       `json:"rebind,omitempty"`      // Only the ciphertext changed, no version is kept and date_modify stays
 }
 
 type UpdateItemResponse struct {
@@ -23,27 +26,25 @@ type UpdateItemResponse struct {
 	Message string `json:"message"`
 }
 
-func ProcessUpdateItem(Item_id uint, category_id *uint, title string, itemdata map[string]interface{}) (*UpdateItemPayload, error) {
-
-	//Encode JSON to byte
-	itemdatabyte, err := json.Marshal(itemdata)
+func ProcessUpdateItem(Item_id uint, category_id *uint, title string, itemdata schema.Data) (*UpdateItemPayload, error) {
+	vaultKey, err := keymaster.Keys.Vaultkey()
 	if err != nil {
-		return nil, fmt.Errorf("failed to encode JSON: %v", err)
+		return nil, err
 	}
+	defer keymaster.Wipe(vaultKey)
 
-	// Encrypt data with sq
-	encryptedItemdata, err := utils.EncryptAES256GCM(itemdatabyte, keymaster.Vaultkey)
+	// Validate and encode with the schema version
+	itemdatabyte, err := schema.Encode(itemdata)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt Itemdata: %v", err)
+		return nil, err
 	}
 
-	encryptedTitle, err := utils.EncryptAES256GCM([]byte(title), keymaster.Vaultkey)
+	// Bound to the item, items still waiting to be created are bound to ID 0 until they are rebound
+	StrencryptedTitle, encryptedItemdata, err := sealItem(vaultKey, Item_id, schema.BackendName(itemdata.Type()), []byte(title), itemdatabyte)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt Title: %v", err)
+		return nil, err
 	}
 
-	StrencryptedTitle := utils.BytToBa64(encryptedTitle)
-
 	// Update login payload
 	payload := &UpdateItemPayload{
 		Item_id:     Item_id,
@@ -55,58 +56,44 @@ func ProcessUpdateItem(Item_id uint, category_id *uint, title string, itemdata m
 	return payload, nil
 }
 
-// SendLoginToBackend sends login data to the backend server
-func SendUpdateItemToBackend(payload *UpdateItemPayload, backendURL string) (*UpdateItemResponse, error) {
-	// Convert payload to JSON
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return nil, fmt.Errorf("failed to encode JSON: %v", err)
-	}
-
-	// Send data to backend server
-	req, err := http.NewRequest("POST", backendURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Access-Control-Allow-Credentials", "true")
-
-	//Delete after test
-	fmt.Println("Item data:", req)
-
-	resp, err := client.HMClient.Do(req)
+func UpdateItemClient(item_id uint, category_id *uint, input ItemInput) (*UpdateItemResponse, error) {
+	ItemData, err := input.data()
 	if err != nil {
+		log.Printf("UpdateItem validation failed: %v", err)
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("UpdateItem failed with status: %d", resp.StatusCode)
+	// The server keeps the type of an item, so the data has to match it
+	if raw, ok := store.rawItem(item_id); ok && schema.FrontendName(raw.TypeName) != ItemData.Type() {
+		log.Printf("UpdateItem type mismatch: item %d is %s, got %s", item_id, raw.TypeName, ItemData.Type())
+		return nil, fmt.Errorf("%w: item %d is not a %s", schema.ErrInvalidItem, item_id, ItemData.Type())
 	}
 
-	// Parse response
-	var result UpdateItemResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+	if err := carryPasswordHistory(item_id, ItemData, time.Now()); err != nil {
+		return nil, err
 	}
 
-	return &result, nil
-}
-
-func UpdateItemClient(item_id uint, category_id *uint, title string, ItemData map[string]interface{}) (*UpdateItemResponse, error) {
 	//Update a item payload
-	payload, err := ProcessUpdateItem(item_id, category_id, title, ItemData)
+	payload, err := ProcessUpdateItem(item_id, category_id, input.Title, ItemData)
 	if err != nil {
 		log.Printf("UpdateItem processing failed: %v", err)
 		return nil, err
 	}
 
+	// Keep the write for later while the server is unreachable, the item is still waiting to be created
+	// or an earlier edit of it is held back by a sync conflict
+	if IsOffline() || isTempID(item_id) || (category_id != nil && isTempID(*category_id)) ||
+		conflictPending(OpUpdateItem, item_id, 0) {
+		return queueUpdateItem(payload)
+	}
+
 	// Send to backend server
-	backendURL := "http://localhost:8080/updateItem" // Change as needed
-	response, err := SendUpdateItemToBackend(payload, backendURL)
+	response := &UpdateItemResponse{}
+	err = client.Backend.Do(context.Background(), http.MethodPost, "/updateItem", payload, response)
+	if errors.Is(err, client.ErrNetwork) {
+		log.Printf("UpdateItem could not reach the server, queueing: %v", err)
+		return queueUpdateItem(payload)
+	}
 	if err != nil {
 		log.Printf("UpdateItem communication failed: %v", err)
 		return nil, err
@@ -0,0 +1,15 @@
+package service
+
+import (
+	"Modsec/clientside/CipherAlgo/keymaster"
+	"log"
+)
+
+// requireUnlocked stops a request from reaching the backend while the vault is locked
+func requireUnlocked() error {
+	if keymaster.Keys.IsLocked() {
+		log.Printf("Rejected request: vault is locked")
+		return keymaster.ErrVaultLocked
+	}
+	return nil
+}
@@ -0,0 +1,324 @@
+package sshagent
+
+// An ssh-agent that serves the SSH keys stored in the vault. Nothing is cached: every request reads
+// the keys from the vault, so once the vault locks the agent has nothing to offer, and every signature
+// waits for the user to approve it in the UI.
+
+import (
+	"Modsec/clientside/config"
+	"Modsec/clientside/schema"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+var (
+	// ErrDenied is returned to ssh when the user rejects a signature or does not answer in time
+	ErrDenied = errors.New("signature request denied")
+	// ErrReadOnly is returned for requests that would change the keys, they are managed in the vault
+	ErrReadOnly = errors.New("keys are managed in the Modsec vault")
+	// ErrNoRequest is returned by Resolve for an unknown or already answered request
+	ErrNoRequest = errors.New("no pending signature request with that id")
+)
+
+// ConfirmTimeout is how long a signature request waits for the user before it is denied
+const ConfirmTimeout = time.Minute
+
+// Key is an SSH key item from the vault
+type Key struct {
+	ItemID uint
+	Title  string
+	Data   *schema.SSHKey
+}
+
+// ConfirmRequest asks the user to approve one use of a key
+type ConfirmRequest struct {
+	ID          string `json:"id"`
+	ItemID      uint   `json:"itemId"`
+	Title       string `json:"title"`
+	Comment     string `json:"comment"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// Status describes whether the agent is serving and where
+type Status struct {
+	Running    bool   `json:"running"`
+	SocketPath string `json:"socketPath"`
+}
+
+// Server runs the agent on a Unix socket
+type Server struct {
+	keys    func(ctx context.Context) ([]Key, error)
+	confirm func(ConfirmRequest)
+	timeout time.Duration
+
+	mu       sync.Mutex
+	listener net.Listener
+	path     string
+	ctx      context.Context
+	cancel   context.CancelFunc
+	pending  map[string]chan bool
+}
+
+// NewServer creates a stopped Server. keys returns the keys of the unlocked vault, confirm is called
+// with every signature request and must not block; the answer comes back through Resolve.
+func NewServer(keys func(ctx context.Context) ([]Key, error), confirm func(ConfirmRequest)) *Server {
+	return &Server{
+		keys:    keys,
+		confirm: confirm,
+		timeout: ConfirmTimeout,
+		pending: make(map[string]chan bool),
+	}
+}
+
+// DefaultSocketPath returns the socket location next to the config file
+func DefaultSocketPath() (string, error) {
+	path, err := config.Path()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "ssh-agent.sock"), nil
+}
+
+// Start listens on path, replacing a stale socket left by an earlier run. Starting a running
+// Server on the same path does nothing.
+func (s *Server) Start(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener != nil {
+		if s.path == path {
+			return nil
+		}
+		s.stopLocked()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove old socket: %w", err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to protect socket: %w", err)
+	}
+
+	s.listener = listener
+	s.path = path
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	go s.serve(s.ctx, listener)
+
+	log.Printf("SSH agent listening on %s", path)
+	return nil
+}
+
+// Stop closes the socket and denies every request still waiting for the user
+func (s *Server) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopLocked()
+}
+
+func (s *Server) stopLocked() {
+	if s.listener == nil {
+		return
+	}
+	s.cancel()
+	s.listener.Close()
+	os.Remove(s.path)
+	s.listener = nil
+
+	for id, answer := range s.pending {
+		answer <- false
+		delete(s.pending, id)
+	}
+	log.Printf("SSH agent stopped")
+}
+
+// Status reports whether the agent is running
+func (s *Server) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Status{Running: s.listener != nil, SocketPath: s.path}
+}
+
+// Resolve answers a pending ConfirmRequest
+func (s *Server) Resolve(id string, allow bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	answer, ok := s.pending[id]
+	if !ok {
+		return ErrNoRequest
+	}
+	delete(s.pending, id)
+	answer <- allow
+	return nil
+}
+
+func (s *Server) serve(ctx context.Context, listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("SSH agent accept failed: %v", err)
+			}
+			return
+		}
+		go func() {
+			defer conn.Close()
+			if err := agent.ServeAgent(&vaultAgent{s: s, ctx: ctx}, conn); err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Printf("SSH agent connection ended: %v", err)
+			}
+		}()
+	}
+}
+
+// ask sends req to the UI and waits for the answer, ctx ends when the agent stops
+func (s *Server) ask(ctx context.Context, req ConfirmRequest) bool {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return false
+	}
+	req.ID = hex.EncodeToString(id)
+
+	answer := make(chan bool, 1)
+	s.mu.Lock()
+	s.pending[req.ID] = answer
+	s.mu.Unlock()
+
+	s.confirm(req)
+
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	select {
+	case allow := <-answer:
+		return allow
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	delete(s.pending, req.ID)
+	s.mu.Unlock()
+	return false
+}
+
+// vaultAgent answers the agent protocol for one connection
+type vaultAgent struct {
+	s   *Server
+	ctx context.Context
+}
+
+// signers parses the vault keys, skipping any that cannot be used
+func (a *vaultAgent) signers() ([]Key, []ssh.Signer, error) {
+	keys, err := a.s.keys(a.ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	usable := make([]Key, 0, len(keys))
+	signers := make([]ssh.Signer, 0, len(keys))
+	for _, key := range keys {
+		signer, err := key.Data.Signer()
+		if err != nil {
+			log.Printf("SSH agent skipping item %d: %v", key.ItemID, err)
+			continue
+		}
+		usable = append(usable, key)
+		signers = append(signers, signer)
+	}
+	return usable, signers, nil
+}
+
+func (a *vaultAgent) List() ([]*agent.Key, error) {
+	keys, signers, err := a.signers()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*agent.Key, len(signers))
+	for i, signer := range signers {
+		comment := keys[i].Data.Comment
+		if comment == "" {
+			comment = keys[i].Title
+		}
+		public := signer.PublicKey()
+		list[i] = &agent.Key{Format: public.Type(), Blob: public.Marshal(), Comment: comment}
+	}
+	return list, nil
+}
+
+func (a *vaultAgent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return a.SignWithFlags(key, data, 0)
+}
+
+func (a *vaultAgent) SignWithFlags(key ssh.PublicKey, data []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	keys, signers, err := a.signers()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, signer := range signers {
+		if !bytes.Equal(signer.PublicKey().Marshal(), key.Marshal()) {
+			continue
+		}
+
+		allowed := a.s.ask(a.ctx, ConfirmRequest{
+			ItemID:      keys[i].ItemID,
+			Title:       keys[i].Title,
+			Comment:     keys[i].Data.Comment,
+			Fingerprint: ssh.FingerprintSHA256(key),
+		})
+		if !allowed {
+			return nil, ErrDenied
+		}
+
+		// The flags only pick the hash for RSA keys
+		algorithm := ""
+		switch {
+		case key.Type() != ssh.KeyAlgoRSA:
+		case flags&agent.SignatureFlagRsaSha256 != 0:
+			algorithm = ssh.KeyAlgoRSASHA256
+		case flags&agent.SignatureFlagRsaSha512 != 0:
+			algorithm = ssh.KeyAlgoRSASHA512
+		}
+		if algorithmSigner, ok := signer.(ssh.AlgorithmSigner); ok && algorithm != "" {
+			return algorithmSigner.SignWithAlgorithm(rand.Reader, data, algorithm)
+		}
+		return signer.Sign(rand.Reader, data)
+	}
+	return nil, fmt.Errorf("no key in the vault matches %s", ssh.FingerprintSHA256(key))
+}
+
+func (a *vaultAgent) Signers() ([]ssh.Signer, error) {
+	return nil, ErrReadOnly
+}
+
+func (a *vaultAgent) Add(agent.AddedKey) error   { return ErrReadOnly }
+func (a *vaultAgent) Remove(ssh.PublicKey) error { return ErrReadOnly }
+func (a *vaultAgent) RemoveAll() error           { return ErrReadOnly }
+func (a *vaultAgent) Lock([]byte) error          { return ErrReadOnly }
+func (a *vaultAgent) Unlock([]byte) error        { return ErrReadOnly }
+
+func (a *vaultAgent) Extension(string, []byte) ([]byte, error) {
+	return nil, agent.ErrExtensionUnsupported
+}
@@ -0,0 +1,125 @@
+package sshagent
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// startAgent serves one generated key and answers every confirmation with allow
+func startAgent(t *testing.T, alg KeyAlgorithm, allow bool) (agent.ExtendedAgent, ssh.PublicKey, string) {
+	t.Helper()
+
+	data, err := GenerateKey(alg, 2048, "dev@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := data.Validate(); err != nil {
+		t.Fatalf("generated key does not validate: %v", err)
+	}
+	public, _, _, _, err := ssh.ParseAuthorizedKey([]byte(data.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var server *Server
+	server = NewServer(
+		func(context.Context) ([]Key, error) { return []Key{{ItemID: 7, Title: "Deploy key", Data: data}}, nil },
+		func(req ConfirmRequest) {
+			if req.ItemID != 7 || req.Fingerprint != ssh.FingerprintSHA256(public) {
+				t.Errorf("unexpected confirmation request %+v", req)
+			}
+			go server.Resolve(req.ID, allow)
+		},
+	)
+
+	// Unix socket paths are short on some systems, so avoid the long test temp dir
+	dir, err := os.MkdirTemp("", "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "agent.sock")
+	if err := server.Start(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		server.Stop()
+		os.RemoveAll(dir)
+	})
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return agent.NewClient(conn), public, path
+}
+
+func TestAgentSigns(t *testing.T) {
+	for _, alg := range []KeyAlgorithm{Ed25519, RSA} {
+		client, public, _ := startAgent(t, alg, true)
+
+		keys, err := client.List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 1 || keys[0].Comment != "dev@example.com" {
+			t.Fatalf("%s: List() = %v", alg, keys)
+		}
+
+		data := []byte("session")
+		sig, err := client.SignWithFlags(public, data, agent.SignatureFlagRsaSha256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := public.Verify(data, sig); err != nil {
+			t.Fatalf("%s: signature does not verify: %v", alg, err)
+		}
+	}
+}
+
+func TestAgentDenied(t *testing.T) {
+	client, public, _ := startAgent(t, Ed25519, false)
+
+	if _, err := client.Sign(public, []byte("session")); err == nil {
+		t.Fatal("Sign succeeded after the user denied it")
+	}
+}
+
+func TestAgentIsReadOnly(t *testing.T) {
+	client, public, _ := startAgent(t, Ed25519, true)
+
+	if err := client.Remove(public); err == nil {
+		t.Fatal("Remove succeeded")
+	}
+}
+
+func TestStopRemovesSocket(t *testing.T) {
+	server := NewServer(func(context.Context) ([]Key, error) { return nil, nil }, func(ConfirmRequest) {})
+	dir, err := os.MkdirTemp("", "agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "agent.sock")
+
+	if err := server.Start(path); err != nil {
+		t.Fatal(err)
+	}
+	if !server.Status().Running {
+		t.Fatal("agent is not running after Start")
+	}
+	server.Stop()
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("socket still exists after Stop: %v", err)
+	}
+	if server.Status().Running {
+		t.Fatal("agent still running after Stop")
+	}
+}
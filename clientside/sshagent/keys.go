@@ -0,0 +1,80 @@
+package sshagent
+
+import (
+	"Modsec/clientside/schema"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// KeyAlgorithm is the kind of key GenerateKey creates
+type KeyAlgorithm string
+
+const (
+	Ed25519 KeyAlgorithm = "ed25519"
+	RSA     KeyAlgorithm = "rsa"
+)
+
+// AllKeyAlgorithms is bound to the frontend so it gets a matching TypeScript enum
+var AllKeyAlgorithms = []struct {
+	Value  KeyAlgorithm
+	TSName string
+}{
+	{Ed25519, "ED25519"},
+	{RSA, "RSA"},
+}
+
+// DefaultRSABits is used when GenerateKey is asked for an RSA key without a size
+const DefaultRSABits = 3072
+
+// GenerateKey creates a new key pair. bits only applies to RSA and must be 2048, 3072 or 4096.
+func GenerateKey(alg KeyAlgorithm, bits int, comment string) (*schema.SSHKey, error) {
+	var private crypto.Signer
+	switch alg {
+	case Ed25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+		private = key
+	case RSA:
+		if bits == 0 {
+			bits = DefaultRSABits
+		}
+		if bits != 2048 && bits != 3072 && bits != 4096 {
+			return nil, fmt.Errorf("RSA keys must be 2048, 3072 or 4096 bits, got %d", bits)
+		}
+		key, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+		private = key
+	default:
+		return nil, fmt.Errorf("unknown key algorithm %q", alg)
+	}
+
+	block, err := ssh.MarshalPrivateKey(private, comment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	public, err := ssh.NewPublicKey(private.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	authorized := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(public)))
+	if comment != "" {
+		authorized += " " + comment
+	}
+	return &schema.SSHKey{
+		PrivateKey: string(pem.EncodeToMemory(block)),
+		PublicKey:  authorized,
+		Comment:    comment,
+	}, nil
+}
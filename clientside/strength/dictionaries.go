@@ -0,0 +1,37 @@
+package strength
+
+import (
+	_ "embed"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// Ranked word lists, one entry per line from most to least common
+var (
+	//go:embed passwords.txt
+	passwordList string
+	//go:embed words.txt
+	wordList string
+)
+
+// rankedDictionary maps a lowercase word to its rank, 1 being the most common
+type rankedDictionary map[string]int
+
+// dictionaries are the built-in ranked dictionaries by name
+var dictionaries = map[string]rankedDictionary{
+	"passwords": buildRanked(strings.Fields(passwordList)),
+	"english":   buildRanked(append(strings.Fields(wordList), bip39.GetWordList()...)),
+}
+
+// buildRanked ranks words by their position, keeping the first rank of duplicates
+func buildRanked(words []string) rankedDictionary {
+	ranked := make(rankedDictionary, len(words))
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if _, ok := ranked[word]; !ok && word != "" {
+			ranked[word] = i + 1
+		}
+	}
+	return ranked
+}
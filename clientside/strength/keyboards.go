@@ -0,0 +1,86 @@
+package strength
+
+import "strings"
+
+// Keyboard layouts, one token per key holding its unshifted and shifted character
+const (
+	qwertyLayout = `
+` + "`~" + ` 1! 2@ 3# 4$ 5% 6^ 7& 8* 9( 0) -_ =+
+    qQ wW eE rR tT yY uU iI oO pP [{ ]} \|
+     aA sS dD fF gG hH jJ kK lL ;: '"
+      zZ xX cC vV bB nN mM ,< .> /?
+`
+	keypadLayout = `
+  / * -
+7 8 9 +
+4 5 6
+1 2 3
+  0 .
+`
+)
+
+// shiftedChars are the characters typed with shift on a qwerty keyboard
+const shiftedChars = `~!@#$%^&*()_+QWERTYUIOP{}|ASDFGHJKL:"ZXCVBNM<>?`
+
+// keyboard maps each character to the keys next to it, one entry per direction with "" where there is
+// no key, so a change of index between two steps is a turn
+type keyboard struct {
+	name      string
+	adjacency map[rune][]string
+	starts    float64 // Number of keys a pattern can start on
+	degree    float64 // Average number of neighbours per key
+}
+
+var keyboards = []*keyboard{
+	buildKeyboard("qwerty", qwertyLayout, true),
+	buildKeyboard("keypad", keypadLayout, false),
+}
+
+type point struct{ x, y int }
+
+// buildKeyboard lays the tokens of layout out on a grid. Rows of a slanted keyboard are each shifted by
+// half a key, which gives every key six neighbours; a keypad is aligned and has eight.
+func buildKeyboard(name, layout string, slanted bool) *keyboard {
+	tokens := map[point]string{}
+	unit := 0
+	for y, line := range strings.Split(layout, "\n") {
+		slant := 0
+		if slanted {
+			slant = y - 1
+		}
+		offset := 0
+		for _, token := range strings.Fields(line) {
+			unit = len(token) + 1
+			index := strings.Index(line[offset:], token) + offset
+			offset = index + len(token)
+			tokens[point{(index - slant) / unit, y}] = token
+		}
+	}
+
+	kb := &keyboard{name: name, adjacency: map[rune][]string{}}
+	neighbours := 0
+	for p, token := range tokens {
+		var around []point
+		if slanted {
+			around = []point{{p.x - 1, p.y}, {p.x, p.y - 1}, {p.x + 1, p.y - 1}, {p.x + 1, p.y}, {p.x, p.y + 1}, {p.x - 1, p.y + 1}}
+		} else {
+			around = []point{{p.x - 1, p.y}, {p.x - 1, p.y - 1}, {p.x, p.y - 1}, {p.x + 1, p.y - 1},
+				{p.x + 1, p.y}, {p.x + 1, p.y + 1}, {p.x, p.y + 1}, {p.x - 1, p.y + 1}}
+		}
+		adjacent := make([]string, len(around))
+		for i, q := range around {
+			adjacent[i] = tokens[q]
+		}
+		for _, ch := range token {
+			kb.adjacency[ch] = adjacent
+			for _, a := range adjacent {
+				if a != "" {
+					neighbours++
+				}
+			}
+		}
+	}
+	kb.starts = float64(len(kb.adjacency))
+	kb.degree = float64(neighbours) / kb.starts
+	return kb
+}
@@ -0,0 +1,536 @@
+package strength
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Pattern names
+const (
+	patternDictionary = "dictionary"
+	patternSpatial    = "spatial"
+	patternRepeat     = "repeat"
+	patternSequence   = "sequence"
+	patternDate       = "date"
+	patternYear       = "year"
+	patternBruteforce = "bruteforce"
+)
+
+// match is a substring password[i:j+1] recognised as a guessable pattern
+type match struct {
+	pattern string
+	i, j    int
+	token   string
+
+	// Dictionary matches
+	dictionary string
+	word       string
+	rank       int
+	reversed   bool
+	l33t       bool
+	sub        map[rune]rune // Substituted character to the letter it stands for
+
+	// Spatial matches
+	keyboard *keyboard
+	turns    int
+	shifted  int
+
+	// Repeat matches
+	baseToken   string
+	baseGuesses float64
+	repeats     int
+
+	// Sequence matches
+	space     float64
+	ascending bool
+
+	// Date and year matches
+	year      int
+	separator bool
+
+	guesses float64 // Cached by estimateGuesses
+}
+
+// omnimatch runs every matcher over password and returns the matches ordered by position
+func omnimatch(password []rune, dicts map[string]rankedDictionary) []*match {
+	var matches []*match
+	matches = append(matches, dictionaryMatch(password, dicts)...)
+	matches = append(matches, reverseDictionaryMatch(password, dicts)...)
+	matches = append(matches, l33tMatch(password, dicts)...)
+	matches = append(matches, spatialMatch(password)...)
+	matches = append(matches, repeatMatch(password, dicts)...)
+	matches = append(matches, sequenceMatch(password)...)
+	matches = append(matches, yearMatch(password)...)
+	matches = append(matches, dateMatch(password)...)
+	sortMatches(matches)
+	return matches
+}
+
+func sortMatches(matches []*match) {
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].i != matches[b].i {
+			return matches[a].i < matches[b].i
+		}
+		return matches[a].j < matches[b].j
+	})
+}
+
+// dictionaryMatch finds every substring of password that is a dictionary word, ignoring case
+func dictionaryMatch(password []rune, dicts map[string]rankedDictionary) []*match {
+	lower := []rune(strings.ToLower(string(password)))
+	if len(lower) != len(password) {
+		lower = password
+	}
+
+	var matches []*match
+	for name, dict := range dicts {
+		for i := range lower {
+			for j := i; j < len(lower); j++ {
+				word := string(lower[i : j+1])
+				if rank, ok := dict[word]; ok {
+					matches = append(matches, &match{
+						pattern: patternDictionary, i: i, j: j, token: string(password[i : j+1]),
+						dictionary: name, word: word, rank: rank,
+					})
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// reverseDictionaryMatch finds dictionary words written backwards
+func reverseDictionaryMatch(password []rune, dicts map[string]rankedDictionary) []*match {
+	n := len(password)
+	matches := dictionaryMatch(reverse(password), dicts)
+	for _, m := range matches {
+		m.token = string(reverse([]rune(m.token)))
+		m.reversed = true
+		m.i, m.j = n-1-m.j, n-1-m.i
+	}
+	return matches
+}
+
+func reverse(r []rune) []rune {
+	out := make([]rune, len(r))
+	for i, c := range r {
+		out[len(r)-1-i] = c
+	}
+	return out
+}
+
+// l33tTable lists the characters commonly typed in place of a letter
+var l33tTable = map[rune][]rune{
+	'a': {'4', '@'},
+	'b': {'8'},
+	'c': {'(', '{', '[', '<'},
+	'e': {'3'},
+	'g': {'6', '9'},
+	'i': {'1', '!', '|'},
+	'l': {'1', '|', '7'},
+	'o': {'0'},
+	's': {'$', '5'},
+	't': {'+', '7'},
+	'x': {'%'},
+	'z': {'2'},
+}
+
+// maxL33tSubs bounds how many substitution tables are tried for one password
+const maxL33tSubs = 64
+
+// l33tMatch finds dictionary words written with substitutions such as p4ssw0rd
+func l33tMatch(password []rune, dicts map[string]rankedDictionary) []*match {
+	var matches []*match
+	for _, sub := range l33tSubs(password) {
+		subbed := make([]rune, len(password))
+		for i, c := range password {
+			if letter, ok := sub[c]; ok {
+				subbed[i] = letter
+			} else {
+				subbed[i] = c
+			}
+		}
+
+		for _, m := range dictionaryMatch(subbed, dicts) {
+			token := password[m.i : m.j+1]
+			if len(token) < 2 || strings.ToLower(string(token)) == m.word {
+				continue
+			}
+			// Keep only the substitutions used in this token
+			used := map[rune]rune{}
+			for _, c := range token {
+				if letter, ok := sub[c]; ok {
+					used[c] = letter
+				}
+			}
+			if len(used) == 0 {
+				continue
+			}
+			m.token = string(token)
+			m.l33t = true
+			m.sub = used
+			matches = append(matches, m)
+		}
+	}
+	return dedupe(matches)
+}
+
+// l33tSubs returns the substitution tables to try. A character that can stand for more than one letter
+// gives one table per letter.
+func l33tSubs(password []rune) []map[rune]rune {
+	candidates := map[rune][]rune{}
+	for letter, subs := range l33tTable {
+		for _, s := range subs {
+			if containsRune(password, s) && !containsRune(candidates[s], letter) {
+				candidates[s] = append(candidates[s], letter)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	keys := make([]rune, 0, len(candidates))
+	for s := range candidates {
+		keys = append(keys, s)
+		sort.Slice(candidates[s], func(a, b int) bool { return candidates[s][a] < candidates[s][b] })
+	}
+	sort.Slice(keys, func(a, b int) bool { return keys[a] < keys[b] })
+
+	subs := []map[rune]rune{{}}
+	for _, s := range keys {
+		var next []map[rune]rune
+		for _, sub := range subs {
+			for _, letter := range candidates[s] {
+				extended := make(map[rune]rune, len(sub)+1)
+				for k, v := range sub {
+					extended[k] = v
+				}
+				extended[s] = letter
+				next = append(next, extended)
+				if len(next) >= maxL33tSubs {
+					break
+				}
+			}
+			if len(next) >= maxL33tSubs {
+				break
+			}
+		}
+		subs = next
+	}
+	return subs
+}
+
+func containsRune(r []rune, c rune) bool {
+	for _, x := range r {
+		if x == c {
+			return true
+		}
+	}
+	return false
+}
+
+// dedupe drops matches found more than once with the same position and word
+func dedupe(matches []*match) []*match {
+	seen := map[string]bool{}
+	var out []*match
+	for _, m := range matches {
+		key := m.dictionary + "\x00" + m.word + "\x00" + strconv.Itoa(m.i) + "\x00" + strconv.Itoa(m.j)
+		if !seen[key] {
+			seen[key] = true
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// spatialMatch finds runs of at least three neighbouring keys on each keyboard
+func spatialMatch(password []rune) []*match {
+	var matches []*match
+	for _, kb := range keyboards {
+		i := 0
+		for i < len(password)-1 {
+			j := i + 1
+			lastDirection, turns, shifted := -1, 0, 0
+			if kb.name == "qwerty" && strings.ContainsRune(shiftedChars, password[i]) {
+				shifted = 1
+			}
+			for {
+				found := false
+				if j < len(password) {
+					cur := password[j]
+					for direction, adjacent := range kb.adjacency[password[j-1]] {
+						if k := strings.IndexRune(adjacent, cur); adjacent != "" && k >= 0 {
+							found = true
+							if k > 0 {
+								shifted++
+							}
+							if direction != lastDirection {
+								turns++
+								lastDirection = direction
+							}
+							break
+						}
+					}
+				}
+				if found {
+					j++
+					continue
+				}
+				if j-i > 2 {
+					matches = append(matches, &match{
+						pattern: patternSpatial, i: i, j: j - 1, token: string(password[i:j]),
+						keyboard: kb, turns: turns, shifted: shifted,
+					})
+				}
+				i = j
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// repeatMatch finds a substring repeated back to back, such as aaa or abcabc. The repeated unit is
+// scored on its own, so "passwordpassword" costs little more than "password".
+func repeatMatch(password []rune, dicts map[string]rankedDictionary) []*match {
+	var matches []*match
+	n := len(password)
+	for i := 0; i < n-1; {
+		bestEnd, bestBase := i, 0
+		for base := 1; i+2*base <= n; base++ {
+			end := i + base
+			for end+base <= n && string(password[end:end+base]) == string(password[i:i+base]) {
+				end += base
+			}
+			if end-i >= 2*base && end > bestEnd {
+				bestEnd, bestBase = end, base
+			}
+		}
+		if bestBase == 0 {
+			i++
+			continue
+		}
+
+		base := password[i : i+bestBase]
+		matches = append(matches, &match{
+			pattern: patternRepeat, i: i, j: bestEnd - 1, token: string(password[i:bestEnd]),
+			baseToken: string(base), baseGuesses: mostGuessable(base, omnimatch(base, dicts)).guesses,
+			repeats: (bestEnd - i) / bestBase,
+		})
+		i = bestEnd
+	}
+	return matches
+}
+
+// maxSequenceDelta is the largest step between characters still counted as a sequence
+const maxSequenceDelta = 5
+
+// sequenceMatch finds runs with a constant step, such as abc, 9753 or ZYX
+func sequenceMatch(password []rune) []*match {
+	var matches []*match
+	emit := func(i, j, delta int) {
+		if j-i < 2 || delta == 0 || delta > maxSequenceDelta || delta < -maxSequenceDelta {
+			return
+		}
+		token := password[i : j+1]
+		space := 26.0
+		switch s := string(token); {
+		case strings.ToLower(s) == s && isLetters(token):
+		case strings.ToUpper(s) == s && isLetters(token):
+		case isDigits(token):
+			space = 10
+		}
+		matches = append(matches, &match{
+			pattern: patternSequence, i: i, j: j, token: string(token), space: space, ascending: delta > 0,
+		})
+	}
+
+	if len(password) < 3 {
+		return nil
+	}
+	i, lastDelta := 0, 0
+	for k := 1; k < len(password); k++ {
+		delta := int(password[k]) - int(password[k-1])
+		if k == 1 {
+			lastDelta = delta
+		}
+		if delta == lastDelta {
+			continue
+		}
+		emit(i, k-1, lastDelta)
+		i, lastDelta = k-1, delta
+	}
+	emit(i, len(password)-1, lastDelta)
+	return matches
+}
+
+func isLetters(r []rune) bool {
+	for _, c := range r {
+		if !unicode.IsLetter(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(r []rune) bool {
+	for _, c := range r {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Date ranges
+const (
+	minYear      = 1000
+	maxYear      = 2050
+	minYearSpace = 20
+)
+
+// referenceYear is the year dates are compared against
+var referenceYear = time.Now().Year()
+
+var yearPattern = regexp.MustCompile(`19\d\d|20[0-4]\d`)
+
+// yearMatch finds recent years
+func yearMatch(password []rune) []*match {
+	var matches []*match
+	s := string(password)
+	for _, loc := range yearPattern.FindAllStringIndex(s, -1) {
+		i := len([]rune(s[:loc[0]]))
+		year, _ := strconv.Atoi(s[loc[0]:loc[1]])
+		matches = append(matches, &match{
+			pattern: patternYear, i: i, j: i + 3, token: s[loc[0]:loc[1]], year: year,
+		})
+	}
+	return matches
+}
+
+// dateSplits lists where to cut a run of digits of each length into day, month and year
+var dateSplits = map[int][][2]int{
+	4: {{1, 2}, {2, 3}},
+	5: {{1, 3}, {2, 3}},
+	6: {{1, 2}, {2, 4}, {4, 5}},
+	7: {{1, 3}, {2, 3}, {4, 5}, {4, 6}},
+	8: {{2, 4}, {4, 6}},
+}
+
+var separatedDate = regexp.MustCompile(`^(\d{1,4})([\s/\\_.-])(\d{1,2})([\s/\\_.-])(\d{1,4})$`)
+
+// dateMatch finds dates written as 4 to 8 digits or with separators, such as 13.5.1990 or 19901305
+func dateMatch(password []rune) []*match {
+	var matches []*match
+	for i := range password {
+		for j := i + 3; j < len(password) && j <= i+9; j++ {
+			token := password[i : j+1]
+			if isDigits(token) {
+				if len(token) > 8 {
+					continue
+				}
+				best, found := 0, false
+				for _, split := range dateSplits[len(token)] {
+					a, _ := strconv.Atoi(string(token[:split[0]]))
+					b, _ := strconv.Atoi(string(token[split[0]:split[1]]))
+					c, _ := strconv.Atoi(string(token[split[1]:]))
+					if year, ok := dateYear(a, b, c); ok {
+						if !found || abs(year-referenceYear) < abs(best-referenceYear) {
+							best, found = year, true
+						}
+					}
+				}
+				if found {
+					matches = append(matches, &match{pattern: patternDate, i: i, j: j, token: string(token), year: best})
+				}
+				continue
+			}
+
+			parts := separatedDate.FindStringSubmatch(string(token))
+			if parts == nil || parts[2] != parts[4] {
+				continue
+			}
+			a, _ := strconv.Atoi(parts[1])
+			b, _ := strconv.Atoi(parts[3])
+			c, _ := strconv.Atoi(parts[5])
+			if year, ok := dateYear(a, b, c); ok {
+				matches = append(matches, &match{pattern: patternDate, i: i, j: j, token: string(token), year: year, separator: true})
+			}
+		}
+	}
+
+	// Drop dates inside longer dates
+	var out []*match
+	for _, m := range matches {
+		inside := false
+		for _, other := range matches {
+			if other != m && other.i <= m.i && other.j >= m.j {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// dateYear reads a, b and c as a day, month and year in any common order and returns the year
+func dateYear(a, b, c int) (int, bool) {
+	ints := [3]int{a, b, c}
+	if b > 31 || b <= 0 {
+		return 0, false
+	}
+	over12, over31, under1 := 0, 0, 0
+	for _, v := range ints {
+		if (v > 99 && v < minYear) || v > maxYear {
+			return 0, false
+		}
+		if v > 31 {
+			over31++
+		}
+		if v > 12 {
+			over12++
+		}
+		if v <= 0 {
+			under1++
+		}
+	}
+	if over31 >= 2 || over12 == 3 || under1 >= 2 {
+		return 0, false
+	}
+
+	candidates := []struct{ year, x, y int }{{c, a, b}, {a, b, c}}
+	for _, cand := range candidates {
+		if cand.year >= minYear && cand.year <= maxYear && dayMonth(cand.x, cand.y) {
+			return cand.year, true
+		}
+	}
+	for _, cand := range candidates {
+		if cand.year <= 99 && dayMonth(cand.x, cand.y) {
+			if cand.year > 50 {
+				return cand.year + 1900, true
+			}
+			return cand.year + 2000, true
+		}
+	}
+	return 0, false
+}
+
+// dayMonth reports whether x and y are a day and a month in either order
+func dayMonth(x, y int) bool {
+	return (x >= 1 && x <= 31 && y >= 1 && y <= 12) || (y >= 1 && y <= 31 && x >= 1 && x <= 12)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
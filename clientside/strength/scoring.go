@@ -0,0 +1,270 @@
+package strength
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Guess estimate constants
+const (
+	bruteforceCardinality = 10
+	minGuessesSingleChar  = 10
+	minGuessesMultiChar   = 50
+	// minGuessesBeforeGrowingSequence keeps a long run of short matches from beating plain bruteforce
+	minGuessesBeforeGrowingSequence = 10000
+)
+
+// sequence is the cheapest way found to guess a password as consecutive matches
+type sequence struct {
+	guesses float64
+	matches []*match
+}
+
+// mostGuessable finds the sequence of non-overlapping matches covering password that needs the fewest
+// guesses. Gaps are covered with bruteforce matches. A sequence of l matches costs l! times the product of
+// their guesses, plus a penalty that grows with l, because the attacker also has to guess the order and
+// the number of patterns.
+func mostGuessable(password []rune, matches []*match) sequence {
+	n := len(password)
+	if n == 0 {
+		return sequence{guesses: 1}
+	}
+
+	byEnd := make([][]*match, n)
+	for _, m := range matches {
+		byEnd[m.j] = append(byEnd[m.j], m)
+	}
+
+	// For each end position k and sequence length l, the best last match, product of guesses and total
+	best := make([]map[int]*match, n)
+	pi := make([]map[int]float64, n)
+	total := make([]map[int]float64, n)
+	for k := range best {
+		best[k], pi[k], total[k] = map[int]*match{}, map[int]float64{}, map[int]float64{}
+	}
+
+	update := func(m *match, l int) {
+		k := m.j
+		p := estimateGuesses(m, n)
+		if l > 1 {
+			p *= pi[m.i-1][l-1]
+		}
+		g := factorial(l)*p + math.Pow(minGuessesBeforeGrowingSequence, float64(l-1))
+		for otherL, otherG := range total[k] {
+			if otherL <= l && otherG <= g {
+				return
+			}
+		}
+		best[k][l], pi[k][l], total[k][l] = m, p, g
+	}
+
+	bruteforce := func(i, j int) *match {
+		return &match{pattern: patternBruteforce, i: i, j: j, token: string(password[i : j+1])}
+	}
+
+	for k := 0; k < n; k++ {
+		for _, m := range byEnd[k] {
+			if m.i > 0 {
+				for l := range best[m.i-1] {
+					update(m, l+1)
+				}
+			} else {
+				update(m, 1)
+			}
+		}
+
+		update(bruteforce(0, k), 1)
+		for i := 1; i <= k; i++ {
+			for l, last := range best[i-1] {
+				// Two bruteforce matches in a row are never better than one
+				if last.pattern != patternBruteforce {
+					update(bruteforce(i, k), l+1)
+				}
+			}
+		}
+	}
+
+	// Walk back from the cheapest sequence ending at the last character
+	bestL, bestG := 0, math.Inf(1)
+	for l, g := range total[n-1] {
+		if g < bestG || (g == bestG && l < bestL) {
+			bestL, bestG = l, g
+		}
+	}
+	seq := make([]*match, bestL)
+	for k, l := n-1, bestL; k >= 0 && l > 0; l-- {
+		m := best[k][l]
+		seq[l-1] = m
+		k = m.i - 1
+	}
+	return sequence{guesses: bestG, matches: seq}
+}
+
+// estimateGuesses returns how many guesses m takes, never less than the floor for a substring of its length
+func estimateGuesses(m *match, passwordLen int) float64 {
+	if m.guesses != 0 {
+		return m.guesses
+	}
+
+	length := len([]rune(m.token))
+	floor := 1.0
+	if length < passwordLen {
+		floor = minGuessesMultiChar
+		if length == 1 {
+			floor = minGuessesSingleChar
+		}
+	}
+
+	var guesses float64
+	switch m.pattern {
+	case patternBruteforce:
+		guesses = math.Pow(bruteforceCardinality, float64(length))
+		if math.IsInf(guesses, 1) {
+			guesses = math.MaxFloat64
+		}
+		minimum := float64(minGuessesMultiChar + 1)
+		if length == 1 {
+			minimum = minGuessesSingleChar + 1
+		}
+		guesses = math.Max(guesses, minimum)
+	case patternDictionary:
+		guesses = float64(m.rank) * uppercaseVariations(m.token) * l33tVariations(m)
+		if m.reversed {
+			guesses *= 2
+		}
+	case patternSpatial:
+		guesses = spatialGuesses(m)
+	case patternRepeat:
+		guesses = m.baseGuesses * float64(m.repeats)
+	case patternSequence:
+		guesses = sequenceGuesses(m)
+	case patternYear:
+		guesses = math.Max(float64(abs(m.year-referenceYear)), minYearSpace)
+	case patternDate:
+		guesses = math.Max(float64(abs(m.year-referenceYear)), minYearSpace) * 365
+		if m.separator {
+			guesses *= 4
+		}
+	}
+
+	m.guesses = math.Max(guesses, floor)
+	return m.guesses
+}
+
+// uppercaseVariations counts the ways the capitals of word could have been placed. Capitalising the
+// first or last letter, or every letter, only doubles the guesses.
+func uppercaseVariations(word string) float64 {
+	upper, lower := 0, 0
+	for _, c := range word {
+		switch {
+		case unicode.IsUpper(c):
+			upper++
+		case unicode.IsLower(c):
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 1
+	}
+	r := []rune(word)
+	if lower == 0 || (upper == 1 && (unicode.IsUpper(r[0]) || unicode.IsUpper(r[len(r)-1]))) {
+		return 2
+	}
+	variations := 0.0
+	for i := 1; i <= upper && i <= lower; i++ {
+		variations += binomial(upper+lower, i)
+	}
+	return variations
+}
+
+// l33tVariations counts the ways the substitutions of m could have been applied
+func l33tVariations(m *match) float64 {
+	if !m.l33t {
+		return 1
+	}
+	variations := 1.0
+	lower := strings.ToLower(m.token)
+	for subbed, letter := range m.sub {
+		s, u := 0, 0
+		for _, c := range lower {
+			switch c {
+			case subbed:
+				s++
+			case letter:
+				u++
+			}
+		}
+		if s == 0 || u == 0 {
+			variations *= 2
+			continue
+		}
+		possibilities := 0.0
+		for i := 1; i <= s && i <= u; i++ {
+			possibilities += binomial(s+u, i)
+		}
+		variations *= possibilities
+	}
+	return variations
+}
+
+// spatialGuesses counts keyboard patterns of the same length with at most as many turns
+func spatialGuesses(m *match) float64 {
+	length := len([]rune(m.token))
+	guesses := 0.0
+	for i := 2; i <= length; i++ {
+		for j := 1; j <= m.turns && j <= i-1; j++ {
+			guesses += binomial(i-1, j-1) * m.keyboard.starts * math.Pow(m.keyboard.degree, float64(j))
+		}
+	}
+	if m.shifted > 0 {
+		unshifted := length - m.shifted
+		if unshifted == 0 {
+			guesses *= 2
+		} else {
+			variations := 0.0
+			for i := 1; i <= m.shifted && i <= unshifted; i++ {
+				variations += binomial(m.shifted+unshifted, i)
+			}
+			guesses *= variations
+		}
+	}
+	return guesses
+}
+
+// sequenceGuesses prices a sequence by its starting character, direction and length
+func sequenceGuesses(m *match) float64 {
+	first := []rune(m.token)[0]
+	base := m.space
+	if strings.ContainsRune("aAzZ019", first) {
+		base = 4
+	}
+	if !m.ascending {
+		base *= 2
+	}
+	return base * float64(len([]rune(m.token)))
+}
+
+func binomial(n, k int) float64 {
+	if k > n {
+		return 0
+	}
+	if k == 0 {
+		return 1
+	}
+	r := 1.0
+	for d := 1; d <= k; d++ {
+		r *= float64(n)
+		r /= float64(d)
+		n--
+	}
+	return r
+}
+
+func factorial(n int) float64 {
+	f := 1.0
+	for i := 2; i <= n; i++ {
+		f *= float64(i)
+	}
+	return f
+}
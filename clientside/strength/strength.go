@@ -0,0 +1,241 @@
+package strength
+
+// Password strength estimation in the style of zxcvbn: the password is split into the cheapest sequence
+// of guessable patterns (dictionary words, l33t spellings, keyboard walks, repeats, sequences and dates)
+// and scored by how many guesses an attacker who knows those patterns would need.
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// maxLength bounds how much of a password is analysed; the rest is treated as random characters
+const maxLength = 100
+
+// Score thresholds, each the number of guesses a password needs to reach the next score
+var scoreThresholds = [4]float64{1e3, 1e6, 1e8, 1e10}
+
+// Guessing rates per second for the crack time scenarios
+const (
+	rateOnlineThrottled   = 100.0 / 3600
+	rateOnlineUnthrottled = 10
+	rateOfflineSlowHash   = 1e4
+	rateOfflineFastHash   = 1e10
+)
+
+// Result is the strength estimate of a password
+type Result struct {
+	Score        int        `json:"score"` // 0 (too guessable) to 4 (very unguessable)
+	Guesses      float64    `json:"guesses"`
+	GuessesLog10 float64    `json:"guessesLog10"`
+	CrackTimes   CrackTimes `json:"crackTimes"`
+	Feedback     Feedback   `json:"feedback"`
+	Patterns     []Pattern  `json:"patterns"`
+}
+
+// CrackTimes estimates how long guessing the password would take in common attack scenarios
+type CrackTimes struct {
+	OnlineThrottled   CrackTime `json:"onlineThrottled"`   // A rate limited login form
+	OnlineUnthrottled CrackTime `json:"onlineUnthrottled"` // A login form without rate limiting
+	OfflineSlowHash   CrackTime `json:"offlineSlowHash"`   // A stolen vault protected by a slow KDF
+	OfflineFastHash   CrackTime `json:"offlineFastHash"`   // A stolen fast hash on many GPUs
+}
+
+// CrackTime is a duration in seconds and its human readable form
+type CrackTime struct {
+	Seconds float64 `json:"seconds"`
+	Display string  `json:"display"`
+}
+
+// Feedback tells the user why a password is weak and how to improve it
+type Feedback struct {
+	Warning     string   `json:"warning"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// Pattern is one part of the password as the estimator read it
+type Pattern struct {
+	Pattern string  `json:"pattern"`
+	Token   string  `json:"token"`
+	Guesses float64 `json:"guesses"`
+}
+
+// Estimate scores password. userInputs are words an attacker would try first, such as the email address.
+func Estimate(password string, userInputs ...string) Result {
+	runes := []rune(password)
+	tail := 0
+	if len(runes) > maxLength {
+		tail = len(runes) - maxLength
+		runes = runes[:maxLength]
+	}
+
+	dicts := make(map[string]rankedDictionary, len(dictionaries)+1)
+	for name, dict := range dictionaries {
+		dicts[name] = dict
+	}
+	dicts["user_inputs"] = buildRanked(userInputs)
+
+	seq := mostGuessable(runes, omnimatch(runes, dicts))
+	guesses := seq.guesses * math.Pow(bruteforceCardinality, float64(tail))
+	if math.IsInf(guesses, 1) {
+		guesses = math.MaxFloat64
+	}
+
+	result := Result{
+		Score:        score(guesses),
+		Guesses:      guesses,
+		GuessesLog10: math.Log10(guesses),
+		CrackTimes: CrackTimes{
+			OnlineThrottled:   crackTime(guesses / rateOnlineThrottled),
+			OnlineUnthrottled: crackTime(guesses / rateOnlineUnthrottled),
+			OfflineSlowHash:   crackTime(guesses / rateOfflineSlowHash),
+			OfflineFastHash:   crackTime(guesses / rateOfflineFastHash),
+		},
+		Patterns: make([]Pattern, len(seq.matches)),
+	}
+	for i, m := range seq.matches {
+		result.Patterns[i] = Pattern{Pattern: m.pattern, Token: m.token, Guesses: m.guesses}
+	}
+	result.Feedback = feedback(result.Score, seq.matches)
+	return result
+}
+
+func score(guesses float64) int {
+	// The margin keeps a password at exactly a threshold from scoring up
+	const delta = 5
+	for s, threshold := range scoreThresholds {
+		if guesses < threshold+delta {
+			return s
+		}
+	}
+	return len(scoreThresholds)
+}
+
+func crackTime(seconds float64) CrackTime {
+	return CrackTime{Seconds: seconds, Display: displayTime(seconds)}
+}
+
+func displayTime(seconds float64) string {
+	const (
+		minute  = 60
+		hour    = minute * 60
+		day     = hour * 24
+		month   = day * 31
+		year    = month * 12
+		century = year * 100
+	)
+	units := []struct {
+		size float64
+		name string
+	}{{year, "year"}, {month, "month"}, {day, "day"}, {hour, "hour"}, {minute, "minute"}, {1, "second"}}
+
+	switch {
+	case seconds < 1:
+		return "less than a second"
+	case seconds >= century:
+		return "centuries"
+	}
+	for _, u := range units {
+		if seconds >= u.size {
+			n := int(math.Round(seconds / u.size))
+			if n == 1 {
+				return fmt.Sprintf("1 %s", u.name)
+			}
+			return fmt.Sprintf("%d %ss", n, u.name)
+		}
+	}
+	return "less than a second"
+}
+
+// feedback explains the weakest part of a password that scored 2 or less
+func feedback(score int, matches []*match) Feedback {
+	if len(matches) == 0 {
+		return Feedback{Suggestions: []string{
+			"Use a few words, avoid common phrases",
+			"No need for symbols, digits, or uppercase letters",
+		}}
+	}
+	if score > 2 {
+		return Feedback{Suggestions: []string{}}
+	}
+
+	longest := matches[0]
+	for _, m := range matches[1:] {
+		if len(m.token) > len(longest.token) {
+			longest = m
+		}
+	}
+	f := matchFeedback(longest, len(matches) == 1)
+	f.Suggestions = append([]string{"Add another word or two. Uncommon words are better."}, f.Suggestions...)
+	return f
+}
+
+func matchFeedback(m *match, soleMatch bool) Feedback {
+	switch m.pattern {
+	case patternDictionary:
+		return dictionaryFeedback(m, soleMatch)
+	case patternSpatial:
+		warning := "Short keyboard patterns are easy to guess"
+		if m.turns == 1 {
+			warning = "Straight rows of keys are easy to guess"
+		}
+		return Feedback{Warning: warning, Suggestions: []string{"Use a longer keyboard pattern with more turns"}}
+	case patternRepeat:
+		warning := `Repeats like "abcabcabc" are only slightly harder to guess than "abc"`
+		if len([]rune(m.baseToken)) == 1 {
+			warning = `Repeats like "aaa" are easy to guess`
+		}
+		return Feedback{Warning: warning, Suggestions: []string{"Avoid repeated words and characters"}}
+	case patternSequence:
+		return Feedback{Warning: "Sequences like abc or 6543 are easy to guess", Suggestions: []string{"Avoid sequences"}}
+	case patternYear:
+		return Feedback{Warning: "Recent years are easy to guess", Suggestions: []string{
+			"Avoid recent years", "Avoid years that are associated with you",
+		}}
+	case patternDate:
+		return Feedback{Warning: "Dates are often easy to guess", Suggestions: []string{
+			"Avoid dates and years that are associated with you",
+		}}
+	}
+	return Feedback{Suggestions: []string{}}
+}
+
+func dictionaryFeedback(m *match, soleMatch bool) Feedback {
+	var f Feedback
+	switch m.dictionary {
+	case "passwords":
+		switch {
+		case soleMatch && !m.l33t && !m.reversed && m.rank <= 10:
+			f.Warning = "This is a top-10 common password"
+		case soleMatch && !m.l33t && !m.reversed && m.rank <= 100:
+			f.Warning = "This is a top-100 common password"
+		case soleMatch:
+			f.Warning = "This is a very common password"
+		default:
+			f.Warning = "This is similar to a commonly used password"
+		}
+	case "english":
+		if soleMatch {
+			f.Warning = "A word by itself is easy to guess"
+		}
+	case "user_inputs":
+		f.Warning = "Avoid using your name or email address in your password"
+	}
+
+	word := []rune(m.token)
+	switch {
+	case unicode.IsUpper(word[0]) && strings.ToLower(string(word[1:])) == string(word[1:]):
+		f.Suggestions = append(f.Suggestions, "Capitalization doesn't help very much")
+	case strings.ToUpper(m.token) == m.token && strings.ToLower(m.token) != m.token:
+		f.Suggestions = append(f.Suggestions, "All-uppercase is almost as easy to guess as all-lowercase")
+	}
+	if m.reversed && len(word) >= 4 {
+		f.Suggestions = append(f.Suggestions, "Reversed words aren't much harder to guess")
+	}
+	if m.l33t {
+		f.Suggestions = append(f.Suggestions, "Predictable substitutions like '@' instead of 'a' don't help very much")
+	}
+	return f
+}
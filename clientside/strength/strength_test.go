@@ -0,0 +1,98 @@
+package strength
+
+import (
+	"testing"
+)
+
+func TestEstimatePatterns(t *testing.T) {
+	tests := []struct {
+		password string
+		pattern  string
+		maxScore int
+	}{
+		{"password", patternDictionary, 0},
+		{"Password1", patternDictionary, 0},
+		{"p@ssw0rd", patternDictionary, 0},
+		{"drowssap", patternDictionary, 0},
+		{"zxcvfr", patternSpatial, 1},
+		{"aaaaaaaaaaaa", patternRepeat, 0},
+		{"abcdefghij", patternSequence, 0},
+		{"97531", patternSequence, 0},
+		{"13.05.1990", patternDate, 1},
+		{"19900513", patternDate, 1},
+	}
+	for _, tt := range tests {
+		r := Estimate(tt.password)
+		if r.Score > tt.maxScore {
+			t.Errorf("%q: score = %d, want at most %d", tt.password, r.Score, tt.maxScore)
+		}
+		found := false
+		for _, p := range r.Patterns {
+			if p.Pattern == tt.pattern {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%q: patterns = %+v, want a %s match", tt.password, r.Patterns, tt.pattern)
+		}
+		if r.Feedback.Warning == "" {
+			t.Errorf("%q: no warning for a weak password", tt.password)
+		}
+	}
+}
+
+func TestEstimateStrong(t *testing.T) {
+	for _, password := range []string{"kX9#vQ2!mPz7$wL", "walrus-pickle-nebula-drizzle-fox"} {
+		r := Estimate(password)
+		if r.Score < 3 {
+			t.Errorf("%q: score = %d, want at least 3 (%+v)", password, r.Score, r.Patterns)
+		}
+		if r.Feedback.Warning != "" {
+			t.Errorf("%q: unexpected warning %q", password, r.Feedback.Warning)
+		}
+	}
+}
+
+func TestEstimateUserInputs(t *testing.T) {
+	without := Estimate("hunsaker1987")
+	with := Estimate("hunsaker1987", "hunsaker")
+	if with.Guesses >= without.Guesses {
+		t.Fatalf("user input did not lower guesses: %g >= %g", with.Guesses, without.Guesses)
+	}
+	if with.Feedback.Warning == "" {
+		t.Fatal("expected a warning about personal information")
+	}
+}
+
+func TestEstimateEmpty(t *testing.T) {
+	r := Estimate("")
+	if r.Score != 0 || r.Guesses != 1 {
+		t.Fatalf("empty password: score %d, guesses %g", r.Score, r.Guesses)
+	}
+}
+
+func TestDisplayTime(t *testing.T) {
+	tests := map[float64]string{
+		0.5:         "less than a second",
+		1:           "1 second",
+		90:          "2 minutes",
+		3 * 86400:   "3 days",
+		1e12:        "centuries",
+		400 * 86400: "1 year",
+	}
+	for seconds, want := range tests {
+		if got := displayTime(seconds); got != want {
+			t.Errorf("displayTime(%g) = %q, want %q", seconds, got, want)
+		}
+	}
+}
+
+func TestKeyboardAdjacency(t *testing.T) {
+	q := keyboards[0].adjacency['q']
+	want := []string{"", "1!", "2@", "wW", "aA", ""}
+	for i := range want {
+		if q[i] != want[i] {
+			t.Fatalf("qwerty neighbours of q = %q, want %q", q, want)
+		}
+	}
+}
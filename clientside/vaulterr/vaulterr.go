@@ -0,0 +1,20 @@
+package vaulterr
+
+// Errors about the vault and its items. They are reported by the key holder and the item schemas and
+// mapped to error codes by the client, which sits below both, so they live here rather than in either.
+
+import "errors"
+
+var (
+	// ErrInvalidItem is returned when item data does not pass validation
+	ErrInvalidItem = errors.New("invalid item")
+	// ErrUnknownType is returned for an item type this client has no schema for
+	ErrUnknownType = errors.New("unknown item type")
+
+	// ErrLocked is returned when key material is requested while the vault is locked
+	ErrLocked = errors.New("vault is locked")
+	// ErrWrongPassword is returned by Unlock when the password does not open the vault key
+	ErrWrongPassword = errors.New("incorrect password")
+	// ErrNoVault is returned by Unlock when nobody has logged in yet
+	ErrNoVault = errors.New("no vault to unlock, please log in")
+)
@@ -0,0 +1,125 @@
+import React, { useState } from "react";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { Alert, AlertDescription } from "@/components/ui/alert";
+import { AlertCircle } from "lucide-react";
+import { ChangeMasterPassword } from "@/wailsjs/go/main/App";
+import { PasswordStrengthMeter, usePasswordStrength } from "@/components/PasswordStrength/PasswordStrengthMeter";
+import { cn } from "@/lib/utils";
+
+interface ChangePasswordFormProps {
+  onBack: () => void;
+}
+
+export function ChangePasswordForm({ onBack }: ChangePasswordFormProps) {
+  const [currentPassword, setCurrentPassword] = useState("");
+  const [newPassword, setNewPassword] = useState("");
+  const [confirmPassword, setConfirmPassword] = useState("");
+  const [message, setMessage] = useState({ type: "", message: "" });
+  const [isChanging, setIsChanging] = useState(false);
+  const passwordCheck = usePasswordStrength(newPassword);
+
+  const handleSubmit = async () => {
+    if (!currentPassword) {
+      setMessage({ type: "error", message: "Current password is required" });
+      return;
+    }
+
+    if (!passwordCheck?.acceptable) {
+      setMessage({
+        type: "error",
+        message: passwordCheck?.message || "Password does not meet security requirements",
+      });
+      return;
+    }
+
+    if (newPassword !== confirmPassword) {
+      setMessage({ type: "error", message: "Passwords do not match" });
+      return;
+    }
+
+    try {
+      setIsChanging(true);
+      setMessage({ type: "info", message: "Changing master password..." });
+
+      await ChangeMasterPassword(currentPassword, newPassword);
+
+      setCurrentPassword("");
+      setNewPassword("");
+      setConfirmPassword("");
+      setMessage({
+        type: "success",
+        message: "Master password changed. Other devices have to log in again with the new password.",
+      });
+      setTimeout(onBack, 2000);
+    } catch (error) {
+      console.error("Change password error:", error);
+      setMessage({ type: "error", message: `Password change failed: ${String(error)}` });
+    } finally {
+      setIsChanging(false);
+    }
+  };
+
+  return (
+    <div className="space-y-4">
+      <div className="flex justify-between items-center">
+        <h3 className="text-lg font-medium">Change Master Password</h3>
+        <Button variant="ghost" size="sm" onClick={onBack}>
+          Back
+        </Button>
+      </div>
+
+      {message.message && (
+        <Alert variant={message.type === "error" ? "destructive" : "default"}>
+          <AlertCircle className={cn("h-4 w-4 mr-2", message.type === "success" ? "text-green-500" : "")} />
+          <AlertDescription className={message.type === "success" ? "text-green-500" : ""}>
+            {message.message}
+          </AlertDescription>
+        </Alert>
+      )}
+
+      <div className="space-y-2">
+        <Label htmlFor="current-password">Current Password</Label>
+        <Input
+          id="current-password"
+          type="password"
+          value={currentPassword}
+          onChange={(e) => setCurrentPassword(e.target.value)}
+          placeholder="Enter current password"
+          disabled={isChanging}
+        />
+      </div>
+
+      <div className="space-y-2">
+        <Label htmlFor="change-new-password">New Password</Label>
+        <Input
+          id="change-new-password"
+          type="password"
+          value={newPassword}
+          onChange={(e) => setNewPassword(e.target.value)}
+          placeholder="Enter new password"
+          disabled={isChanging}
+        />
+
+        {newPassword.length > 0 && <PasswordStrengthMeter password={newPassword} check={passwordCheck} />}
+      </div>
+
+      <div className="space-y-2">
+        <Label htmlFor="change-confirm-password">Confirm Password</Label>
+        <Input
+          id="change-confirm-password"
+          type="password"
+          value={confirmPassword}
+          onChange={(e) => setConfirmPassword(e.target.value)}
+          placeholder="Confirm new password"
+          disabled={isChanging}
+        />
+      </div>
+
+      <Button className="w-full" onClick={handleSubmit} disabled={isChanging}>
+        {isChanging ? "Processing..." : "Change Password"}
+      </Button>
+    </div>
+  );
+}
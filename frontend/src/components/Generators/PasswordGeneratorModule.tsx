@@ -3,98 +3,168 @@ import { Input } from "@/components/ui/input";
 import { Label } from "@/components/ui/label";
 import { Slider } from "@/components/ui/slider";
 import { Switch } from "@/components/ui/switch";
-import { useState, useEffect } from "react"; // Add useEffect import
+import { Tabs, TabsList, TabsTrigger } from "@/components/ui/tabs";
+import { useState, useEffect } from "react";
+import { toast } from "sonner";
+import { GeneratePassword, GeneratePassphrase } from "@/wailsjs/go/main/App";
+import { generator } from "@/wailsjs/go/models";
 
 interface PasswordGeneratorModuleProps {
   onGenerate: (password: string) => void;
 }
 
+type Mode = "password" | "passphrase";
+
 export function PasswordGeneratorModule({ onGenerate }: PasswordGeneratorModuleProps) {
-  const [length, setLength] = useState(16);
+  const [mode, setMode] = useState<Mode>("password");
+  const [length, setLength] = useState(20);
   const [options, setOptions] = useState({
     uppercase: true,
     numbers: true,
     symbols: true,
+    excludeAmbiguous: false,
   });
+  const [words, setWords] = useState(6);
+  const [phrase, setPhrase] = useState({
+    separator: "-",
+    capitalize: false,
+    includeNumber: false,
+  });
+  const [entropy, setEntropy] = useState<number | null>(null);
 
-  const generatePassword = () => {
-    const uppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ";
-    const lowercase = "abcdefghijklmnopqrstuvwxyz";
-    const numbers = "0123456789";
-    const symbols = "!@#$%^&*_+-|.";
-
-    let chars = lowercase;
-    if (options.uppercase) chars += uppercase;
-    if (options.numbers) chars += numbers;
-    if (options.symbols) chars += symbols;
-
-    let generatedPassword = "";
-    const array = new Uint32Array(length);
-    crypto.getRandomValues(array);
-    for (let i = 0; i < length; i++) {
-      generatedPassword += chars.charAt(array[i] % chars.length);
+  const generatePassword = async () => {
+    try {
+      const result =
+        mode === "password"
+          ? await GeneratePassword(
+              generator.PasswordPolicy.createFrom({
+                length,
+                lowercase: true,
+                uppercase: options.uppercase,
+                digits: options.numbers,
+                symbols: options.symbols,
+                minLowercase: 1,
+                minUppercase: options.uppercase ? 1 : 0,
+                minDigits: options.numbers ? 1 : 0,
+                minSymbols: options.symbols ? 1 : 0,
+                excludeAmbiguous: options.excludeAmbiguous,
+              })
+            )
+          : await GeneratePassphrase(
+              generator.PassphrasePolicy.createFrom({
+                words,
+                separator: phrase.separator,
+                capitalize: phrase.capitalize,
+                includeNumber: phrase.includeNumber,
+              })
+            );
+      setEntropy(result.entropy);
+      onGenerate(result.value);
+    } catch (err) {
+      toast.error(String(err));
     }
-
-    onGenerate(generatedPassword);
   };
 
-  // Add useEffect to generate password on mount and when options/length change
+  // Generate on mount and whenever the policy changes
   useEffect(() => {
     generatePassword();
-  }, [length, options.uppercase, options.numbers, options.symbols]);
+  }, [mode, length, options, words, phrase]);
+
+  const toggle = (key: keyof typeof options) => (checked: boolean) =>
+    setOptions((prev) => ({ ...prev, [key]: checked }));
 
   return (
     <div className="space-y-8" data-generator="password">
-      <div className="space-y-2">
-        <Label>Password Length: {length}</Label>
-        <Slider
-          value={[length]}
-          onValueChange={([value]) => {
-            setLength(value);
-            generatePassword();
-          }}
-          max={32}
-          min={8}
-          step={1}
-        />
-      </div>
+      <Tabs value={mode} onValueChange={(value) => setMode(value as Mode)} className="w-full">
+        <TabsList className="grid w-full grid-cols-2 bg-accent">
+          <TabsTrigger value="password" className="data-[state=active]:bg-secondary">
+            Characters
+          </TabsTrigger>
+          <TabsTrigger value="passphrase" className="data-[state=active]:bg-secondary">
+            Passphrase
+          </TabsTrigger>
+        </TabsList>
+      </Tabs>
 
-      <div className="space-y-4">
-        <div className="flex items-center justify-between">
-          <Label htmlFor="uppercase">Include Uppercase</Label>
-          <Switch
-            id="uppercase"
-            checked={options.uppercase}
-            onCheckedChange={(checked) => {
-              setOptions((prev) => ({ ...prev, uppercase: checked }));
-              generatePassword();
-            }}
-          />
-        </div>
-        <div className="flex items-center justify-between">
-          <Label htmlFor="numbers">Include Numbers</Label>
-          <Switch
-            id="numbers"
-            checked={options.numbers}
-            onCheckedChange={(checked) => {
-              setOptions((prev) => ({ ...prev, numbers: checked }));
-              generatePassword();
-            }}
-          />
-        </div>
-        <div className="flex items-center justify-between">
-          <Label htmlFor="symbols">Include Symbols</Label>
-          <Switch
-            id="symbols"
-            checked={options.symbols}
-            onCheckedChange={(checked) => {
-              setOptions((prev) => ({ ...prev, symbols: checked }));
-              generatePassword();
-            }}
-          />
-        </div>
-      </div>
-      <Button 
+      {mode === "password" ? (
+        <>
+          <div className="space-y-2">
+            <Label>Password Length: {length}</Label>
+            <Slider
+              value={[length]}
+              onValueChange={([value]) => setLength(value)}
+              max={64}
+              min={8}
+              step={1}
+            />
+          </div>
+
+          <div className="space-y-4">
+            <div className="flex items-center justify-between">
+              <Label htmlFor="uppercase">Include Uppercase</Label>
+              <Switch id="uppercase" checked={options.uppercase} onCheckedChange={toggle("uppercase")} />
+            </div>
+            <div className="flex items-center justify-between">
+              <Label htmlFor="numbers">Include Numbers</Label>
+              <Switch id="numbers" checked={options.numbers} onCheckedChange={toggle("numbers")} />
+            </div>
+            <div className="flex items-center justify-between">
+              <Label htmlFor="symbols">Include Symbols</Label>
+              <Switch id="symbols" checked={options.symbols} onCheckedChange={toggle("symbols")} />
+            </div>
+            <div className="flex items-center justify-between">
+              <Label htmlFor="ambiguous">Avoid Ambiguous Characters</Label>
+              <Switch
+                id="ambiguous"
+                checked={options.excludeAmbiguous}
+                onCheckedChange={toggle("excludeAmbiguous")}
+              />
+            </div>
+          </div>
+        </>
+      ) : (
+        <>
+          <div className="space-y-2">
+            <Label>Words: {words}</Label>
+            <Slider value={[words]} onValueChange={([value]) => setWords(value)} max={12} min={3} step={1} />
+          </div>
+
+          <div className="space-y-4">
+            <div className="flex items-center justify-between">
+              <Label htmlFor="separator">Separator</Label>
+              <Input
+                id="separator"
+                value={phrase.separator}
+                maxLength={3}
+                onChange={(e) => setPhrase((prev) => ({ ...prev, separator: e.target.value }))}
+                className="w-16 text-center font-mono"
+              />
+            </div>
+            <div className="flex items-center justify-between">
+              <Label htmlFor="capitalize">Capitalize Words</Label>
+              <Switch
+                id="capitalize"
+                checked={phrase.capitalize}
+                onCheckedChange={(checked) => setPhrase((prev) => ({ ...prev, capitalize: checked }))}
+              />
+            </div>
+            <div className="flex items-center justify-between">
+              <Label htmlFor="include-number">Include a Number</Label>
+              <Switch
+                id="include-number"
+                checked={phrase.includeNumber}
+                onCheckedChange={(checked) => setPhrase((prev) => ({ ...prev, includeNumber: checked }))}
+              />
+            </div>
+          </div>
+        </>
+      )}
+
+      {entropy !== null && (
+        <p className="text-xs text-muted-foreground">About {Math.floor(entropy)} bits of entropy</p>
+      )}
+
+      <Button
         data-action="generate"
         onClick={generatePassword}
         className="hidden"
@@ -103,4 +173,4 @@ export function PasswordGeneratorModule({ onGenerate }: PasswordGeneratorModuleP
       </Button>
     </div>
   );
-}
\ No newline at end of file
+}
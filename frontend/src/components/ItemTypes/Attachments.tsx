@@ -0,0 +1,113 @@
+import { useEffect, useState } from "react";
+import { Download, Loader2, Paperclip, Plus, Trash2 } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { toast } from "sonner";
+import { AddAttachment, DeleteAttachment, GetAttachments, SaveAttachment } from "@/wailsjs/go/main/App";
+import { service } from "@/wailsjs/go/models";
+
+interface AttachmentsProps {
+  itemId: number;
+  isEditing: boolean;
+}
+
+const formatSize = (bytes: number) => {
+  if (bytes < 1024) return `${bytes} B`;
+  if (bytes < 1024 * 1024) return `${(bytes / 1024).toFixed(1)} KB`;
+  return `${(bytes / (1024 * 1024)).toFixed(1)} MB`;
+};
+
+export function Attachments({ itemId, isEditing }: AttachmentsProps) {
+  const [attachments, setAttachments] = useState<service.Attachment[]>([]);
+  const [busy, setBusy] = useState<number | "add" | null>(null);
+
+  const load = () =>
+    GetAttachments(itemId)
+      .then((result) => setAttachments(result ?? []))
+      .catch((err) => console.error("Failed to load attachments:", err));
+
+  useEffect(() => {
+    setAttachments([]);
+    load();
+  }, [itemId]);
+
+  const handleAdd = async () => {
+    setBusy("add");
+    try {
+      const added = await AddAttachment(itemId);
+      if (added) {
+        toast.success(`Attached ${added.name}`);
+        await load();
+      }
+    } catch (err) {
+      toast.error(`Failed to attach file: ${err}`);
+    } finally {
+      setBusy(null);
+    }
+  };
+
+  const handleSave = async (file: service.Attachment) => {
+    setBusy(file.attachmentId);
+    try {
+      const path = await SaveAttachment(itemId, file.attachmentId, file.name);
+      if (path) toast.success(`Saved to ${path}`);
+    } catch (err) {
+      toast.error(`Failed to save attachment: ${err}`);
+    } finally {
+      setBusy(null);
+    }
+  };
+
+  const handleDelete = async (file: service.Attachment) => {
+    setBusy(file.attachmentId);
+    try {
+      await DeleteAttachment(file.attachmentId);
+      setAttachments((prev) => prev.filter((a) => a.attachmentId !== file.attachmentId));
+    } catch (err) {
+      toast.error(`Failed to delete attachment: ${err}`);
+    } finally {
+      setBusy(null);
+    }
+  };
+
+  return (
+    <div className="space-y-2">
+      <div className="flex items-center justify-between">
+        <div className="flex items-center gap-1.5 text-sm font-medium text-muted-foreground">
+          <Paperclip className="h-4 w-4" />
+          <span>Attachments</span>
+        </div>
+        <Button variant="ghost" size="sm" className="h-7 px-2 text-xs" onClick={handleAdd} disabled={busy !== null}>
+          {busy === "add" ? <Loader2 className="h-3 w-3 mr-1 animate-spin" /> : <Plus className="h-3 w-3 mr-1" />}
+          Attach file
+        </Button>
+      </div>
+
+      {attachments.length > 0 && (
+        <div className="space-y-1 pl-6">
+          {attachments.map((file) => (
+            <div key={file.attachmentId} className="flex items-center gap-2 rounded-md bg-secondary/30 px-2 py-1">
+              <span className="flex-1 truncate text-sm">{file.name}</span>
+              <span className="shrink-0 text-xs text-muted-foreground">{formatSize(file.size)}</span>
+              {busy === file.attachmentId ? (
+                <Loader2 className="h-3.5 w-3.5 mx-2 animate-spin text-muted-foreground" />
+              ) : (
+                <>
+                  <Button size="icon" variant="ghost" className="h-7 w-7" title="Save a decrypted copy"
+                    onClick={() => handleSave(file)} disabled={busy !== null}>
+                    <Download className="h-3.5 w-3.5" />
+                  </Button>
+                  {isEditing && (
+                    <Button size="icon" variant="ghost" className="h-7 w-7 text-red-500" title="Delete attachment"
+                      onClick={() => handleDelete(file)} disabled={busy !== null}>
+                      <Trash2 className="h-3.5 w-3.5" />
+                    </Button>
+                  )}
+                </>
+              )}
+            </div>
+          ))}
+        </div>
+      )}
+    </div>
+  );
+}
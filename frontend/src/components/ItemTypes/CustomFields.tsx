@@ -0,0 +1,127 @@
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { PasswordInput } from "@/components/ui/password-input";
+import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from "@/components/ui/select";
+import { Tooltip, TooltipContent, TooltipTrigger } from "@/components/ui/tooltip";
+import { Check, Copy, Plus, Trash2 } from "lucide-react";
+import { schema } from "@/wailsjs/go/models";
+
+interface CustomFieldsProps {
+  fields: schema.CustomField[];
+  isEditing: boolean;
+  onChange: (fields: schema.CustomField[]) => void;
+  copyToClipboard: (field: string, value: string) => void;
+  copiedField: string | null;
+}
+
+const kindLabels: Record<schema.FieldKind, string> = {
+  [schema.FieldKind.TEXT]: "Text",
+  [schema.FieldKind.HIDDEN]: "Hidden",
+  [schema.FieldKind.URL]: "URL",
+  [schema.FieldKind.DATE]: "Date",
+  [schema.FieldKind.TOTP]: "One-time password",
+};
+
+// CustomFields shows the user-defined fields of an item, in the order they were added
+export function CustomFields({ fields, isEditing, onChange, copyToClipboard, copiedField }: CustomFieldsProps) {
+  const update = (index: number, patch: Partial<schema.CustomField>) => {
+    onChange(fields.map((field, i) => (i === index ? schema.CustomField.createFrom({ ...field, ...patch }) : field)));
+  };
+
+  const remove = (index: number) => {
+    onChange(fields.filter((_, i) => i !== index));
+  };
+
+  const add = () => {
+    onChange([...fields, schema.CustomField.createFrom({ label: "", kind: schema.FieldKind.TEXT, value: "" })]);
+  };
+
+  if (!isEditing && fields.length === 0) return null;
+
+  return (
+    <div className="space-y-4">
+      {fields.map((field, index) => {
+        const copyKey = `custom-${index}`;
+        const concealed = field.kind === schema.FieldKind.HIDDEN || field.kind === schema.FieldKind.TOTP;
+
+        return (
+          <div key={index} className="space-y-2">
+            {isEditing ? (
+              <div className="flex gap-2">
+                <Input
+                  placeholder="Label"
+                  className="bg-secondary"
+                  value={field.label}
+                  onChange={(e) => update(index, { label: e.target.value })}
+                />
+                <Select value={field.kind} onValueChange={(kind) => update(index, { kind: kind as schema.FieldKind })}>
+                  <SelectTrigger className="w-[180px] bg-secondary">
+                    <SelectValue />
+                  </SelectTrigger>
+                  <SelectContent>
+                    {Object.values(schema.FieldKind).map((kind) => (
+                      <SelectItem key={kind} value={kind}>{kindLabels[kind]}</SelectItem>
+                    ))}
+                  </SelectContent>
+                </Select>
+                <Button type="button" variant="ghost" size="sm" className="h-10 w-10 p-0" onClick={() => remove(index)}>
+                  <Trash2 className="h-4 w-4 text-muted-foreground" />
+                </Button>
+              </div>
+            ) : (
+              <label className="text-sm font-medium text-muted-foreground">{field.label}</label>
+            )}
+            <div className="relative group">
+              {concealed ? (
+                <PasswordInput
+                  isEditing={isEditing}
+                  placeholder={field.kind === schema.FieldKind.TOTP ? "Secret or otpauth:// link" : "Value"}
+                  value={field.value}
+                  onChange={(e) => update(index, { value: e.target.value })}
+                />
+              ) : (
+                <Input
+                  type={field.kind === schema.FieldKind.DATE ? "date" : "text"}
+                  placeholder={field.kind === schema.FieldKind.URL ? "https://" : "Value"}
+                  className={`${isEditing ? "bg-secondary" : "bg-background cursor-pointer hover:border-primary/50 transition-colors"} pr-10`}
+                  value={field.value}
+                  readOnly={!isEditing}
+                  onChange={(e) => update(index, { value: e.target.value })}
+                  onClick={() => !isEditing && field.value && copyToClipboard(copyKey, field.value)}
+                />
+              )}
+              {!isEditing && field.value && (
+                <Tooltip>
+                  <TooltipTrigger asChild>
+                    <div
+                      className={`
+                        absolute ${concealed ? "right-10" : "right-3"} top-1/2 -translate-y-1/2
+                        text-muted-foreground hover:text-primary transition-all duration-200
+                        ${copiedField === copyKey ? "opacity-100" : "opacity-0 group-hover:opacity-80"}
+                        cursor-pointer
+                      `}
+                      onClick={(e) => {
+                        e.stopPropagation();
+                        copyToClipboard(copyKey, field.value);
+                      }}
+                    >
+                      {copiedField === copyKey ? <Check className="h-4 w-4 text-green-500" /> : <Copy className="h-4 w-4" />}
+                    </div>
+                  </TooltipTrigger>
+                  <TooltipContent side="left" className="bg-primary text-primary-foreground">
+                    <p>{copiedField === copyKey ? "Copied!" : "Click to copy"}</p>
+                  </TooltipContent>
+                </Tooltip>
+              )}
+            </div>
+          </div>
+        );
+      })}
+      {isEditing && (
+        <Button type="button" variant="outline" size="sm" onClick={add}>
+          <Plus className="h-4 w-4 mr-1" /> Add field
+        </Button>
+      )}
+    </div>
+  );
+}
@@ -0,0 +1,99 @@
+import { useEffect, useState } from "react";
+import { ChevronDown, ChevronRight, Copy, Check, Eye, EyeOff, History, RotateCcw } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Label } from "@/components/ui/label";
+import { toast } from "sonner";
+import { GetPasswordHistory, RestorePassword } from "@/wailsjs/go/main/App";
+import { schema } from "@/wailsjs/go/models";
+
+interface PasswordHistoryProps {
+  itemId: number;
+  currentPassword: string; // Reloads the history once the password changes
+  isEditing: boolean;
+  onRestored: () => void;
+  copyToClipboard: (field: string, value: string) => void;
+  copiedField: string | null;
+}
+
+export function PasswordHistory({
+  itemId,
+  currentPassword,
+  isEditing,
+  onRestored,
+  copyToClipboard,
+  copiedField,
+}: PasswordHistoryProps) {
+  const [open, setOpen] = useState(false);
+  const [history, setHistory] = useState<schema.PasswordEntry[]>([]);
+  const [revealed, setRevealed] = useState<number | null>(null);
+
+  useEffect(() => {
+    if (!open) return;
+    GetPasswordHistory(itemId)
+      .then((entries) => setHistory(entries ?? []))
+      .catch((err) => console.error("Failed to load password history:", err));
+  }, [open, itemId, currentPassword]);
+
+  useEffect(() => {
+    setOpen(false);
+    setRevealed(null);
+  }, [itemId]);
+
+  const handleRestore = async (index: number) => {
+    try {
+      await RestorePassword(itemId, index);
+      toast.success("Previous password restored");
+      setRevealed(null);
+      onRestored();
+    } catch (err) {
+      toast.error(`Failed to restore password: ${err}`);
+    }
+  };
+
+  return (
+    <div className="space-y-2">
+      <button
+        type="button"
+        onClick={() => setOpen(!open)}
+        className="flex items-center gap-1.5 text-sm font-medium text-muted-foreground hover:text-foreground"
+      >
+        {open ? <ChevronDown className="h-4 w-4" /> : <ChevronRight className="h-4 w-4" />}
+        <History className="h-4 w-4" />
+        <Label className="cursor-pointer">Password History</Label>
+      </button>
+
+      {open && (
+        <div className="space-y-1 pl-6">
+          {history.length === 0 && (
+            <p className="text-xs text-muted-foreground">No previous passwords</p>
+          )}
+          {history.map((entry, index) => {
+            const field = `history-${index}`;
+            return (
+              <div key={index} className="flex items-center gap-2 rounded-md bg-secondary/30 px-2 py-1">
+                <span className="flex-1 truncate font-mono text-sm">
+                  {revealed === index ? entry.password : "••••••••••"}
+                </span>
+                <span className="shrink-0 text-xs text-muted-foreground">
+                  {new Date(entry.replaced).toLocaleDateString()}
+                </span>
+                <Button size="icon" variant="ghost" className="h-7 w-7"
+                  onClick={() => setRevealed(revealed === index ? null : index)}>
+                  {revealed === index ? <EyeOff className="h-3.5 w-3.5" /> : <Eye className="h-3.5 w-3.5" />}
+                </Button>
+                <Button size="icon" variant="ghost" className="h-7 w-7"
+                  onClick={() => copyToClipboard(field, entry.password)}>
+                  {copiedField === field ? <Check className="h-3.5 w-3.5" /> : <Copy className="h-3.5 w-3.5" />}
+                </Button>
+                <Button size="icon" variant="ghost" className="h-7 w-7" title="Restore this password"
+                  disabled={isEditing} onClick={() => handleRestore(index)}>
+                  <RotateCcw className="h-3.5 w-3.5" />
+                </Button>
+              </div>
+            );
+          })}
+        </div>
+      )}
+    </div>
+  );
+}
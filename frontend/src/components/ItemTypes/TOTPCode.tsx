@@ -0,0 +1,92 @@
+import { useCallback, useEffect, useState } from "react";
+import { Button } from "@/components/ui/button";
+import { Check, Copy, RefreshCw } from "lucide-react";
+import { GetTOTPCode } from "@/wailsjs/go/main/App";
+import { service } from "@/wailsjs/go/models";
+
+interface TOTPCodeProps {
+  itemId: string;
+  otpKey: string;
+  copyToClipboard: (field: string, value: string) => void;
+  copiedField: string | null;
+}
+
+// TOTPCode shows the current one-time code of a saved item. Codes come from Go, the key never leaves it.
+export function TOTPCode({ itemId, otpKey, copyToClipboard, copiedField }: TOTPCodeProps) {
+  const [code, setCode] = useState<service.TOTPCode | null>(null);
+  const [remaining, setRemaining] = useState(0);
+  const [error, setError] = useState<string | null>(null);
+
+  // HOTP codes use up a counter, so only fetch them when asked
+  const counterBased = otpKey.toLowerCase().startsWith("otpauth://hotp");
+
+  const load = useCallback(async () => {
+    try {
+      const next = await GetTOTPCode(parseInt(itemId));
+      setCode(next);
+      setRemaining(next.remaining);
+      setError(null);
+    } catch (err) {
+      setError(String(err));
+    }
+  }, [itemId]);
+
+  useEffect(() => {
+    setCode(null);
+    if (!counterBased) load();
+  }, [load, counterBased, otpKey]);
+
+  // Count down locally and fetch the next code when this one expires
+  useEffect(() => {
+    if (!code || code.period === 0) return;
+    const timer = setInterval(() => {
+      setRemaining((prev) => {
+        if (prev <= 1) {
+          load();
+          return 0;
+        }
+        return prev - 1;
+      });
+    }, 1000);
+    return () => clearInterval(timer);
+  }, [code, load]);
+
+  const formatted = code ? code.code.replace(/^(\d{3,4})(\d{3,4})$/, "$1 $2") : "";
+
+  return (
+    <div className="space-y-2">
+      <label className="text-sm font-medium text-muted-foreground">One-time password</label>
+      <div className="flex items-center gap-3 rounded-md border border-input px-3 py-2">
+        {error ? (
+          <span className="text-sm text-destructive">{error}</span>
+        ) : code ? (
+          <span
+            className="font-mono text-lg tracking-widest cursor-pointer hover:text-primary"
+            onClick={() => copyToClipboard("totp", code.code)}
+          >
+            {formatted}
+          </span>
+        ) : (
+          <span className="text-sm text-muted-foreground">{counterBased ? "No code generated yet" : "Loading..."}</span>
+        )}
+        <div className="ml-auto flex items-center gap-2">
+          {code && code.period > 0 && (
+            <span className={`text-xs tabular-nums ${remaining <= 5 ? "text-destructive" : "text-muted-foreground"}`}>
+              {remaining}s
+            </span>
+          )}
+          {counterBased && (
+            <Button type="button" variant="ghost" size="sm" className="h-7 px-2" onClick={load}>
+              <RefreshCw className="h-4 w-4" />
+            </Button>
+          )}
+          {code && (
+            <Button type="button" variant="ghost" size="sm" className="h-7 px-2" onClick={() => copyToClipboard("totp", code.code)}>
+              {copiedField === "totp" ? <Check className="h-4 w-4 text-green-500" /> : <Copy className="h-4 w-4" />}
+            </Button>
+          )}
+        </div>
+      </div>
+    </div>
+  );
+}
@@ -0,0 +1,134 @@
+import { useEffect, useState } from "react";
+import { ChevronDown, ChevronRight, Eye, EyeOff, GitCompare, RotateCcw } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { toast } from "sonner";
+import { DiffItemVersions, GetItemVersions, RestoreItemVersion } from "@/wailsjs/go/main/App";
+import { service } from "@/wailsjs/go/models";
+
+// Version number that stands for the item as it is now
+const CURRENT_VERSION = 0;
+
+interface VersionHistoryProps {
+  itemId: number;
+  dateModified: Date; // Reloads the versions once the item is saved
+  isEditing: boolean;
+  onRestored: () => void;
+}
+
+export function VersionHistory({ itemId, dateModified, isEditing, onRestored }: VersionHistoryProps) {
+  const [open, setOpen] = useState(false);
+  const [versions, setVersions] = useState<service.AfterItemVersion[]>([]);
+  const [error, setError] = useState<string | null>(null);
+  const [selected, setSelected] = useState<number | null>(null);
+  const [diff, setDiff] = useState<service.ItemDiff | null>(null);
+  const [reveal, setReveal] = useState(false);
+
+  useEffect(() => {
+    setOpen(false);
+    setSelected(null);
+    setDiff(null);
+  }, [itemId]);
+
+  useEffect(() => {
+    if (!open) return;
+    setError(null);
+    GetItemVersions(itemId)
+      .then((result) => setVersions(result ?? []))
+      .catch((err) => setError(String(err)));
+  }, [open, itemId, dateModified]);
+
+  const showDiff = async (version: number) => {
+    if (selected === version) {
+      setSelected(null);
+      setDiff(null);
+      return;
+    }
+    try {
+      setDiff(await DiffItemVersions(itemId, version, CURRENT_VERSION));
+      setSelected(version);
+      setReveal(false);
+    } catch (err) {
+      toast.error(`Failed to compare versions: ${err}`);
+    }
+  };
+
+  const handleRestore = async (version: number) => {
+    try {
+      await RestoreItemVersion(itemId, version);
+      toast.success(`Version ${version} restored`);
+      setSelected(null);
+      setDiff(null);
+      onRestored();
+    } catch (err) {
+      toast.error(`Failed to restore version: ${err}`);
+    }
+  };
+
+  const display = (change: service.FieldChange, value: string) =>
+    !value ? <span className="italic text-muted-foreground">empty</span> : change.sensitive && !reveal ? "••••••••" : value;
+
+  return (
+    <div className="space-y-2 mt-4 pt-3 border-t border-border/30">
+      <button
+        type="button"
+        onClick={() => setOpen(!open)}
+        className="flex items-center gap-1.5 text-xs font-medium text-muted-foreground/70 hover:text-foreground"
+      >
+        {open ? <ChevronDown className="h-3.5 w-3.5" /> : <ChevronRight className="h-3.5 w-3.5" />}
+        Version History
+      </button>
+
+      {open && (
+        <div className="space-y-1 pl-1">
+          {error && <p className="text-xs text-red-500">{error}</p>}
+          {!error && versions.length === 0 && (
+            <p className="text-xs text-muted-foreground">No earlier versions</p>
+          )}
+          {versions.map((version) => (
+            <div key={version.version} className="rounded-md bg-secondary/30">
+              <div className="flex items-center gap-2 px-2 py-1">
+                <span className="w-8 shrink-0 font-mono text-xs text-muted-foreground">v{version.version}</span>
+                <span className="flex-1 truncate text-sm">{version.title}</span>
+                <span className="shrink-0 text-xs text-muted-foreground">
+                  {new Date(version.dateModify).toLocaleString()}
+                </span>
+                <Button size="icon" variant="ghost" className="h-7 w-7" title="Compare with the current item"
+                  onClick={() => showDiff(version.version)}>
+                  <GitCompare className="h-3.5 w-3.5" />
+                </Button>
+                <Button size="icon" variant="ghost" className="h-7 w-7" title="Restore this version"
+                  disabled={isEditing || !version.data} onClick={() => handleRestore(version.version)}>
+                  <RotateCcw className="h-3.5 w-3.5" />
+                </Button>
+              </div>
+
+              {selected === version.version && diff && (
+                <div className="space-y-1 border-t border-border/30 px-2 py-2">
+                  {diff.changes.length === 0 && (
+                    <p className="text-xs text-muted-foreground">Same as the current item</p>
+                  )}
+                  {diff.changes.some((change) => change.sensitive) && (
+                    <button type="button" onClick={() => setReveal(!reveal)}
+                      className="flex items-center gap-1 text-xs text-muted-foreground hover:text-foreground">
+                      {reveal ? <EyeOff className="h-3 w-3" /> : <Eye className="h-3 w-3" />}
+                      {reveal ? "Hide values" : "Show values"}
+                    </button>
+                  )}
+                  {diff.changes.map((change) => (
+                    <div key={change.field} className="grid grid-cols-[8rem_1fr] gap-x-2 text-xs">
+                      <span className="truncate text-muted-foreground">{change.field}</span>
+                      <div className="min-w-0 font-mono">
+                        <div className="truncate text-red-500">- {display(change, change.before)}</div>
+                        <div className="truncate text-emerald-500">+ {display(change, change.after)}</div>
+                      </div>
+                    </div>
+                  ))}
+                </div>
+              )}
+            </div>
+          ))}
+        </div>
+      )}
+    </div>
+  );
+}
@@ -7,6 +7,7 @@ import { InlinePasswordGenerator } from "../Generators/PasswordGeneratorInline";
 import { useState } from "react";
 import { PasswordInput } from "@/components/ui/password-input";
 import { Tooltip, TooltipContent, TooltipTrigger } from "@/components/ui/tooltip";
+import { TOTPCode } from "./TOTPCode";
 
 interface WebsiteFieldsProps {
   formData: WebsiteEntry;
@@ -243,6 +244,29 @@ export function WebsiteFields({
         placeholder="Enter website URL"
       />
       
+      {/* One-time password: the key while editing, the current code otherwise */}
+      {isEditing ? (
+        <div className="space-y-2">
+          <label className="text-sm font-medium text-muted-foreground">One-time password key</label>
+          <PasswordInput
+            name="totp"
+            placeholder="Secret or otpauth:// link"
+            value={formData.totp || ''}
+            onChange={handleChange('totp')}
+            isEditing={isEditing}
+          />
+        </div>
+      ) : (
+        formData.totp && formData.id && (
+          <TOTPCode
+            itemId={formData.id}
+            otpKey={formData.totp}
+            copyToClipboard={copyToClipboard}
+            copiedField={copiedField}
+          />
+        )
+      )}
+
       {/* Notes field with copyable textarea */}
       <CopyableTextarea
         label="Notes"
@@ -5,15 +5,18 @@ import { RegisterPage } from "./Pages/RegisterPage";
 import { Sidebar } from "./Sidebar";
 import { PasswordManager } from "./PasswordManager";
 import { PasswordGenerator } from "./Generators/PasswordGenerator";
+import { Watchtower } from "./Watchtower";
 import { PasswordEditor } from "./PasswordEditor";
 import { PasswordEntry } from "@/types/password";
 import { ColorSettingsProvider } from "@/context/ColorSettingsContext";
 import { useToast } from "@/components/ui/use-toast";
 import { SeedPhraseConfirmationPage } from './Pages/SeedPhraseConfirmationPage';
-import { ShieldIcon } from "lucide-react";
+import { SyncConflictDialog } from './SyncConflictDialog';
+import { SSHConfirmDialog } from './SSHConfirmDialog';
+import { ShieldIcon, WifiOffIcon } from "lucide-react";
 
 export function Layout() {
-  const { isAuthenticated, login, register, isRegistrationComplete, checkSession } = useAuth();
+  const { isAuthenticated, isOffline, login, register, isRegistrationComplete, checkSession } = useAuth();
   const [currentView, setCurrentView] = useState("passwords");
   const [selectedPassword, setSelectedPassword] = useState<PasswordEntry | undefined>();
   const [showRegister, setShowRegister] = useState(false);
@@ -130,17 +133,27 @@ export function Layout() {
         <SeedPhraseConfirmationPage />
       ) : (
         // Main application UI
-        <div className="h-screen bg-[#1E1E1E] overflow-hidden">
-          <div className="grid h-full md:grid-cols-[240px_280px_1fr]">
-            <div className="h-screen overflow-hidden">
+        <div className="h-screen bg-[#1E1E1E] overflow-hidden flex flex-col">
+          {isOffline && (
+            <div className="flex items-center justify-center gap-2 bg-amber-500/15 text-amber-400 text-xs py-1">
+              <WifiOffIcon className="h-3 w-3" />
+              Offline — changes are saved on this device and will sync when the server is reachable
+            </div>
+          )}
+          <SyncConflictDialog onResolved={() => setRefreshCounter(prev => prev + 1)} />
+          <SSHConfirmDialog />
+          <div className="grid flex-1 min-h-0 md:grid-cols-[240px_280px_1fr]">
+            <div className="h-full overflow-hidden">
               <Sidebar 
                 currentView={currentView} 
                 onViewChange={setCurrentView} 
               />
             </div>
-            <div className="border-r border-border h-screen overflow-hidden">
+            <div className="border-r border-border h-full overflow-hidden">
               {currentView === "generator" ? (
                 <PasswordGenerator />
+              ) : currentView === "watchtower" ? (
+                <Watchtower onSelectPassword={handleSelectPassword} refreshTrigger={refreshCounter} />
               ) : (
                 <PasswordManager 
                   currentView={currentView}
@@ -150,7 +163,7 @@ export function Layout() {
                 />
               )}
             </div>
-            <div className="border-0 border-border h-screen overflow-hidden">
+            <div className="border-0 border-border h-full overflow-hidden">
               {selectedPassword ? (
                 <PasswordEditor
                   password={selectedPassword}
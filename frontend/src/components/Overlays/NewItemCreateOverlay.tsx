@@ -2,15 +2,18 @@ import React, { useState, useEffect, useRef } from "react";
 import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
 import { X } from "lucide-react";
-import { CardEntry, CryptoEntry, IdentityEntry, MemoEntry, PasswordEntry, PasswordType, WebsiteEntry } from "@/types/password";
+import { CardEntry, CryptoEntry, IdentityEntry, MemoEntry, PasswordEntry, PasswordType, SSHKeyEntry, WebsiteEntry } from "@/types/password";
 import { WebsiteFields } from "../ItemTypes/WebsiteFields";
 import { IdentityFields } from "../ItemTypes/IdentityFields";
 import { CardFields } from "../ItemTypes/CardFields";
 import { CryptoFields } from "../ItemTypes/CryptoFields";
 import { MemoFields } from "../ItemTypes/MemoFields";
+import { CustomFields } from "../ItemTypes/CustomFields";
+import { SSHKeyFields } from "../ItemTypes/SSHKeyFields";
 import { ScrollArea } from "@/components/ui/scroll-area";
 import { toast } from "@/components/ui/use-toast";
 import { CreateItemClient } from '@/wailsjs/go/main/App';
+import { toItemInput } from "@/lib/items";
 
 interface NewItemCreateOverlayProps {
   type: PasswordType;
@@ -66,18 +69,6 @@ export function NewItemCreateOverlay({ type, onSave, onClose }: NewItemCreateOve
     }
   }, [formData, focusedField]);
 
-  // Map frontend type name to backend type name
-  const mapTypeNameToBackend = (frontendType: PasswordType): string => {
-    switch (frontendType) {
-      case "website": return "login";
-      case "identity": return "identity";
-      case "card": return "credit";
-      case "crypto": return "cryptowallet";
-      case "memo": return "note";
-      default: return frontendType;
-    }
-  };
-
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
 
@@ -93,15 +84,8 @@ export function NewItemCreateOverlay({ type, onSave, onClose }: NewItemCreateOve
     setIsSubmitting(true);
 
     try {
-      // Extract title and type, map type to backend format
-      const { title } = formData;
-      const backendType = mapTypeNameToBackend(type);
-
-      // Remove type and id properties from the data to send
-      const { type: _, id: __, ...itemData } = formData;
-
-      // Call Go CreateItemClient function through Wails
-      const response = await CreateItemClient(title as string, backendType, itemData);
+      // Send only the fields of this item type, the backend validates them
+      const response = await CreateItemClient(toItemInput({ ...formData, type }));
 
       // Create the complete item with response data
       const savedItem = {
@@ -190,6 +174,16 @@ export function NewItemCreateOverlay({ type, onSave, onClose }: NewItemCreateOve
             copiedField={copiedField}
           />
         );
+      case "ssh_key":
+        return (
+          <SSHKeyFields
+            formData={formData as SSHKeyEntry}
+            isEditing={isEditing}
+            setFields={(fields) => setFormData(prev => ({ ...prev, ...fields } as typeof prev))}
+            copyToClipboard={dummyCopyToClipboard}
+            copiedField={copiedField}
+          />
+        );
     }
   };
 
@@ -229,6 +223,13 @@ export function NewItemCreateOverlay({ type, onSave, onClose }: NewItemCreateOve
                   />
                 </div>
                 {renderFields()}
+                <CustomFields
+                  fields={formData.customFields || []}
+                  isEditing={isEditing}
+                  onChange={(customFields) => setFormData(prev => ({ ...prev, customFields }))}
+                  copyToClipboard={dummyCopyToClipboard}
+                  copiedField={copiedField}
+                />
               </div>
             </ScrollArea>
             <div className="flex items-center justify-end gap-2 border-t p-4 flex-shrink-0">
@@ -1,7 +1,20 @@
+import { useEffect, useState } from "react";
 import { Button } from "@/components/ui/button";
-import { Globe, User, CreditCard, Wallet, File, X } from "lucide-react";
+import { Globe, User, CreditCard, Wallet, File, KeyRound, X, LucideIcon } from "lucide-react";
 import { PasswordType } from "@/types/password";
 import { useColorSettings } from "@/context/ColorSettingsContext";
+import { GetItemTypes } from "@/wailsjs/go/main/App";
+import { schema } from "@/wailsjs/go/models";
+
+// Icons for the registered item types, types without one get a plain file icon
+const typeIcons: Record<string, LucideIcon> = {
+  website: Globe,
+  identity: User,
+  card: CreditCard,
+  crypto: Wallet,
+  memo: File,
+  ssh_key: KeyRound,
+};
 
 interface NewItemTypeOverlayProps {
   onSelect: (type: PasswordType) => void;
@@ -11,6 +24,14 @@ interface NewItemTypeOverlayProps {
 export function NewItemTypeOverlay({ onSelect, onClose }: NewItemTypeOverlayProps) {
   // Use the color settings from context instead of hardcoded values
   const { colors } = useColorSettings();
+  const [itemTypes, setItemTypes] = useState<schema.TypeInfo[]>([]);
+
+  // The list of types comes from the registry in Go
+  useEffect(() => {
+    GetItemTypes()
+      .then((types) => setItemTypes(types || []))
+      .catch((error) => console.error("Failed to load item types:", error));
+  }, []);
 
   // Updated helper function to determine icon background and text color
   const getIconStyle = (type: string): React.CSSProperties => {
@@ -48,13 +69,12 @@ export function NewItemTypeOverlay({ onSelect, onClose }: NewItemTypeOverlayProp
     }
   };
 
-  const types = [
-    { type: "website" as PasswordType, icon: Globe, label: "Website", description: "Store login credentials for websites" },
-    { type: "identity" as PasswordType, icon: User, label: "Identity", description: "Save personal identification details" },
-    { type: "card" as PasswordType, icon: CreditCard, label: "Payment Card", description: "Secure your payment card information" },
-    { type: "crypto" as PasswordType, icon: Wallet, label: "Crypto Wallet", description: "Store cryptocurrency wallet details" },
-    { type: "memo" as PasswordType, icon: File, label: "Secure Note", description: "Keep sensitive notes and information" },
-  ];
+  const types = itemTypes.map((info) => ({
+    type: info.type as PasswordType,
+    icon: typeIcons[info.type] || File,
+    label: info.label,
+    description: info.description,
+  }));
 
   return (
     <div 
@@ -14,7 +14,21 @@ import { Switch } from "@/components/ui/switch";
 import { CreditCard, File, Globe, User, Wallet } from "lucide-react";
 import { useColorSettings } from "@/context/ColorSettingsContext";
 import { RecoveryForm } from "@/components/Recovery/RecoveryForm";
+import { ChangePasswordForm } from "@/components/Account/ChangePasswordForm";
 import { RecoverySeedPhraseConfirmation } from "@/components/Recovery/RecoverySeedPhraseConfirmation";
+import {
+  GetAutoLockSettings,
+  GetConnectionSettings,
+  GetSSHAgentStatus,
+  IsKeyRotationPending,
+  RotateVaultKey,
+  StartSSHAgent,
+  StopSSHAgent,
+  UpdateAutoLockSettings,
+  UpdateConnectionSettings,
+} from "@/wailsjs/go/main/App";
+import { config, sshagent } from "@/wailsjs/go/models";
+import { EventsOn } from "@/wailsjs/runtime/runtime";
 
 interface SettingsOverlayProps {
   open: boolean;
@@ -25,18 +39,127 @@ export function SettingsOverlay({ open, onOpenChange }: SettingsOverlayProps) {
   const { colors, updateColor, resetColors } = useColorSettings();
   const [autoLogout, setAutoLogout] = useState(true);
   const [logoutTime, setLogoutTime] = useState(5);
+  const [preferences, setPreferences] = useState<config.Preferences | null>(null);
+  const [historyLimit, setHistoryLimit] = useState(10);
   const [localColors, setLocalColors] = useState(colors);
   const [recoveryMode, setRecoveryMode] = useState(false);
+  const [changePasswordMode, setChangePasswordMode] = useState(false);
   const [seedPhraseConfirmation, setSeedPhraseConfirmation] = useState<string | null>(null);
+  const [connection, setConnection] = useState<config.Config | null>(null);
+  const [connectionStatus, setConnectionStatus] = useState<{ error: boolean; message: string } | null>(null);
+  const [rotationPending, setRotationPending] = useState(false);
+  const [confirmRotation, setConfirmRotation] = useState(false);
+  const [rotating, setRotating] = useState(false);
+  const [rotationProgress, setRotationProgress] = useState<{ done: number; total: number } | null>(null);
+  const [rotationError, setRotationError] = useState<string | null>(null);
+  const [sshAgent, setSSHAgent] = useState<sshagent.Status>(sshagent.Status.createFrom({ running: false, socketPath: "" }));
+  const [sshAgentError, setSSHAgentError] = useState<string | null>(null);
 
   useEffect(() => {
     setLocalColors({ ...colors });
   }, [colors, open]);
 
-  const handleSave = () => {
+  useEffect(() => {
+    if (!open) return;
+    setConnectionStatus(null);
+    GetConnectionSettings()
+      .then((settings) => setConnection(settings))
+      .catch((err) => console.error("Failed to load connection settings:", err));
+    GetAutoLockSettings()
+      .then((pref) => {
+        setPreferences(pref);
+        setAutoLogout(pref.auto_lock);
+        setLogoutTime(pref.auto_lock_minutes);
+        setHistoryLimit(pref.password_history_limit);
+      })
+      .catch((err) => console.error("Failed to load auto-lock settings:", err));
+    setRotationError(null);
+    setConfirmRotation(false);
+    setChangePasswordMode(false);
+    IsKeyRotationPending().then(setRotationPending);
+  }, [open]);
+
+  useEffect(() => EventsOn("vault:rotation", setRotationProgress), []);
+
+  // The agent stops on its own when the vault locks, follow its status
+  useEffect(() => {
+    if (!open) return;
+    setSSHAgentError(null);
+    GetSSHAgentStatus()
+      .then(setSSHAgent)
+      .catch((err) => console.error("Failed to get SSH agent status:", err));
+    return EventsOn("ssh:agent", (status: sshagent.Status) => setSSHAgent(status));
+  }, [open]);
+
+  const toggleSSHAgent = async (enabled: boolean) => {
+    setSSHAgentError(null);
+    try {
+      setSSHAgent(enabled ? await StartSSHAgent() : await StopSSHAgent());
+    } catch (err) {
+      setSSHAgentError(String(err));
+    }
+  };
+
+  const handleRotateKey = async () => {
+    setConfirmRotation(false);
+    setRotating(true);
+    setRotationError(null);
+    setRotationProgress(null);
+    try {
+      const seedPhrase = await RotateVaultKey();
+      setRotationPending(false);
+      setSeedPhraseConfirmation(seedPhrase);
+    } catch (err) {
+      setRotationError(String(err));
+      IsKeyRotationPending().then(setRotationPending);
+    } finally {
+      setRotating(false);
+    }
+  };
+
+  const updateConnection = (patch: Partial<config.Config>) => {
+    setConnection((prev) => (prev ? config.Config.createFrom({ ...prev, ...patch }) : prev));
+  };
+
+  const updateConnectionTLS = (patch: Partial<config.TLSConfig>) => {
+    setConnection((prev) =>
+      prev ? config.Config.createFrom({ ...prev, tls: { ...prev.tls, ...patch } }) : prev
+    );
+  };
+
+  const updateConnectionBreach = (patch: Partial<config.BreachConfig>) => {
+    setConnection((prev) =>
+      prev ? config.Config.createFrom({ ...prev, breach: { ...prev.breach, ...patch } }) : prev
+    );
+  };
+
+  const handleApplyConnection = async () => {
+    if (!connection) return;
+    try {
+      await UpdateConnectionSettings(connection);
+      setConnectionStatus({ error: false, message: "Connection settings saved" });
+    } catch (err) {
+      setConnectionStatus({ error: true, message: String(err) });
+    }
+  };
+
+  const handleSave = async () => {
     Object.entries(localColors).forEach(([type, color]) => {
       updateColor(type as keyof typeof colors, color);
     });
+    try {
+      await UpdateAutoLockSettings(
+        // Spread the loaded preferences so fields without a control here keep their values
+        config.Preferences.createFrom({
+          ...preferences,
+          auto_lock: autoLogout,
+          auto_lock_minutes: logoutTime,
+          password_history_limit: historyLimit,
+        })
+      );
+    } catch (err) {
+      console.error("Failed to save auto-lock settings:", err);
+    }
     onOpenChange(false);
   };
 
@@ -86,9 +209,10 @@ export function SettingsOverlay({ open, onOpenChange }: SettingsOverlayProps) {
             </DialogHeader>
 
             <Tabs defaultValue="appearance" className="w-full">
-              <TabsList className="grid w-full grid-cols-3">
+              <TabsList className="grid w-full grid-cols-4">
                 <TabsTrigger value="appearance">Appearance</TabsTrigger>
                 <TabsTrigger value="security">Security</TabsTrigger>
+                <TabsTrigger value="connection">Connection</TabsTrigger>
                 <TabsTrigger value="account">Account</TabsTrigger>
               </TabsList>
 
@@ -263,11 +387,151 @@ export function SettingsOverlay({ open, onOpenChange }: SettingsOverlayProps) {
                     />
                   </div>
                 )}
+
+                <div className="space-y-2">
+                  <Label htmlFor="history-limit">Password History</Label>
+                  <p className="text-sm text-muted-foreground">
+                    Previous passwords kept per website item, 0 turns the history off
+                  </p>
+                  <Input
+                    id="history-limit"
+                    type="number"
+                    value={historyLimit}
+                    onChange={(e) => setHistoryLimit(Math.min(100, Math.max(0, parseInt(e.target.value) || 0)))}
+                    min={0}
+                    max={100}
+                  />
+                </div>
+
+                <div className="flex items-center justify-between">
+                  <div>
+                    <Label htmlFor="ssh-agent">SSH Agent</Label>
+                    <p className="text-sm text-muted-foreground">
+                      Serve SSH keys from the vault until it locks, asking before each use
+                    </p>
+                  </div>
+                  <Switch id="ssh-agent" checked={sshAgent.running} onCheckedChange={toggleSSHAgent} />
+                </div>
+                {sshAgent.running && (
+                  <div className="space-y-1">
+                    <p className="text-xs text-muted-foreground">Point ssh at the agent with:</p>
+                    <code className="block text-xs bg-secondary rounded px-2 py-1 break-all">
+                      export SSH_AUTH_SOCK="{sshAgent.socketPath}"
+                    </code>
+                  </div>
+                )}
+                {sshAgentError && <p className="text-sm text-destructive">{sshAgentError}</p>}
+              </TabsContent>
+
+              <TabsContent value="connection" className="space-y-4 mt-4">
+                {connection && (
+                  <>
+                    <div className="space-y-2">
+                      <Label htmlFor="backend-url">Backend URL</Label>
+                      <Input
+                        id="backend-url"
+                        type="text"
+                        value={connection.backend_url}
+                        onChange={(e) => updateConnection({ backend_url: e.target.value })}
+                        placeholder="https://vault.example.com"
+                      />
+                    </div>
+
+                    <div className="space-y-2">
+                      <Label htmlFor="request-timeout">Request Timeout (seconds)</Label>
+                      <Input
+                        id="request-timeout"
+                        type="number"
+                        value={connection.timeout_seconds}
+                        onChange={(e) => updateConnection({ timeout_seconds: parseInt(e.target.value) || 30 })}
+                        min={1}
+                      />
+                    </div>
+
+                    <div className="space-y-2">
+                      <Label htmlFor="ca-file">CA Certificate File</Label>
+                      <Input
+                        id="ca-file"
+                        type="text"
+                        value={connection.tls?.ca_file ?? ""}
+                        onChange={(e) => updateConnectionTLS({ ca_file: e.target.value })}
+                        placeholder="Use system certificates"
+                      />
+                    </div>
+
+                    <div className="flex items-center justify-between">
+                      <div>
+                        <Label htmlFor="tls-insecure">Skip TLS Verification</Label>
+                        <p className="text-sm text-muted-foreground">
+                          Only for testing against self-signed backends
+                        </p>
+                      </div>
+                      <Switch
+                        id="tls-insecure"
+                        checked={connection.tls?.insecure_skip_verify ?? false}
+                        onCheckedChange={(checked) => updateConnectionTLS({ insecure_skip_verify: checked })}
+                      />
+                    </div>
+
+                    <div className="flex items-center justify-between">
+                      <div>
+                        <Label htmlFor="breach-check">Check for Breached Passwords</Label>
+                        <p className="text-sm text-muted-foreground">
+                          Sends only the first 5 characters of each password's SHA-1 hash
+                        </p>
+                      </div>
+                      <Switch
+                        id="breach-check"
+                        checked={connection.breach?.enabled ?? false}
+                        onCheckedChange={(checked) => updateConnectionBreach({ enabled: checked })}
+                      />
+                    </div>
+
+                    {connection.breach?.enabled && (
+                      <div className="space-y-2">
+                        <Label htmlFor="breach-url">Breach Range Endpoint</Label>
+                        <Input
+                          id="breach-url"
+                          type="text"
+                          value={connection.breach.range_url}
+                          onChange={(e) => updateConnectionBreach({ range_url: e.target.value })}
+                          placeholder="https://api.pwnedpasswords.com/range"
+                        />
+                      </div>
+                    )}
+
+                    {connectionStatus && (
+                      <p className={`text-sm ${connectionStatus.error ? "text-destructive" : "text-muted-foreground"}`}>
+                        {connectionStatus.message}
+                      </p>
+                    )}
+
+                    <Button variant="secondary" className="w-full" onClick={handleApplyConnection}>
+                      Apply Connection Settings
+                    </Button>
+                  </>
+                )}
               </TabsContent>
 
               <TabsContent value="account" className="space-y-4 mt-4">
-                {!recoveryMode ? (
+                {changePasswordMode ? (
+                  <ChangePasswordForm onBack={() => setChangePasswordMode(false)} />
+                ) : !recoveryMode ? (
                   <>
+                    <div className="space-y-2">
+                      <Label>Master Password</Label>
+                      <p className="text-sm text-muted-foreground mb-2">
+                        Change your master password while you still know the current one
+                      </p>
+                      <Button
+                        variant="secondary"
+                        className="w-full"
+                        onClick={() => setChangePasswordMode(true)}
+                      >
+                        Change Master Password
+                      </Button>
+                    </div>
+
                     <div className="space-y-2">
                       <Label htmlFor="password-management">Password Recovery</Label>
                       <p className="text-sm text-muted-foreground mb-2">
@@ -281,6 +545,37 @@ export function SettingsOverlay({ open, onOpenChange }: SettingsOverlayProps) {
                         Recover with Seed Phrase
                       </Button>
                     </div>
+
+                    <div className="space-y-2">
+                      <Label>Vault Key</Label>
+                      <p className="text-sm text-muted-foreground mb-2">
+                        Encrypt the whole vault with a new key, for example if the current one may be compromised
+                      </p>
+                      {confirmRotation && (
+                        <p className="text-sm text-destructive">
+                          Every item is encrypted again and you get a new recovery seed phrase. The current seed
+                          phrase stops working, and the version history of every item is deleted because it is
+                          encrypted with the current key.
+                        </p>
+                      )}
+                      <Button
+                        variant={confirmRotation ? "destructive" : "secondary"}
+                        className="w-full"
+                        onClick={confirmRotation || rotationPending ? handleRotateKey : () => setConfirmRotation(true)}
+                        disabled={rotating}
+                      >
+                        {rotating
+                          ? rotationProgress
+                            ? `Re-encrypting ${rotationProgress.done} of ${rotationProgress.total}...`
+                            : "Preparing..."
+                          : rotationPending
+                            ? "Resume Key Rotation"
+                            : confirmRotation
+                              ? "Confirm Key Rotation"
+                              : "Rotate Vault Key"}
+                      </Button>
+                      {rotationError && <p className="text-sm text-destructive">{rotationError}</p>}
+                    </div>
                   </>
                 ) : (
                   <RecoveryForm
@@ -291,7 +586,7 @@ export function SettingsOverlay({ open, onOpenChange }: SettingsOverlayProps) {
               </TabsContent>
             </Tabs>
 
-            {!recoveryMode && (
+            {!recoveryMode && !changePasswordMode && (
               <div className="flex justify-end gap-2 mt-4">
                 <Button variant="outline" onClick={() => onOpenChange(false)}>
                   Cancel
@@ -5,7 +5,7 @@ import { Label } from "@/components/ui/label";
 import { useState } from "react";
 import { LockKeyhole, ArrowRightCircle, Shield, AlertCircle, Eye, EyeOff, Info, Check } from "lucide-react";
 import { Tooltip, TooltipContent, TooltipProvider, TooltipTrigger } from "@/components/ui/tooltip";
-import { PasswordStrengthMeter, isPasswordValid } from "@/components/PasswordStrength/PasswordStrengthMeter";
+import { PasswordStrengthMeter, usePasswordStrength } from "@/components/PasswordStrength/PasswordStrengthMeter";
 
 interface RegisterPageProps {
   onRegister: (email: string, masterPassword: string, confirmPassword: string) => void;
@@ -40,9 +40,10 @@ export function RegisterPage({ onRegister, onLoginClick }: RegisterPageProps) {
     }
   };
 
+  const passwordCheck = usePasswordStrength(password, email);
   const isEmailValid = /^[^\s@]+@[^\s@]+\.[^\s@]+$/.test(email);
   const doPasswordsMatch = password === confirmPassword && confirmPassword !== "";
-  const canSubmit = isEmailValid && isPasswordValid(password, email) && doPasswordsMatch && !isRegistering;
+  const canSubmit = isEmailValid && !!passwordCheck?.acceptable && doPasswordsMatch && !isRegistering;
 
   return (
     <div className="h-screen flex items-center justify-center bg-[#1E1E1E] p-4">
@@ -151,7 +152,7 @@ export function RegisterPage({ onRegister, onLoginClick }: RegisterPageProps) {
                 
                 {/* Using our new modular PasswordStrengthMeter component */}
                 {password.length > 0 && (
-                  <PasswordStrengthMeter password={password} email={email} />
+                  <PasswordStrengthMeter password={password} check={passwordCheck} />
                 )}
               </div>
 
@@ -1,20 +1,27 @@
 import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
 import { Textarea } from "@/components/ui/textarea";
-import { Bookmark, Globe, MoreVertical, Pencil, User, CreditCard, Pen, Eye, EyeOff, Wallet, Tag, Copy, Check, File, Calendar } from "lucide-react";
+import { Bookmark, Globe, MoreVertical, Pencil, User, CreditCard, Pen, Eye, EyeOff, Wallet, KeyRound, Tag, Copy, Check, File, Calendar } from "lucide-react";
 import { useState, useEffect } from "react";
-import { CardEntry, CryptoEntry, IdentityEntry, MemoEntry, PasswordEntry, WebsiteEntry } from "@/types/password";
+import { CardEntry, CryptoEntry, IdentityEntry, MemoEntry, PasswordEntry, SSHKeyEntry, WebsiteEntry } from "@/types/password";
 import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from "@/components/ui/select";
 import { CardFields } from './ItemTypes/CardFields';
 import { CryptoFields } from './ItemTypes/CryptoFields';
 import { IdentityFields } from './ItemTypes/IdentityFields';
 import { WebsiteFields } from './ItemTypes/WebsiteFields';
+import { PasswordHistory } from './ItemTypes/PasswordHistory';
+import { VersionHistory } from './ItemTypes/VersionHistory';
+import { Attachments } from './ItemTypes/Attachments';
 import { MemoFields } from './ItemTypes/MemoFields';
+import { CustomFields } from './ItemTypes/CustomFields';
+import { SSHKeyFields } from './ItemTypes/SSHKeyFields';
 import { SettingsDropdown } from "./ui/SettingsDropdown";
 import { Tooltip, TooltipContent, TooltipTrigger } from "@/components/ui/tooltip";
 import { useToast } from "@/components/ui/use-toast";
 import { useColorSettings } from "@/context/ColorSettingsContext";
-import { GetPasswordList, UpdateItemClient, DeleteItemClient } from "@/wailsjs/go/main/App";
+import { GetItemDetail, UpdateItemClient, DeleteItemClient } from "@/wailsjs/go/main/App";
+import { convertToPasswordEntry } from "./PasswordList";
+import { toItemInput } from "@/lib/items";
 import { DeleteConfirmationDialog } from "@/components/DeleteConfirmationDialog";
 import { useCategories } from "@/context/CategoryContext";
 
@@ -59,50 +66,36 @@ export function PasswordEditor({ password, isOpen, onDelete, onUpdate }: Passwor
   const [isDeleteDialogOpen, setIsDeleteDialogOpen] = useState(false);
   
   // Use the shared categories context instead of managing categories locally
-  const { categories, isLoading: isLoadingCategories } = useCategories();
+  const { categories, isLoading: isLoadingCategories, getCategoryNameById } = useCategories();
 
-  // New effect to fetch the current item's category information from GetPasswordList
+  // The list only carries titles, fetch the decrypted fields of the selected item from Go
   useEffect(() => {
-    const fetchItemCategoryInfo = async () => {
+    const fetchItemDetail = async () => {
       // Only proceed if we have a valid password with ID
       if (!password || !password.id) return;
       
       try {
         setIsLoadingItemCategory(true);
         
-        // Get the complete list of items with their category information
-        const items = await GetPasswordList();
+        const detail = await GetItemDetail(Number(password.id));
+        if (!detail) return;
         
-        if (!items || !Array.isArray(items)) return;
-        
-        // Find the current item in the items list by ID
-        const currentItem = items.find(item => 
-          parseInt(String(item.ItemID)) === parseInt(String(password.id))
-        );
-        
-        if (currentItem) {
-          console.log("Found item with category data:", {
-            id: currentItem.ItemID,
-            title: currentItem.Title,
-            categoryId: currentItem.CategoryID,
-            categoryName: currentItem.CategoryName
-          });
-          
-          // Update formData with the accurate category info from the backend
-          setFormData(prev => ({
-            ...prev,
-            category: currentItem.CategoryName || null,
-            categoryId: currentItem.CategoryID || null
-          }));
-        }
+        const entry = convertToPasswordEntry(detail);
+        const categoryId = detail.CategoryID || null;
+        setFormData(prev => ({
+          ...prev,
+          ...entry,
+          category: getCategoryNameById(categoryId),
+          categoryId
+        }));
       } catch (error) {
-        console.error("Failed to fetch item's category details:", error);
+        console.error("Failed to fetch item details:", error);
       } finally {
         setIsLoadingItemCategory(false);
       }
     };
     
-    fetchItemCategoryInfo();
+    fetchItemDetail();
   }, [password?.id]); // Re-run when the password ID changes
 
   // Make sure password changes are properly reflected in the component
@@ -143,56 +136,27 @@ export function PasswordEditor({ password, isOpen, onDelete, onUpdate }: Passwor
     }
   }, [formData, focusedField]);
 
+  // A restore saves the item in Go, so read it back and pass the new state on to the list
+  const handleRestored = async () => {
+    try {
+      const detail = await GetItemDetail(Number(formData.id));
+      if (!detail) return;
+      const categoryId = detail.CategoryID || null;
+      const restored: typeof formData = {
+        ...convertToPasswordEntry(detail),
+        category: getCategoryNameById(categoryId),
+        categoryId,
+        dateModified: new Date()
+      };
+      setFormData(restored);
+      onUpdate?.(restored);
+    } catch (error) {
+      console.error("Failed to reload item after restore:", error);
+    }
+  };
+
   const handleSave = async () => {
     try {
-      // Extract the data appropriate for this item type
-      const itemData: Record<string, any> = {};
-      
-      // Common fields first
-      if (formData.notes) {
-        itemData.notes = formData.notes;
-      }
-      
-      // Type-specific data fields
-      switch (formData.type) {
-        case "website":
-          const websiteData = formData as WebsiteEntry;
-          if (websiteData.username) itemData.username = websiteData.username;
-          if (websiteData.password) itemData.password = websiteData.password;
-          if (websiteData.url) itemData.url = websiteData.url;
-          break;
-          
-        case "card":
-          const cardData = formData as CardEntry;
-          if (cardData.cardholderName) itemData.cardholderName = cardData.cardholderName;
-          if (cardData.cardNumber) itemData.cardNumber = cardData.cardNumber;
-          if (cardData.expirationMonth) itemData.expirationMonth = cardData.expirationMonth;
-          if (cardData.expirationYear) itemData.expirationYear = cardData.expirationYear;
-          if (cardData.cvv) itemData.cvv = cardData.cvv;
-          break;
-          
-        case "crypto":
-          const cryptoData = formData as CryptoEntry;
-          if (cryptoData.walletName) itemData.walletName = cryptoData.walletName;
-          if (cryptoData.address) itemData.address = cryptoData.address;
-          if (cryptoData.privateKey) itemData.privateKey = cryptoData.privateKey;
-          break;
-          
-        case "identity":
-          const identityData = formData as IdentityEntry;
-          if (identityData.firstName) itemData.firstName = identityData.firstName;
-          if (identityData.lastName) itemData.lastName = identityData.lastName;
-          if (identityData.email) itemData.email = identityData.email;
-          if (identityData.phone) itemData.phone = identityData.phone;
-          if (identityData.address) itemData.address = identityData.address;
-          break;
-          
-        case "memo":
-          const memoData = formData as MemoEntry;
-          if (memoData.content) itemData.content = memoData.content;
-          break;
-      }
-      
       // Convert category information to the format expected by the backend
       const categoryId = formData.categoryId !== undefined 
         ? (formData.categoryId === null ? null : Number(formData.categoryId)) 
@@ -204,8 +168,7 @@ export function PasswordEditor({ password, isOpen, onDelete, onUpdate }: Passwor
       const response = await UpdateItemClient(
         parseInt(formData.id),
         categoryId,
-        formData.title,
-        itemData
+        toItemInput(formData)
       );
       
       console.log("Item updated successfully:", response);
@@ -423,6 +386,8 @@ export function PasswordEditor({ password, isOpen, onDelete, onUpdate }: Passwor
         return <Wallet className="h-5 w-5" />;
       case "memo":
         return <File className="h-5 w-5" />;
+      case "ssh_key":
+        return <KeyRound className="h-5 w-5" />;
       default:
         return <File className="h-5 w-5" />;
     }
@@ -478,6 +443,8 @@ export function PasswordEditor({ password, isOpen, onDelete, onUpdate }: Passwor
         return <Wallet className="h-6 w-6" />;
       case "memo":
         return <File className="h-6 w-6" />;
+      case "ssh_key":
+        return <KeyRound className="h-6 w-6" />;
       default:
         return <Globe className="h-6 w-6" />;
     }
@@ -648,6 +615,16 @@ export function PasswordEditor({ password, isOpen, onDelete, onUpdate }: Passwor
               copiedField={copiedField}
             />
           )}
+          {formData.type === "website" && formData.id && (
+            <PasswordHistory
+              itemId={Number(formData.id)}
+              currentPassword={(formData as WebsiteEntry).password}
+              isEditing={isEditing}
+              onRestored={handleRestored}
+              copyToClipboard={copyToClipboard}
+              copiedField={copiedField}
+            />
+          )}
           {formData.type === "card" && (
             <CardFields
               formData={formData as CardEntry}
@@ -689,6 +666,27 @@ export function PasswordEditor({ password, isOpen, onDelete, onUpdate }: Passwor
             />
           )}
 
+          {formData.type === "ssh_key" && (
+            <SSHKeyFields
+              formData={formData as SSHKeyEntry}
+              isEditing={isEditing}
+              setFields={(fields) => setFormData(prev => ({ ...prev, ...fields } as typeof prev))}
+              copyToClipboard={copyToClipboard}
+              copiedField={copiedField}
+            />
+          )}
+
+          {/* Custom fields */}
+          <CustomFields
+            fields={formData.customFields || []}
+            isEditing={isEditing}
+            onChange={(customFields) => setFormData(prev => ({ ...prev, customFields }))}
+            copyToClipboard={copyToClipboard}
+            copiedField={copiedField}
+          />
+
+          {formData.id && <Attachments itemId={Number(formData.id)} isEditing={isEditing} />}
+
           {/* Notes field - only show for non-memo types and when not included in the component */}
           {formData.notes !== undefined && 
            formData.type !== "memo" && // MemoFields already shows notes
@@ -814,6 +812,15 @@ export function PasswordEditor({ password, isOpen, onDelete, onUpdate }: Passwor
               </div>
             </div>
           </div>
+
+          {formData.id && (
+            <VersionHistory
+              itemId={Number(formData.id)}
+              dateModified={formData.dateModified}
+              isEditing={isEditing}
+              onRestored={handleRestored}
+            />
+          )}
         </div>
       </div>
 
@@ -1,13 +1,15 @@
 import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
 import { ScrollArea } from "@/components/ui/scroll-area";
-import { Globe, Plus, Search, User, CreditCard, Wallet, File, AlertCircle, Loader2, Bookmark, ChevronDown, SortAsc, SortDesc, ArrowDownAZ, ArrowUpZA, CalendarClock, X, FilterIcon, ShieldIcon } from "lucide-react";
+import { Globe, Plus, Search, User, CreditCard, Wallet, File, KeyRound, AlertCircle, Loader2, Bookmark, ChevronDown, SortAsc, SortDesc, ArrowDownAZ, ArrowUpZA, CalendarClock, X, FilterIcon, ShieldIcon } from "lucide-react";
 import { useState, useEffect } from "react";
 import { IdentityEntry, PasswordEntry, PasswordType } from "@/types/password";
 import { NewItemTypeOverlay } from "./Overlays/NewItemTypeOverlay";
 import { NewItemCreateOverlay } from "./Overlays/NewItemCreateOverlay";
 import { useColorSettings } from "@/context/ColorSettingsContext";
-import { GetPasswordList, ToggleBookmark } from "@/wailsjs/go/main/App";
+import { GetPasswordList, SyncVault, ToggleBookmark } from "@/wailsjs/go/main/App";
+import { EventsOn } from "@/wailsjs/runtime/runtime";
+import { service } from "@/wailsjs/go/models";
 import { useToast } from "./ui/use-toast";
 import { DropdownMenu, DropdownMenuContent, DropdownMenuItem, DropdownMenuTrigger } from "@/components/ui/dropdown-menu";
 import { useCategories } from "@/context/CategoryContext";
@@ -33,39 +35,8 @@ const formatFullName = (entry: IdentityEntry) => {
   return `${entry.firstName} ${entry.lastName}`.trim();
 };
 
-// Map backend types to frontend types
-const mapTypeToFrontend = (backendType: string): PasswordType => {
-  // First normalize the type to lowercase for case-insensitive comparison
-  const type = backendType?.toLowerCase() || '';
-  
-  switch (type) {
-    case 'login':
-      return 'website';
-    case 'note':
-      return 'memo';
-    case 'cryptowallet':
-      return 'crypto';
-    case 'identity':
-      return 'identity';
-    case 'card':
-      return 'card';
-    case 'credit':  
-      return 'card';
-    // Other mappings
-    case 'website':
-      return 'website';
-    case 'crypto':
-      return 'crypto';
-    case 'memo':
-      return 'memo';
-    default:
-      console.warn(`Unknown type: "${backendType}", defaulting to memo`);
-      return 'memo';
-  }
-};
-
 // Convert backend data to frontend format
-const convertToPasswordEntry = (item: any): PasswordEntry => {
+export const convertToPasswordEntry = (item: any): PasswordEntry => {
   try {
     if (!item) {
       console.error("Null or undefined item received");
@@ -75,10 +46,12 @@ const convertToPasswordEntry = (item: any): PasswordEntry => {
     // Log the item to help with debugging
     console.log("Converting item:", item.ItemID, item.Title, item.TypeName);
     
-    // Get the frontend type based on backend type
-    const frontendType = mapTypeToFrontend(item.TypeName || "memo");
-    console.log(`Mapped type ${item.TypeName} -> ${frontendType}`);
+    // TypeName is already the frontend type, mapped by the item type registry in Go
+    const frontendType = (item.TypeName || "memo") as PasswordType;
     
+    // Data holds one member per item type, named after the frontend type
+    const data: any = item.Data?.[frontendType] || {};
+
     // Base properties common to all entry types
     const baseProps = {
       id: String(item.ItemID || 0),
@@ -87,12 +60,10 @@ const convertToPasswordEntry = (item: any): PasswordEntry => {
       dateCreated: parseBangkokDate(item.DateCreate || Date.now()),
       dateModified: parseBangkokDate(item.DateModify || Date.now()),
       notes: "",
-      categoryId: item.CategoryID || null
+      categoryId: item.CategoryID || null,
+      customFields: data.customFields || []
     };
 
-    // Make sure Data is an object
-    const data = typeof item.Data === 'object' && item.Data !== null ? item.Data : {};
-    
     // Create the appropriate type of entry based on the frontend type
     switch (frontendType) {
       case 'website':
@@ -102,6 +73,7 @@ const convertToPasswordEntry = (item: any): PasswordEntry => {
           username: data.username || '',
           password: data.password || '',
           url: data.url || '',
+          totp: data.totp || '',
           notes: data.notes || ''
         };
       
@@ -109,10 +81,10 @@ const convertToPasswordEntry = (item: any): PasswordEntry => {
         return {
           ...baseProps,
           type: 'card' as const,
-          cardholderName: data.cardholder || data.cardholderName || '',
-          cardNumber: data.cardNumber || data.number || '',
-          expirationMonth: data.expMonth || data.expirationMonth || '',
-          expirationYear: data.expYear || data.expirationYear || '',
+          cardholderName: data.cardholderName || '',
+          cardNumber: data.cardNumber || '',
+          expirationMonth: data.expirationMonth || '',
+          expirationYear: data.expirationYear || '',
           cvv: data.cvv || '',
           notes: data.notes || ''
         };
@@ -139,6 +111,16 @@ const convertToPasswordEntry = (item: any): PasswordEntry => {
           notes: data.notes || ''
         };
       
+      case 'ssh_key':
+        return {
+          ...baseProps,
+          type: 'ssh_key' as const,
+          privateKey: data.privateKey || '',
+          publicKey: data.publicKey || '',
+          comment: data.comment || '',
+          notes: data.notes || ''
+        };
+
       default:
         return {
           ...baseProps,
@@ -185,15 +167,33 @@ export function PasswordList({
   const [sortField, setSortField] = useState<SortField>('dateModified');
   const [sortDirection, setSortDirection] = useState<SortDirection>('desc');
 
-  // Load passwords on component mount
+  // Load the full list once, later updates arrive through the vault change feed
   useEffect(() => {
     loadPasswords();
   }, []);
 
-  // Add dependency to the useEffect to reload when refreshTrigger or activeCategory changes
+  // After an edit only ask Go for what changed, the result comes back as a vault:changed event
   useEffect(() => {
-    loadPasswords();
-  }, [refreshTrigger, activeCategory]);
+    if (refreshTrigger === 0) return;
+    SyncVault().catch((err) => console.error("SyncVault failed:", err));
+  }, [refreshTrigger]);
+
+  // Patch the list in place with the items that changed
+  useEffect(() => {
+    return EventsOn("vault:changed", (changeSet: service.ChangeSet) => {
+      setPasswords((prev) => {
+        const byId = new Map(prev.map((pw) => [pw.id, pw]));
+        for (const change of changeSet.Changes || []) {
+          if (change.Kind === "item_changed" && change.Item) {
+            byId.set(String(change.ItemID), convertToPasswordEntry(change.Item));
+          } else if (change.Kind === "item_deleted") {
+            byId.delete(String(change.ItemID));
+          }
+        }
+        return Array.from(byId.values());
+      });
+    });
+  }, []);
 
   const loadPasswords = async () => {
     setIsLoading(true);
@@ -638,6 +638,7 @@ export function PasswordList({
                       {entry.type === "card" && <CreditCard className="h-[18px] w-[18px]" />}
                       {entry.type === "crypto" && <Wallet className="h-[18px] w-[18px]" />}
                       {entry.type === "memo" && <File className="h-[18px] w-[18px]" />}
+                      {entry.type === "ssh_key" && <KeyRound className="h-[18px] w-[18px]" />}
                     </div>
                     <div className="text-left flex-1 min-w-0">
                       <div className="font-medium text-sm truncate pr-8">{entry.title}</div>
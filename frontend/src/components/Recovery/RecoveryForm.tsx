@@ -6,7 +6,7 @@ import { Textarea } from "@/components/ui/textarea";
 import { Alert, AlertDescription } from "@/components/ui/alert";
 import { AlertCircle } from "lucide-react";
 import { RecoveryProcess } from "../../../wailsjs/go/main/App";
-import { PasswordStrengthMeter, isPasswordValid } from "@/components/PasswordStrength/PasswordStrengthMeter";
+import { PasswordStrengthMeter, usePasswordStrength } from "@/components/PasswordStrength/PasswordStrengthMeter";
 import { cn } from "@/lib/utils";
 import { RecoverySeedPhraseConfirmation } from "./RecoverySeedPhraseConfirmation";
 import { Dialog, DialogPortal, DialogOverlay, DialogContent } from "@/components/ui/dialog";
@@ -28,6 +28,7 @@ export function RecoveryForm({
   const [isRecovering, setIsRecovering] = useState(false);
   const [newSeedPhrase, setNewSeedPhrase] = useState("");
   const [recoveryComplete, setRecoveryComplete] = useState(false);
+  const passwordCheck = usePasswordStrength(newPassword, email);
 
   const handleSubmit = async () => {
     // Validation
@@ -50,10 +51,10 @@ export function RecoveryForm({
       return;
     }
     
-    if (!isPasswordValid(newPassword, email)) {
+    if (!passwordCheck?.acceptable) {
       setRecoveryMessage({ 
         type: "error", 
-        message: "Password does not meet security requirements" 
+        message: passwordCheck?.message || "Password does not meet security requirements" 
       });
       return;
     }
@@ -191,7 +192,7 @@ export function RecoveryForm({
         />
         
         {newPassword.length > 0 && (
-          <PasswordStrengthMeter password={newPassword} email={email} />
+          <PasswordStrengthMeter password={newPassword} check={passwordCheck} />
         )}
       </div>
       
@@ -0,0 +1,70 @@
+import { useEffect, useState } from 'react';
+import { KeyRound } from 'lucide-react';
+import {
+  AlertDialog,
+  AlertDialogContent,
+  AlertDialogDescription,
+  AlertDialogFooter,
+  AlertDialogHeader,
+  AlertDialogTitle,
+} from "@/components/ui/alert-dialog";
+import { Button } from "@/components/ui/button";
+import { ResolveSSHConfirmation } from '../../wailsjs/go/main/App';
+import { EventsOn } from '../../wailsjs/runtime/runtime';
+
+// SSHConfirmRequest mirrors sshagent.ConfirmRequest, which only reaches the frontend through events
+interface SSHConfirmRequest {
+  id: string;
+  itemId: number;
+  title: string;
+  comment: string;
+  fingerprint: string;
+}
+
+// SSHConfirmDialog asks the user to approve each use of a vault key by the SSH agent
+export function SSHConfirmDialog() {
+  const [requests, setRequests] = useState<SSHConfirmRequest[]>([]);
+
+  useEffect(() => {
+    return EventsOn('ssh:confirm', (request: SSHConfirmRequest) => {
+      setRequests((prev) => [...prev, request]);
+    });
+  }, []);
+
+  const current = requests[0];
+
+  const answer = async (allow: boolean) => {
+    if (!current) return;
+    setRequests((prev) => prev.slice(1));
+    try {
+      await ResolveSSHConfirmation(current.id, allow);
+    } catch (error) {
+      // The request timed out or the agent stopped, ssh has already been told no
+      console.warn('SSH confirmation no longer pending:', error);
+    }
+  };
+
+  if (!current) return null;
+
+  return (
+    <AlertDialog open={true}>
+      <AlertDialogContent className="max-w-[480px]">
+        <AlertDialogHeader>
+          <div className="flex items-center gap-2">
+            <KeyRound className="h-5 w-5" />
+            <AlertDialogTitle>Use SSH key?</AlertDialogTitle>
+          </div>
+          <AlertDialogDescription className="pt-2">
+            A program wants to sign in with <span className="font-medium text-foreground">"{current.title}"</span>
+            {current.comment && <> ({current.comment})</>}.
+          </AlertDialogDescription>
+        </AlertDialogHeader>
+        <code className="block text-xs bg-secondary rounded px-2 py-1 break-all">{current.fingerprint}</code>
+        <AlertDialogFooter>
+          <Button variant="outline" onClick={() => answer(false)}>Deny</Button>
+          <Button onClick={() => answer(true)}>Allow</Button>
+        </AlertDialogFooter>
+      </AlertDialogContent>
+    </AlertDialog>
+  );
+}
@@ -1,6 +1,6 @@
 import { Button } from "@/components/ui/button";
 import { cn } from "@/lib/utils";
-import { Bookmark, FileText, Infinity } from "lucide-react";
+import { Bookmark, FileText, Infinity, ShieldCheck } from "lucide-react";
 import { CatList } from "@/components/CatList";
 import { SettingsDropdown } from "./ui/SettingsDropdown";
 import { useAuth } from "@/context/AuthContext";
@@ -73,6 +73,14 @@ export function Sidebar({ className, currentView = "passwords", onViewChange }:
               <Infinity className="mr-2 h-4 w-4" />
               Generator
             </Button>
+            <Button 
+              variant={currentView === "watchtower" ? "secondary" : "ghost"}
+              className="w-full justify-start text-sm h-9"
+              onClick={() => onViewChange?.("watchtower")}
+            >
+              <ShieldCheck className="mr-2 h-4 w-4" />
+              Watchtower
+            </Button>
           </div>
         </div>
         
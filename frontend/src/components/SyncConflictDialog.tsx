@@ -0,0 +1,147 @@
+import React, { useCallback, useEffect, useState } from 'react';
+import { GitMerge } from 'lucide-react';
+import {
+  AlertDialog,
+  AlertDialogContent,
+  AlertDialogDescription,
+  AlertDialogFooter,
+  AlertDialogHeader,
+  AlertDialogTitle,
+} from "@/components/ui/alert-dialog";
+import { Button } from "@/components/ui/button";
+import { useToast } from "@/components/ui/use-toast";
+import { GetSyncConflicts, ResolveSyncConflict } from '../../wailsjs/go/main/App';
+import { EventsOn } from '../../wailsjs/runtime/runtime';
+import { service } from '../../wailsjs/go/models';
+
+interface SyncConflictDialogProps {
+  onResolved: () => void;
+}
+
+const reasonText: Record<string, string> = {
+  modified: "was changed on the server while you were offline",
+  deleted: "was deleted on the server while you were offline",
+  rejected: "could not be saved by the server",
+};
+
+function VersionCard({ label, item }: { label: string; item?: service.AfterItem }) {
+  return (
+    <div className="flex-1 rounded-md border border-border p-3 text-sm">
+      <div className="text-xs uppercase text-muted-foreground mb-1">{label}</div>
+      {item ? (
+        <>
+          <div className="font-medium text-foreground truncate">{item.Title}</div>
+          <div className="text-xs text-muted-foreground">
+            Modified {item.DateModify ? new Date(item.DateModify).toLocaleString() : "unknown"}
+          </div>
+        </>
+      ) : (
+        <div className="text-muted-foreground italic">Deleted</div>
+      )}
+    </div>
+  );
+}
+
+function NameCard({ label, name }: { label: string; name?: string }) {
+  return (
+    <div className="flex-1 rounded-md border border-border p-3 text-sm">
+      <div className="text-xs uppercase text-muted-foreground mb-1">{label}</div>
+      {name ? (
+        <div className="font-medium text-foreground truncate">{name}</div>
+      ) : (
+        <div className="text-muted-foreground italic">Deleted</div>
+      )}
+    </div>
+  );
+}
+
+// SyncConflictDialog walks the user through offline changes that clashed with the server copy
+export function SyncConflictDialog({ onResolved }: SyncConflictDialogProps) {
+  const [conflicts, setConflicts] = useState<service.SyncConflict[]>([]);
+  const [busy, setBusy] = useState(false);
+  const { toast } = useToast();
+
+  const load = useCallback(async () => {
+    try {
+      setConflicts((await GetSyncConflicts()) || []);
+    } catch (error) {
+      console.error('Failed to load sync conflicts:', error);
+    }
+  }, []);
+
+  useEffect(() => {
+    load();
+    return EventsOn('sync:conflicts', () => load());
+  }, [load]);
+
+  const current = conflicts[0];
+
+  const resolve = async (resolution: service.Resolution) => {
+    if (!current) return;
+    setBusy(true);
+    try {
+      await ResolveSyncConflict(current.ID, resolution);
+      setConflicts((prev) => prev.slice(1));
+      onResolved();
+    } catch (error) {
+      toast({
+        variant: "destructive",
+        title: "Could not resolve conflict",
+        description: String(error),
+      });
+    } finally {
+      setBusy(false);
+    }
+  };
+
+  if (!current) return null;
+
+  const isCategory = current.Kind === 'update_category' || current.Kind === 'delete_category';
+  const title = isCategory
+    ? current.MineName || current.TheirsName || `Category ${current.CategoryID}`
+    : current.Mine?.Title || current.Theirs?.Title || `Item ${current.ItemID}`;
+
+  return (
+    <AlertDialog open={true}>
+      <AlertDialogContent className="max-w-[520px]">
+        <AlertDialogHeader>
+          <div className="flex items-center gap-2">
+            <GitMerge className="h-5 w-5" />
+            <AlertDialogTitle>Sync conflict</AlertDialogTitle>
+          </div>
+          <AlertDialogDescription className="pt-2">
+            <span className="font-medium text-foreground">"{title}"</span> {reasonText[current.Reason] || "has a conflict"}.
+            Choose which version to keep.
+            {conflicts.length > 1 && <> ({conflicts.length - 1} more after this one)</>}
+          </AlertDialogDescription>
+        </AlertDialogHeader>
+        <div className="flex gap-3">
+          {isCategory ? (
+            <>
+              <NameCard label="Mine" name={current.MineName} />
+              <NameCard label="Server" name={current.TheirsName} />
+            </>
+          ) : (
+            <>
+              <VersionCard label="Mine" item={current.Mine} />
+              <VersionCard label="Server" item={current.Theirs} />
+            </>
+          )}
+        </div>
+        <AlertDialogFooter>
+          <Button variant="outline" disabled={busy} onClick={() => resolve(service.Resolution.THEIRS)}>
+            Keep server
+          </Button>
+          {current.Mine && (
+            <Button variant="outline" disabled={busy} onClick={() => resolve(service.Resolution.BOTH)}>
+              Keep both
+            </Button>
+          )}
+          <Button disabled={busy} onClick={() => resolve(service.Resolution.MINE)}>
+            Keep mine
+          </Button>
+        </AlertDialogFooter>
+      </AlertDialogContent>
+    </AlertDialog>
+  );
+}
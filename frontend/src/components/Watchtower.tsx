@@ -0,0 +1,163 @@
+import { useEffect, useState, ReactNode } from "react";
+import { AlertTriangle, Copy, Clock, CreditCard, RefreshCw, ShieldAlert, ShieldCheck, Unlock } from "lucide-react";
+import { Button } from "@/components/ui/button";
+import { Badge } from "@/components/ui/badge";
+import { toast } from "sonner";
+import { GetBreachedPasswords, GetConnectionSettings, GetItemDetail, GetVaultHealthReport } from "@/wailsjs/go/main/App";
+import { service } from "@/wailsjs/go/models";
+import { PasswordEntry } from "@/types/password";
+import { convertToPasswordEntry } from "./PasswordList";
+
+interface WatchtowerProps {
+  onSelectPassword: (password: PasswordEntry) => void;
+  refreshTrigger?: number;
+}
+
+const strengthLabels = ["Very weak", "Weak", "Fair", "Good", "Strong"];
+
+export function Watchtower({ onSelectPassword, refreshTrigger = 0 }: WatchtowerProps) {
+  const [report, setReport] = useState<service.HealthReport | null>(null);
+  const [breached, setBreached] = useState<service.BreachedPassword[] | null>(null);
+  const [loading, setLoading] = useState(false);
+
+  const loadReport = async () => {
+    setLoading(true);
+    try {
+      setReport(await GetVaultHealthReport());
+    } catch (err) {
+      toast.error(`Failed to check vault health: ${err}`);
+    } finally {
+      setLoading(false);
+    }
+
+    // The breach check is optional and slower, so it fills its section in on its own
+    try {
+      const settings = await GetConnectionSettings();
+      setBreached(settings.breach?.enabled ? await GetBreachedPasswords() : null);
+    } catch (err) {
+      setBreached(null);
+      toast.error(`Failed to check for breached passwords: ${err}`);
+    }
+  };
+
+  useEffect(() => {
+    loadReport();
+  }, [refreshTrigger]);
+
+  const openItem = async (itemId: number) => {
+    try {
+      onSelectPassword(convertToPasswordEntry(await GetItemDetail(itemId)));
+    } catch (err) {
+      toast.error(`Failed to open item: ${err}`);
+    }
+  };
+
+  const itemButton = (item: service.HealthItem, detail?: ReactNode) => (
+    <button
+      key={item.itemId}
+      onClick={() => openItem(item.itemId)}
+      className="w-full flex items-center justify-between gap-2 rounded-md px-2 py-1.5 text-left text-sm hover:bg-secondary/50"
+    >
+      <span className="truncate">{item.title}</span>
+      {detail && <span className="shrink-0 text-xs text-muted-foreground">{detail}</span>}
+    </button>
+  );
+
+  const scoreColor = !report
+    ? "text-muted-foreground"
+    : report.score >= 90
+      ? "text-emerald-500"
+      : report.score >= 70
+        ? "text-yellow-500"
+        : "text-red-500";
+
+  return (
+    <div className="h-full flex flex-col">
+      <div className="border-b border-border p-4 flex items-start justify-between">
+        <div>
+          <h2 className="text-lg font-medium">Watchtower</h2>
+          <p className="text-xs text-muted-foreground">Passwords and cards that need attention</p>
+        </div>
+        <Button variant="ghost" size="sm" onClick={loadReport} disabled={loading} className="h-7 px-2 text-xs">
+          <RefreshCw className={`h-3 w-3 mr-1 ${loading ? "animate-spin" : ""}`} />
+          Refresh
+        </Button>
+      </div>
+
+      <div className="flex-1 overflow-auto custom-scrollbar p-4 space-y-5">
+        {report && (
+          <>
+            <div className="flex items-center gap-3">
+              <ShieldCheck className={`h-10 w-10 ${scoreColor}`} />
+              <div>
+                <div className={`text-2xl font-semibold ${scoreColor}`}>{report.score}%</div>
+                <div className="text-xs text-muted-foreground">
+                  of {report.totalItems} items have no issues
+                </div>
+              </div>
+            </div>
+
+            {breached && (
+              <Section icon={<ShieldAlert className="h-4 w-4" />} title="Breached passwords" count={breached.length}
+                empty="No passwords found in known breaches">
+                {breached.map((b) => itemButton(b.item, `Seen ${b.count.toLocaleString()} times`))}
+              </Section>
+            )}
+
+            <Section icon={<Copy className="h-4 w-4" />} title="Reused passwords" count={report.reused.length}
+              empty="Every website has its own password">
+              {report.reused.map((group, i) => (
+                <div key={i} className="rounded-md border border-border/50 p-1">
+                  {group.items.map((item) => itemButton(item))}
+                </div>
+              ))}
+            </Section>
+
+            <Section icon={<AlertTriangle className="h-4 w-4" />} title="Weak passwords" count={report.weak.length}
+              empty="No weak passwords">
+              {report.weak.map((weak) => itemButton(weak.item, strengthLabels[weak.score]))}
+            </Section>
+
+            <Section icon={<Clock className="h-4 w-4" />} title="Old passwords" count={report.old.length}
+              empty={`No passwords older than ${report.options.maxPasswordAgeDays} days`}>
+              {report.old.map((old) => itemButton(old.item, `${old.ageDays} days`))}
+            </Section>
+
+            <Section icon={<Unlock className="h-4 w-4" />} title="Unsecured websites" count={report.insecureUrls.length}
+              empty="Every link uses https">
+              {report.insecureUrls.map((insecure) => itemButton(insecure.item, insecure.url))}
+            </Section>
+
+            <Section icon={<CreditCard className="h-4 w-4" />} title="Expiring cards" count={report.cards.length}
+              empty="No cards expire soon">
+              {report.cards.map((card) =>
+                itemButton(card.item, card.expired ? `Expired ${card.expires}` : `Expires ${card.expires}`)
+              )}
+            </Section>
+          </>
+        )}
+      </div>
+    </div>
+  );
+}
+
+interface SectionProps {
+  icon: ReactNode;
+  title: string;
+  count: number;
+  empty: string;
+  children: ReactNode;
+}
+
+function Section({ icon, title, count, empty, children }: SectionProps) {
+  return (
+    <div className="space-y-2">
+      <div className="flex items-center gap-2 text-sm font-medium">
+        {icon}
+        <span>{title}</span>
+        <Badge variant={count > 0 ? "destructive" : "secondary"} className="ml-auto">{count}</Badge>
+      </div>
+      {count > 0 ? <div className="space-y-1">{children}</div> : <p className="text-xs text-muted-foreground">{empty}</p>}
+    </div>
+  );
+}
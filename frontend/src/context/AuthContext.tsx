@@ -1,8 +1,11 @@
 import React, { createContext, useContext, useState, ReactNode, useEffect, useCallback } from 'react';
-import { LoginUser, RegisterUser, CheckSession, LogoutUser } from '../../wailsjs/go/main/App';
+import { LoginUser, RegisterUser, CheckSession, LogoutUser, ReportActivity } from '../../wailsjs/go/main/App';
+import { EventsOn } from '../../wailsjs/runtime/runtime';
+import { client } from '../../wailsjs/go/models';
 
 interface AuthContextType {
   isAuthenticated: boolean;
+  isOffline: boolean;
   isRegistrationComplete: boolean;
   seedPhrase: string | null;
   userEmail: string | null;
@@ -15,6 +18,7 @@ interface AuthContextType {
 
 const AuthContext = createContext<AuthContextType>({
   isAuthenticated: false,
+  isOffline: false,
   isRegistrationComplete: false,
   seedPhrase: null,
   userEmail: null,
@@ -31,6 +35,7 @@ interface AuthProviderProps {
 
 export const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
   const [isAuthenticated, setIsAuthenticated] = useState<boolean>(false);
+  const [isOffline, setIsOffline] = useState<boolean>(false);
   const [isRegistrationComplete, setIsRegistrationComplete] = useState<boolean>(true);
   const [seedPhrase, setSeedPhrase] = useState<string | null>(null);
   const [userEmail, setUserEmail] = useState<string>(() => {
@@ -50,12 +55,16 @@ export const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
       // Check for success status in the response
       if (response && response.success) {
         setIsAuthenticated(true);
+        setIsOffline(!!response.offline);
         setUserEmail(email);
         localStorage.setItem('userEmail', email);
         return true;
       } else if (response) {
         // If there's a response but success is false, use the message from the response
-        throw new Error(response.message || "Login failed");
+        // and keep the error code so callers can tell a bad password from an outage
+        const loginError = new Error(response.message || "Login failed") as Error & { code?: client.ErrorCode };
+        loginError.code = response.code;
+        throw loginError;
       } else {
         throw new Error("Login failed");
       }
@@ -111,6 +120,37 @@ export const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
     }
   }, []);
 
+  // Keep the Go-side idle timer alive while the user is interacting with the app
+  useEffect(() => {
+    if (!isAuthenticated) return;
+
+    let lastReport = 0;
+    const onActivity = () => {
+      const now = Date.now();
+      if (now - lastReport < 30000) return;
+      lastReport = now;
+      ReportActivity().catch((err) => console.error('ReportActivity failed:', err));
+    };
+
+    const events = ['mousemove', 'mousedown', 'keydown', 'wheel', 'touchstart'];
+    events.forEach((name) => window.addEventListener(name, onActivity, { passive: true }));
+    return () => events.forEach((name) => window.removeEventListener(name, onActivity));
+  }, [isAuthenticated]);
+
+  // The vault keys are wiped in Go when the idle timer fires, send the user back to login
+  useEffect(() => {
+    return EventsOn('vault:locked', () => {
+      setIsAuthenticated(false);
+    });
+  }, []);
+
+  // Go reports when the vault switches to or from the read-only offline copy
+  useEffect(() => {
+    return EventsOn('vault:offline', (offline: boolean) => {
+      setIsOffline(offline);
+    });
+  }, []);
+
   const checkSession = useCallback(async () => {
     try {
       const response = await CheckSession();
@@ -132,6 +172,7 @@ export const AuthProvider: React.FC<AuthProviderProps> = ({ children }) => {
   return (
     <AuthContext.Provider value={{
       isAuthenticated,
+      isOffline,
       isRegistrationComplete,
       seedPhrase,
       userEmail,
@@ -2,6 +2,7 @@ import React, { createContext, useState, useContext, useEffect, ReactNode } from
 import { GetCategoryList } from '@/wailsjs/go/main/App';
 import { useToast } from '@/components/ui/use-toast';
 import { useAuth } from '@/context/AuthContext'; // Add this import
+import { EventsOn } from '@/wailsjs/runtime/runtime';
 
 // Define the category interface
 export interface Category {
@@ -71,6 +72,14 @@ export function CategoryProvider({ children }: { children: ReactNode }) {
     }
   }, [isAuthenticated]);
 
+  // Item counts and names follow the vault change feed
+  useEffect(() => {
+    if (!isAuthenticated) return;
+    return EventsOn('vault:changed', () => {
+      loadCategories();
+    });
+  }, [isAuthenticated]);
+
   const refreshCategories = async () => {
     await loadCategories();
   };
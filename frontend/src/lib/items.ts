@@ -0,0 +1,55 @@
+import { schema, service } from "@/wailsjs/go/models";
+import { PasswordEntry } from "@/types/password";
+
+// toItemFields picks the fields of the entry's type, the backend rejects anything else
+export function toItemFields(entry: Partial<PasswordEntry>): schema.Fields {
+  const e = entry as any;
+  const notes = e.notes || "";
+  const customFields = e.customFields || [];
+
+  switch (entry.type) {
+    case "website":
+      return schema.Fields.createFrom({
+        website: { username: e.username || "", password: e.password || "", url: e.url || "", totp: e.totp || "", notes, customFields },
+      });
+    case "card":
+      return schema.Fields.createFrom({
+        card: {
+          cardholderName: e.cardholderName || "",
+          cardNumber: e.cardNumber || "",
+          expirationMonth: e.expirationMonth || "",
+          expirationYear: e.expirationYear || "",
+          cvv: e.cvv || "",
+          notes,
+          customFields,
+        },
+      });
+    case "identity":
+      return schema.Fields.createFrom({
+        identity: {
+          firstName: e.firstName || "",
+          lastName: e.lastName || "",
+          email: e.email || "",
+          phone: e.phone || "",
+          address: e.address || "",
+          notes,
+          customFields,
+        },
+      });
+    case "crypto":
+      return schema.Fields.createFrom({
+        crypto: { walletName: e.walletName || "", address: e.address || "", privateKey: e.privateKey || "", notes, customFields },
+      });
+    case "ssh_key":
+      return schema.Fields.createFrom({
+        ssh_key: { privateKey: e.privateKey || "", publicKey: e.publicKey || "", comment: e.comment || "", notes, customFields },
+      });
+    default:
+      return schema.Fields.createFrom({ memo: { content: e.content || "", notes, customFields } });
+  }
+}
+
+// toItemInput builds the typed payload for CreateItemClient and UpdateItemClient
+export function toItemInput(entry: Partial<PasswordEntry>): service.ItemInput {
+  return service.ItemInput.createFrom({ title: entry.title || "", fields: toItemFields(entry) });
+}
@@ -1,4 +1,6 @@
-export type PasswordType = "website" | "identity" | "card" | "crypto" | "memo";
+import { schema } from "@/wailsjs/go/models";
+
+export type PasswordType = "website" | "identity" | "card" | "crypto" | "memo" | "ssh_key";
 
 export interface PasswordEntryBase {
   id: string;
@@ -8,6 +10,7 @@ export interface PasswordEntryBase {
   dateModified: Date;
   notes?: string;
   categoryId?: number | null;
+  customFields?: schema.CustomField[];
 }
 
 export interface WebsiteEntry extends PasswordEntryBase {
@@ -15,6 +18,7 @@ export interface WebsiteEntry extends PasswordEntryBase {
   username: string;
   password: string;
   url?: string;
+  totp?: string;
 }
 
 export interface IdentityEntry extends PasswordEntryBase {
@@ -47,5 +51,12 @@ export interface MemoEntry extends PasswordEntryBase {
   content: string;
 }
 
-export type PasswordEntry = WebsiteEntry | IdentityEntry | CardEntry | CryptoEntry | MemoEntry;
+export interface SSHKeyEntry extends PasswordEntryBase {
+  type: "ssh_key";
+  privateKey: string;
+  publicKey: string;
+  comment: string;
+}
+
+export type PasswordEntry = WebsiteEntry | IdentityEntry | CardEntry | CryptoEntry | MemoEntry | SSHKeyEntry;
 
@@ -1,33 +1,94 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 import {service} from '../models';
+import {auth} from '../models';
+import {generator} from '../models';
+import {sshagent} from '../models';
+import {schema} from '../models';
+import {config} from '../models';
+
+export function AddAttachment(arg1:number):Promise<service.Attachment>;
+
+export function ChangeMasterPassword(arg1:string,arg2:string):Promise<void>;
+
+export function CheckPasswordBreach(arg1:string):Promise<number>;
+
+export function CheckPasswordStrength(arg1:string,arg2:string):Promise<auth.PasswordCheck>;
 
 export function CheckSession():Promise<{[key: string]: any}>;
 
 export function CreateCategoryClient(arg1:string):Promise<service.CreateCategoryResponse>;
 
-export function CreateItemClient(arg1:string,arg2:string,arg3:{[key: string]: any}):Promise<service.CreateItemResponse>;
+export function CreateItemClient(arg1:service.ItemInput):Promise<service.CreateItemResponse>;
 
 export function DecryptAES256GCM(arg1:Array<number>,arg2:Array<number>,arg3:Array<number>):Promise<Array<number>>;
 
+export function DeleteAttachment(arg1:number):Promise<void>;
+
 export function DeleteCategoryClient(arg1:number):Promise<service.DeleteCategoryResponse>;
 
 export function DeleteItemClient(arg1:number):Promise<service.DeleteItemResponse>;
 
+export function DiffItemVersions(arg1:number,arg2:number,arg3:number):Promise<service.ItemDiff>;
+
 export function EmailToSHA256(arg1:string):Promise<string>;
 
 export function EncryptAES256GCM(arg1:Array<number>,arg2:Array<number>,arg3:Array<number>):Promise<Array<number>>;
 
 export function GenerateIV():Promise<Array<number>>;
 
+export function GeneratePassphrase(arg1:generator.PassphrasePolicy):Promise<generator.Result>;
+
+export function GeneratePassword(arg1:generator.PasswordPolicy):Promise<generator.Result>;
+
+export function GenerateSSHKey(arg1:sshagent.KeyAlgorithm,arg2:number,arg3:string):Promise<schema.SSHKey>;
+
 export function GenerateSessionKey():Promise<Array<number>>;
 
+export function GetAttachments(arg1:number):Promise<Array<service.Attachment>>;
+
+export function GetAutoLockSettings():Promise<config.Preferences>;
+
+export function GetBreachedPasswords():Promise<Array<service.BreachedPassword>>;
+
 export function GetCategoryList():Promise<Array<{[key: string]: any}>>;
 
+export function GetConnectionSettings():Promise<config.Config>;
+
+export function GetDefaultPassphrasePolicy():Promise<generator.PassphrasePolicy>;
+
+export function GetDefaultPasswordPolicy():Promise<generator.PasswordPolicy>;
+
+export function GetItemDetail(arg1:number):Promise<service.AfterItem>;
+
+export function GetItemTypes():Promise<Array<schema.TypeInfo>>;
+
+export function GetItemVersions(arg1:number):Promise<Array<service.AfterItemVersion>>;
+
+export function GetPasswordHistory(arg1:number):Promise<schema.PasswordHistory>;
+
 export function GetPasswordList():Promise<Array<{[key: string]: any}>>;
 
+export function GetPendingChangeCount():Promise<number>;
+
+export function GetSSHAgentStatus():Promise<sshagent.Status>;
+
+export function GetSyncConflicts():Promise<Array<service.SyncConflict>>;
+
+export function GetTOTPCode(arg1:number):Promise<service.TOTPCode>;
+
+export function GetVaultHealthReport():Promise<service.HealthReport>;
+
 export function Greet(arg1:string):Promise<string>;
 
+export function IsKeyRotationPending():Promise<boolean>;
+
+export function IsOffline():Promise<boolean>;
+
+export function IsVaultLocked():Promise<boolean>;
+
+export function LockVault():Promise<void>;
+
 export function LoginUser(arg1:string,arg2:string):Promise<{[key: string]: any}>;
 
 export function LogoutUser():Promise<{[key: string]: any}>;
@@ -40,10 +101,36 @@ export function RecoverySetup(arg1:string):Promise<string>;
 
 export function RegisterUser(arg1:string,arg2:string):Promise<string>;
 
+export function ReportActivity():Promise<void>;
+
+export function ResolveSSHConfirmation(arg1:string,arg2:boolean):Promise<void>;
+
+export function ResolveSyncConflict(arg1:string,arg2:service.Resolution):Promise<void>;
+
+export function RestoreItemVersion(arg1:number,arg2:number):Promise<service.UpdateItemResponse>;
+
+export function RestorePassword(arg1:number,arg2:number):Promise<service.UpdateItemResponse>;
+
+export function RotateVaultKey():Promise<string>;
+
+export function SaveAttachment(arg1:number,arg2:number,arg3:string):Promise<string>;
+
 export function SimplePOC(arg1:string):Promise<void>;
 
+export function StartSSHAgent():Promise<sshagent.Status>;
+
+export function StopSSHAgent():Promise<sshagent.Status>;
+
+export function SyncVault():Promise<service.ChangeSet>;
+
 export function ToggleBookmark(arg1:number,arg2:boolean):Promise<service.BookmarkResponse>;
 
+export function UnlockVault(arg1:string):Promise<{[key: string]: any}>;
+
+export function UpdateAutoLockSettings(arg1:config.Preferences):Promise<void>;
+
 export function UpdateCategoryClient(arg1:number,arg2:string):Promise<service.UpdateCategoryResponse>;
 
-export function UpdateItemClient(arg1:number,arg2:any,arg3:string,arg4:{[key: string]: any}):Promise<service.UpdateItemResponse>;
+export function UpdateConnectionSettings(arg1:config.Config):Promise<void>;
+
+export function UpdateItemClient(arg1:number,arg2:any,arg3:service.ItemInput):Promise<service.UpdateItemResponse>;
@@ -2,6 +2,22 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AddAttachment(arg1) {
+  return window['go']['main']['App']['AddAttachment'](arg1);
+}
+
+export function ChangeMasterPassword(arg1, arg2) {
+  return window['go']['main']['App']['ChangeMasterPassword'](arg1, arg2);
+}
+
+export function CheckPasswordBreach(arg1) {
+  return window['go']['main']['App']['CheckPasswordBreach'](arg1);
+}
+
+export function CheckPasswordStrength(arg1, arg2) {
+  return window['go']['main']['App']['CheckPasswordStrength'](arg1, arg2);
+}
+
 export function CheckSession() {
   return window['go']['main']['App']['CheckSession']();
 }
@@ -10,14 +26,18 @@ export function CreateCategoryClient(arg1) {
   return window['go']['main']['App']['CreateCategoryClient'](arg1);
 }
 
-export function CreateItemClient(arg1, arg2, arg3) {
-  return window['go']['main']['App']['CreateItemClient'](arg1, arg2, arg3);
+export function CreateItemClient(arg1) {
+  return window['go']['main']['App']['CreateItemClient'](arg1);
 }
 
 export function DecryptAES256GCM(arg1, arg2, arg3) {
   return window['go']['main']['App']['DecryptAES256GCM'](arg1, arg2, arg3);
 }
 
+export function DeleteAttachment(arg1) {
+  return window['go']['main']['App']['DeleteAttachment'](arg1);
+}
+
 export function DeleteCategoryClient(arg1) {
   return window['go']['main']['App']['DeleteCategoryClient'](arg1);
 }
@@ -26,6 +46,10 @@ export function DeleteItemClient(arg1) {
   return window['go']['main']['App']['DeleteItemClient'](arg1);
 }
 
+export function DiffItemVersions(arg1, arg2, arg3) {
+  return window['go']['main']['App']['DiffItemVersions'](arg1, arg2, arg3);
+}
+
 export function EmailToSHA256(arg1) {
   return window['go']['main']['App']['EmailToSHA256'](arg1);
 }
@@ -38,22 +62,110 @@ export function GenerateIV() {
   return window['go']['main']['App']['GenerateIV']();
 }
 
+export function GeneratePassphrase(arg1) {
+  return window['go']['main']['App']['GeneratePassphrase'](arg1);
+}
+
+export function GeneratePassword(arg1) {
+  return window['go']['main']['App']['GeneratePassword'](arg1);
+}
+
+export function GenerateSSHKey(arg1, arg2, arg3) {
+  return window['go']['main']['App']['GenerateSSHKey'](arg1, arg2, arg3);
+}
+
 export function GenerateSessionKey() {
   return window['go']['main']['App']['GenerateSessionKey']();
 }
 
+export function GetAttachments(arg1) {
+  return window['go']['main']['App']['GetAttachments'](arg1);
+}
+
+export function GetAutoLockSettings() {
+  return window['go']['main']['App']['GetAutoLockSettings']();
+}
+
+export function GetBreachedPasswords() {
+  return window['go']['main']['App']['GetBreachedPasswords']();
+}
+
 export function GetCategoryList() {
   return window['go']['main']['App']['GetCategoryList']();
 }
 
+export function GetConnectionSettings() {
+  return window['go']['main']['App']['GetConnectionSettings']();
+}
+
+export function GetDefaultPassphrasePolicy() {
+  return window['go']['main']['App']['GetDefaultPassphrasePolicy']();
+}
+
+export function GetDefaultPasswordPolicy() {
+  return window['go']['main']['App']['GetDefaultPasswordPolicy']();
+}
+
+export function GetItemDetail(arg1) {
+  return window['go']['main']['App']['GetItemDetail'](arg1);
+}
+
+export function GetItemTypes() {
+  return window['go']['main']['App']['GetItemTypes']();
+}
+
+export function GetItemVersions(arg1) {
+  return window['go']['main']['App']['GetItemVersions'](arg1);
+}
+
+export function GetPasswordHistory(arg1) {
+  return window['go']['main']['App']['GetPasswordHistory'](arg1);
+}
+
 export function GetPasswordList() {
   return window['go']['main']['App']['GetPasswordList']();
 }
 
+export function GetPendingChangeCount() {
+  return window['go']['main']['App']['GetPendingChangeCount']();
+}
+
+export function GetSSHAgentStatus() {
+  return window['go']['main']['App']['GetSSHAgentStatus']();
+}
+
+export function GetSyncConflicts() {
+  return window['go']['main']['App']['GetSyncConflicts']();
+}
+
+export function GetTOTPCode(arg1) {
+  return window['go']['main']['App']['GetTOTPCode'](arg1);
+}
+
+export function GetVaultHealthReport() {
+  return window['go']['main']['App']['GetVaultHealthReport']();
+}
+
 export function Greet(arg1) {
   return window['go']['main']['App']['Greet'](arg1);
 }
 
+export function IsKeyRotationPending() {
+  return window['go']['main']['App']['IsKeyRotationPending']();
+}
+
+export function IsOffline() {
+  return window['go']['main']['App']['IsOffline']();
+}
+
+export function IsVaultLocked() {
+  return window['go']['main']['App']['IsVaultLocked']();
+}
+
+export function LockVault() {
+  return window['go']['main']['App']['LockVault']();
+}
+
 export function LoginUser(arg1, arg2) {
   return window['go']['main']['App']['LoginUser'](arg1, arg2);
 }
@@ -78,18 +190,70 @@ export function RegisterUser(arg1, arg2) {
   return window['go']['main']['App']['RegisterUser'](arg1, arg2);
 }
 
+export function ReportActivity() {
+  return window['go']['main']['App']['ReportActivity']();
+}
+
+export function ResolveSSHConfirmation(arg1, arg2) {
+  return window['go']['main']['App']['ResolveSSHConfirmation'](arg1, arg2);
+}
+
+export function ResolveSyncConflict(arg1, arg2) {
+  return window['go']['main']['App']['ResolveSyncConflict'](arg1, arg2);
+}
+
+export function RestoreItemVersion(arg1, arg2) {
+  return window['go']['main']['App']['RestoreItemVersion'](arg1, arg2);
+}
+
+export function RestorePassword(arg1, arg2) {
+  return window['go']['main']['App']['RestorePassword'](arg1, arg2);
+}
+
+export function RotateVaultKey() {
+  return window['go']['main']['App']['RotateVaultKey']();
+}
+
+export function SaveAttachment(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SaveAttachment'](arg1, arg2, arg3);
+}
+
 export function SimplePOC(arg1) {
   return window['go']['main']['App']['SimplePOC'](arg1);
 }
 
+export function StartSSHAgent() {
+  return window['go']['main']['App']['StartSSHAgent']();
+}
+
+export function StopSSHAgent() {
+  return window['go']['main']['App']['StopSSHAgent']();
+}
+
+export function SyncVault() {
+  return window['go']['main']['App']['SyncVault']();
+}
+
 export function ToggleBookmark(arg1, arg2) {
   return window['go']['main']['App']['ToggleBookmark'](arg1, arg2);
 }
 
+export function UnlockVault(arg1) {
+  return window['go']['main']['App']['UnlockVault'](arg1);
+}
+
+export function UpdateAutoLockSettings(arg1) {
+  return window['go']['main']['App']['UpdateAutoLockSettings'](arg1);
+}
+
 export function UpdateCategoryClient(arg1, arg2) {
   return window['go']['main']['App']['UpdateCategoryClient'](arg1, arg2);
 }
 
-export function UpdateItemClient(arg1, arg2, arg3, arg4) {
-  return window['go']['main']['App']['UpdateItemClient'](arg1, arg2, arg3, arg4);
+export function UpdateConnectionSettings(arg1) {
+  return window['go']['main']['App']['UpdateConnectionSettings'](arg1);
+}
+
+export function UpdateItemClient(arg1, arg2, arg3) {
+  return window['go']['main']['App']['UpdateItemClient'](arg1, arg2, arg3);
 }
@@ -1,50 +1,1239 @@
+export namespace auth {
+	
+	export class PasswordCheck {
+	    acceptable: boolean;
+	    message: string;
+	    strength: strength.Result;
+	
+	    static createFrom(source: any = {}) {
+	        return new PasswordCheck(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.acceptable = source["acceptable"];
+	        this.message = source["message"];
+	        this.strength = this.convertValues(source["strength"], strength.Result);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace client {
+	
+	export enum ErrorCode {
+	    NONE = "",
+	    INVALID_CREDENTIALS = "INVALID_CREDENTIALS",
+	    USER_NOT_FOUND = "USER_NOT_FOUND",
+	    NETWORK_ERROR = "NETWORK_ERROR",
+	    SERVER_ERROR = "SERVER_ERROR",
+	    SESSION_EXPIRED = "SESSION_EXPIRED",
+	    VAULT_LOCKED = "VAULT_LOCKED",
+	    OFFLINE = "OFFLINE",
+	    INVALID_ITEM = "INVALID_ITEM",
+	    UNKNOWN = "UNKNOWN",
+	}
+
+}
+
+export namespace config {
+	
+	export class BreachConfig {
+	    enabled: boolean;
+	    range_url: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BreachConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.range_url = source["range_url"];
+	    }
+	}
+	export class TLSConfig {
+	    insecure_skip_verify: boolean;
+	    ca_file?: string;
+	    server_name?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TLSConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.insecure_skip_verify = source["insecure_skip_verify"];
+	        this.ca_file = source["ca_file"];
+	        this.server_name = source["server_name"];
+	    }
+	}
+	export class Config {
+	    backend_url: string;
+	    timeout_seconds: number;
+	    tls: TLSConfig;
+	    breach: BreachConfig;
+	
+	    static createFrom(source: any = {}) {
+	        return new Config(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.backend_url = source["backend_url"];
+	        this.timeout_seconds = source["timeout_seconds"];
+	        this.tls = this.convertValues(source["tls"], TLSConfig);
+	        this.breach = this.convertValues(source["breach"], BreachConfig);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Preferences {
+	    auto_lock: boolean;
+	    auto_lock_minutes: number;
+	    password_history_limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Preferences(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.auto_lock = source["auto_lock"];
+	        this.auto_lock_minutes = source["auto_lock_minutes"];
+	        this.password_history_limit = source["password_history_limit"];
+	    }
+	}
+
+}
+
+export namespace generator {
+	
+	export class PassphrasePolicy {
+	    words: number;
+	    separator: string;
+	    capitalize: boolean;
+	    includeNumber: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PassphrasePolicy(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.words = source["words"];
+	        this.separator = source["separator"];
+	        this.capitalize = source["capitalize"];
+	        this.includeNumber = source["includeNumber"];
+	    }
+	}
+	export class PasswordPolicy {
+	    length: number;
+	    lowercase: boolean;
+	    uppercase: boolean;
+	    digits: boolean;
+	    symbols: boolean;
+	    symbolSet?: string;
+	    minLowercase: number;
+	    minUppercase: number;
+	    minDigits: number;
+	    minSymbols: number;
+	    excludeAmbiguous: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PasswordPolicy(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.length = source["length"];
+	        this.lowercase = source["lowercase"];
+	        this.uppercase = source["uppercase"];
+	        this.digits = source["digits"];
+	        this.symbols = source["symbols"];
+	        this.symbolSet = source["symbolSet"];
+	        this.minLowercase = source["minLowercase"];
+	        this.minUppercase = source["minUppercase"];
+	        this.minDigits = source["minDigits"];
+	        this.minSymbols = source["minSymbols"];
+	        this.excludeAmbiguous = source["excludeAmbiguous"];
+	    }
+	}
+	export class Result {
+	    value: string;
+	    entropy: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Result(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.value = source["value"];
+	        this.entropy = source["entropy"];
+	    }
+	}
+
+}
+
+export namespace schema {
+	
+	export enum FieldKind {
+	    TEXT = "text",
+	    HIDDEN = "hidden",
+	    URL = "url",
+	    DATE = "date",
+	    TOTP = "totp",
+	}
+	export class CustomField {
+	    label: string;
+	    kind: FieldKind;
+	    value: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CustomField(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.label = source["label"];
+	        this.kind = source["kind"];
+	        this.value = source["value"];
+	    }
+	}
+	export class Card {
+	    cardholderName: string;
+	    cardNumber: string;
+	    expirationMonth: string;
+	    expirationYear: string;
+	    cvv: string;
+	    notes: string;
+	    customFields?: CustomField[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Card(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.cardholderName = source["cardholderName"];
+	        this.cardNumber = source["cardNumber"];
+	        this.expirationMonth = source["expirationMonth"];
+	        this.expirationYear = source["expirationYear"];
+	        this.cvv = source["cvv"];
+	        this.notes = source["notes"];
+	        this.customFields = this.convertValues(source["customFields"], CustomField);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CryptoWallet {
+	    walletName: string;
+	    address: string;
+	    privateKey: string;
+	    notes: string;
+	    customFields?: CustomField[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CryptoWallet(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.walletName = source["walletName"];
+	        this.address = source["address"];
+	        this.privateKey = source["privateKey"];
+	        this.notes = source["notes"];
+	        this.customFields = this.convertValues(source["customFields"], CustomField);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class SSHKey {
+	    privateKey: string;
+	    publicKey: string;
+	    comment: string;
+	    notes: string;
+	    customFields?: CustomField[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SSHKey(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.privateKey = source["privateKey"];
+	        this.publicKey = source["publicKey"];
+	        this.comment = source["comment"];
+	        this.notes = source["notes"];
+	        this.customFields = this.convertValues(source["customFields"], CustomField);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Memo {
+	    content: string;
+	    notes: string;
+	    customFields?: CustomField[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Memo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.content = source["content"];
+	        this.notes = source["notes"];
+	        this.customFields = this.convertValues(source["customFields"], CustomField);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Identity {
+	    firstName: string;
+	    lastName: string;
+	    email: string;
+	    phone: string;
+	    address: string;
+	    notes: string;
+	    customFields?: CustomField[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Identity(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.firstName = source["firstName"];
+	        this.lastName = source["lastName"];
+	        this.email = source["email"];
+	        this.phone = source["phone"];
+	        this.address = source["address"];
+	        this.notes = source["notes"];
+	        this.customFields = this.convertValues(source["customFields"], CustomField);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class PasswordEntry {
+	    password: string;
+	    // Go type: time
+	    replaced: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new PasswordEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.password = source["password"];
+	        this.replaced = this.convertValues(source["replaced"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Website {
+	    username: string;
+	    password: string;
+	    url: string;
+	    totp?: string;
+	    notes: string;
+	    customFields?: CustomField[];
+	    passwordHistory?: PasswordEntry[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Website(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.password = source["password"];
+	        this.url = source["url"];
+	        this.totp = source["totp"];
+	        this.notes = source["notes"];
+	        this.customFields = this.convertValues(source["customFields"], CustomField);
+	        this.passwordHistory = this.convertValues(source["passwordHistory"], PasswordEntry);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Fields {
+	    website?: Website;
+	    card?: Card;
+	    identity?: Identity;
+	    crypto?: CryptoWallet;
+	    memo?: Memo;
+	    ssh_key?: SSHKey;
+	
+	    static createFrom(source: any = {}) {
+	        return new Fields(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.website = this.convertValues(source["website"], Website);
+	        this.card = this.convertValues(source["card"], Card);
+	        this.identity = this.convertValues(source["identity"], Identity);
+	        this.crypto = this.convertValues(source["crypto"], CryptoWallet);
+	        this.memo = this.convertValues(source["memo"], Memo);
+	        this.ssh_key = this.convertValues(source["ssh_key"], SSHKey);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	
+	
+	export class TypeInfo {
+	    type: string;
+	    backendName: string;
+	    aliases?: string[];
+	    label: string;
+	    description: string;
+	    sensitive: string[];
+	    display: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TypeInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.backendName = source["backendName"];
+	        this.aliases = source["aliases"];
+	        this.label = source["label"];
+	        this.description = source["description"];
+	        this.sensitive = source["sensitive"];
+	        this.display = source["display"];
+	    }
+	}
+
+}
+
 export namespace service {
 	
-	export class BookmarkResponse {
-	    item_id: number;
-	    status: string;
+	export enum Resolution {
+	    MINE = "mine",
+	    THEIRS = "theirs",
+	    BOTH = "both",
+	}
+	export class AfterCategory {
+	    CategoryID: number;
+	    CategoryName: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AfterCategory(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.CategoryID = source["CategoryID"];
+	        this.CategoryName = source["CategoryName"];
+	    }
+	}
+	export class AfterItem {
+	    ItemID: number;
+	    CategoryID?: number;
+	    Title: string;
+	    TypeName: string;
+	    // Go type: time
+	    DateCreate: any;
+	    // Go type: time
+	    DateModify: any;
+	    Data?: schema.Fields;
+	    IsBookmark: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new AfterItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ItemID = source["ItemID"];
+	        this.CategoryID = source["CategoryID"];
+	        this.Title = source["Title"];
+	        this.TypeName = source["TypeName"];
+	        this.DateCreate = this.convertValues(source["DateCreate"], null);
+	        this.DateModify = this.convertValues(source["DateModify"], null);
+	        this.Data = this.convertValues(source["Data"], schema.Fields);
+	        this.IsBookmark = source["IsBookmark"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AfterItemVersion {
+	    version: number;
+	    categoryId?: number;
+	    title: string;
+	    // Go type: time
+	    dateModify: any;
+	    data?: schema.Fields;
+	
+	    static createFrom(source: any = {}) {
+	        return new AfterItemVersion(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.categoryId = source["categoryId"];
+	        this.title = source["title"];
+	        this.dateModify = this.convertValues(source["dateModify"], null);
+	        this.data = this.convertValues(source["data"], schema.Fields);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Attachment {
+	    attachmentId: number;
+	    itemId: number;
+	    name: string;
+	    size: number;
+	    // Go type: time
+	    dateCreate: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Attachment(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.attachmentId = source["attachmentId"];
+	        this.itemId = source["itemId"];
+	        this.name = source["name"];
+	        this.size = source["size"];
+	        this.dateCreate = this.convertValues(source["dateCreate"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class BookmarkResponse {
+	    item_id: number;
+	    status: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BookmarkResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.item_id = source["item_id"];
+	        this.status = source["status"];
+	    }
+	}
+	export class HealthItem {
+	    itemId: number;
+	    title: string;
+	    typeName: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new HealthItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.itemId = source["itemId"];
+	        this.title = source["title"];
+	        this.typeName = source["typeName"];
+	    }
+	}
+	export class BreachedPassword {
+	    item: HealthItem;
+	    count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BreachedPassword(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.item = this.convertValues(source["item"], HealthItem);
+	        this.count = source["count"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Change {
+	    Kind: string;
+	    ItemID?: number;
+	    CategoryID?: number;
+	    Item?: AfterItem;
+	    Category?: AfterCategory;
+	
+	    static createFrom(source: any = {}) {
+	        return new Change(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Kind = source["Kind"];
+	        this.ItemID = source["ItemID"];
+	        this.CategoryID = source["CategoryID"];
+	        this.Item = this.convertValues(source["Item"], AfterItem);
+	        this.Category = this.convertValues(source["Category"], AfterCategory);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ChangeSet {
+	    Revision: number;
+	    Changes: Change[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ChangeSet(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Revision = source["Revision"];
+	        this.Changes = this.convertValues(source["Changes"], Change);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CreateCategoryResponse {
+	    category_id: number;
+	    Category: string;
+	    status: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CreateCategoryResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.category_id = source["category_id"];
+	        this.Category = source["Category"];
+	        this.status = source["status"];
+	    }
+	}
+	export class CreateItemResponse {
+	    item_id: number;
+	    title: string;
+	    // Go type: time
+	    createAt: any;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CreateItemResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.item_id = source["item_id"];
+	        this.title = source["title"];
+	        this.createAt = this.convertValues(source["createAt"], null);
+	        this.message = source["message"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DeleteCategoryResponse {
+	    category_id: number;
+	    status: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeleteCategoryResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.category_id = source["category_id"];
+	        this.status = source["status"];
+	    }
+	}
+	export class DeleteItemResponse {
+	    item_id: number;
+	    status: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeleteItemResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.item_id = source["item_id"];
+	        this.status = source["status"];
+	    }
+	}
+	export class ExpiringCard {
+	    item: HealthItem;
+	    expires: string;
+	    daysLeft: number;
+	    expired: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExpiringCard(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.item = this.convertValues(source["item"], HealthItem);
+	        this.expires = source["expires"];
+	        this.daysLeft = source["daysLeft"];
+	        this.expired = source["expired"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class FieldChange {
+	    field: string;
+	    before: string;
+	    after: string;
+	    sensitive: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FieldChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.field = source["field"];
+	        this.before = source["before"];
+	        this.after = source["after"];
+	        this.sensitive = source["sensitive"];
+	    }
+	}
+	
+	export class HealthOptions {
+	    maxPasswordAgeDays: number;
+	    cardExpiryDays: number;
+	    minPasswordScore: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HealthOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.maxPasswordAgeDays = source["maxPasswordAgeDays"];
+	        this.cardExpiryDays = source["cardExpiryDays"];
+	        this.minPasswordScore = source["minPasswordScore"];
+	    }
+	}
+	export class InsecureURL {
+	    item: HealthItem;
+	    url: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new InsecureURL(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.item = this.convertValues(source["item"], HealthItem);
+	        this.url = source["url"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OldPassword {
+	    item: HealthItem;
+	    // Go type: time
+	    changed: any;
+	    ageDays: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new OldPassword(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.item = this.convertValues(source["item"], HealthItem);
+	        this.changed = this.convertValues(source["changed"], null);
+	        this.ageDays = source["ageDays"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class WeakPassword {
+	    item: HealthItem;
+	    score: number;
+	    warning: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new BookmarkResponse(source);
+	        return new WeakPassword(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.item_id = source["item_id"];
-	        this.status = source["status"];
+	        this.item = this.convertValues(source["item"], HealthItem);
+	        this.score = source["score"];
+	        this.warning = source["warning"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
-	export class CreateCategoryResponse {
-	    Category: string;
-	    status: string;
+	export class ReusedPassword {
+	    items: HealthItem[];
 	
 	    static createFrom(source: any = {}) {
-	        return new CreateCategoryResponse(source);
+	        return new ReusedPassword(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.Category = source["Category"];
-	        this.status = source["status"];
+	        this.items = this.convertValues(source["items"], HealthItem);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HealthReport {
+	    // Go type: time
+	    generatedAt: any;
+	    options: HealthOptions;
+	    totalItems: number;
+	    passwords: number;
+	    score: number;
+	    reused: ReusedPassword[];
+	    weak: WeakPassword[];
+	    old: OldPassword[];
+	    insecureUrls: InsecureURL[];
+	    cards: ExpiringCard[];
+	
+	    static createFrom(source: any = {}) {
+	        return new HealthReport(source);
 	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.generatedAt = this.convertValues(source["generatedAt"], null);
+	        this.options = this.convertValues(source["options"], HealthOptions);
+	        this.totalItems = source["totalItems"];
+	        this.passwords = source["passwords"];
+	        this.score = source["score"];
+	        this.reused = this.convertValues(source["reused"], ReusedPassword);
+	        this.weak = this.convertValues(source["weak"], WeakPassword);
+	        this.old = this.convertValues(source["old"], OldPassword);
+	        this.insecureUrls = this.convertValues(source["insecureUrls"], InsecureURL);
+	        this.cards = this.convertValues(source["cards"], ExpiringCard);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
-	export class CreateItemResponse {
-	    item_id: number;
+	
+	export class ItemDiff {
+	    itemId: number;
+	    from: number;
+	    to: number;
+	    changes: FieldChange[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ItemDiff(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.itemId = source["itemId"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.changes = this.convertValues(source["changes"], FieldChange);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ItemInput {
 	    title: string;
-	    // Go type: time
-	    createAt: any;
-	    message: string;
+	    fields: schema.Fields;
 	
 	    static createFrom(source: any = {}) {
-	        return new CreateItemResponse(source);
+	        return new ItemInput(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.item_id = source["item_id"];
 	        this.title = source["title"];
-	        this.createAt = this.convertValues(source["createAt"], null);
-	        this.message = source["message"];
+	        this.fields = this.convertValues(source["fields"], schema.Fields);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -65,32 +1254,75 @@ export namespace service {
 		    return a;
 		}
 	}
-	export class DeleteCategoryResponse {
-	    category_id: number;
-	    status: string;
+	
+	
+	export class SyncConflict {
+	    ID: string;
+	    Kind: string;
+	    Reason: string;
+	    ItemID: number;
+	    CategoryID: number;
+	    Mine?: AfterItem;
+	    Theirs?: AfterItem;
+	    MineName?: string;
+	    TheirsName?: string;
+	    // Go type: time
+	    QueuedAt: any;
 	
 	    static createFrom(source: any = {}) {
-	        return new DeleteCategoryResponse(source);
+	        return new SyncConflict(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.category_id = source["category_id"];
-	        this.status = source["status"];
+	        this.ID = source["ID"];
+	        this.Kind = source["Kind"];
+	        this.Reason = source["Reason"];
+	        this.ItemID = source["ItemID"];
+	        this.CategoryID = source["CategoryID"];
+	        this.Mine = this.convertValues(source["Mine"], AfterItem);
+	        this.Theirs = this.convertValues(source["Theirs"], AfterItem);
+	        this.MineName = source["MineName"];
+	        this.TheirsName = source["TheirsName"];
+	        this.QueuedAt = this.convertValues(source["QueuedAt"], null);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
-	export class DeleteItemResponse {
-	    item_id: number;
-	    status: string;
+	export class TOTPCode {
+	    code: string;
+	    remaining: number;
+	    period: number;
+	    issuer: string;
+	    account: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new DeleteItemResponse(source);
+	        return new TOTPCode(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.item_id = source["item_id"];
-	        this.status = source["status"];
+	        this.code = source["code"];
+	        this.remaining = source["remaining"];
+	        this.period = source["period"];
+	        this.issuer = source["issuer"];
+	        this.account = source["account"];
 	    }
 	}
 	export class UpdateCategoryResponse {
@@ -124,3 +1356,151 @@ export namespace service {
 
 }
 
+export namespace sshagent {
+	
+	export enum KeyAlgorithm {
+	    ED25519 = "ed25519",
+	    RSA = "rsa",
+	}
+	export class Status {
+	    running: boolean;
+	    socketPath: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Status(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.running = source["running"];
+	        this.socketPath = source["socketPath"];
+	    }
+	}
+
+}
+
+export namespace strength {
+	
+	export class CrackTime {
+	    seconds: number;
+	    display: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CrackTime(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.seconds = source["seconds"];
+	        this.display = source["display"];
+	    }
+	}
+	export class CrackTimes {
+	    onlineThrottled: CrackTime;
+	    onlineUnthrottled: CrackTime;
+	    offlineSlowHash: CrackTime;
+	    offlineFastHash: CrackTime;
+	
+	    static createFrom(source: any = {}) {
+	        return new CrackTimes(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.onlineThrottled = this.convertValues(source["onlineThrottled"], CrackTime);
+	        this.onlineUnthrottled = this.convertValues(source["onlineUnthrottled"], CrackTime);
+	        this.offlineSlowHash = this.convertValues(source["offlineSlowHash"], CrackTime);
+	        this.offlineFastHash = this.convertValues(source["offlineFastHash"], CrackTime);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Feedback {
+	    warning: string;
+	    suggestions: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Feedback(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.warning = source["warning"];
+	        this.suggestions = source["suggestions"];
+	    }
+	}
+	export class Pattern {
+	    pattern: string;
+	    token: string;
+	    guesses: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Pattern(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pattern = source["pattern"];
+	        this.token = source["token"];
+	        this.guesses = source["guesses"];
+	    }
+	}
+	export class Result {
+	    score: number;
+	    guesses: number;
+	    guessesLog10: number;
+	    crackTimes: CrackTimes;
+	    feedback: Feedback;
+	    patterns: Pattern[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Result(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.score = source["score"];
+	        this.guesses = source["guesses"];
+	        this.guessesLog10 = source["guessesLog10"];
+	        this.crackTimes = this.convertValues(source["crackTimes"], CrackTimes);
+	        this.feedback = this.convertValues(source["feedback"], Feedback);
+	        this.patterns = this.convertValues(source["patterns"], Pattern);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
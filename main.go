@@ -2,8 +2,14 @@ package main
 
 import (
 	"embed"
+	"log"
 
+	"Modsec/clientside/breach"
 	"Modsec/clientside/client"
+	"Modsec/clientside/config"
+	"Modsec/clientside/schema"
+	"Modsec/clientside/service"
+	"Modsec/clientside/sshagent"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -17,10 +23,19 @@ func main() {
 	// Create an instance of the app structure
 	app := NewApp()
 
-	client.InitClient()
+	// Load backend settings from the config file and environment
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("Failed to load config, using defaults: %v", err)
+	}
+
+	if err := client.InitClient(cfg); err != nil {
+		log.Printf("Failed to apply client config: %v", err)
+	}
+	breach.Configure(cfg)
 
 	// Create application with options
-	err := wails.Run(&options.App{
+	err = wails.Run(&options.App{
 		Title:  "Modsec",
 		Width:  1024,
 		Height: 768,
@@ -32,6 +47,12 @@ func main() {
 		Bind: []interface{}{
 			app,
 		},
+		EnumBind: []interface{}{
+			client.AllErrorCodes,
+			service.AllResolutions,
+			schema.AllFieldKinds,
+			sshagent.AllKeyAlgorithms,
+		},
 	})
 
 	if err != nil {